@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/evn/eom_backendl/config"
 	"github.com/evn/eom_backendl/db"
@@ -10,20 +12,43 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "storage" {
+		runStorage(os.Args[2:])
+		return
+	}
+
 	cfg := config.NewConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	database := db.InitDB(cfg.DatabaseDSN)
 	defer database.Close()
 
+	// Недостающие миграции применяются при старте; откат — только через `migrate down`
+	applied, err := db.MigrateUp(context.Background(), database)
+	if err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+
 	redisClient := config.NewRedisClient()
 	defer redisClient.Close()
 
-	router := routes.Setup(cfg, database, redisClient)
-
-	if err := routes.EnsureUploadDirs(); err != nil {
-		log.Fatalf("Failed to create upload directories: %v", err)
+	store, err := routes.NewStorage(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.StorageBackend, err)
 	}
 
-	go routes.AutoEndShiftsLoop(database)
+	router := routes.Setup(cfg, database, redisClient, store)
+
+	go routes.AutoEndShiftsLoop(routes.NewShiftService(database), routes.NewAuditLogger(database))
+	go routes.UploadRetentionLoop(routes.NewUploadRetention(cfg, database, store))
 
 	serverAddress := ":" + cfg.ServerPort
 	log.Printf("🚀 Server starting on %s", serverAddress)
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/evn/eom_backendl/config"
+	"github.com/evn/eom_backendl/internal/routes"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+)
+
+const storageUsage = `usage: server storage <command>
+
+commands:
+  migrate [-from DIR] [-delete-source]
+            copy files from a local uploads directory (default STORAGE_LOCAL_ROOT)
+            into the configured STORAGE_BACKEND; files already there with
+            the same size are skipped, so the command can be re-run;
+            modification times are kept (in S3 as x-amz-meta-mtime), so
+            upload retention still counts from the original upload`
+
+// runStorage — подкоманда `server storage`. База и Redis не нужны.
+func runStorage(args []string) {
+	if len(args) == 0 || args[0] != "migrate" {
+		fmt.Fprintln(os.Stderr, storageUsage)
+		os.Exit(2)
+	}
+
+	cfg := config.NewConfig()
+	flags := flag.NewFlagSet("storage migrate", flag.ExitOnError)
+	from := flags.String("from", cfg.StorageLocalRoot, "local uploads directory to copy from")
+	deleteSource := flags.Bool("delete-source", false, "delete local files after they are copied")
+	flags.Parse(args[1:])
+
+	if cfg.StorageBackend == "local" && *from == cfg.StorageLocalRoot {
+		log.Fatalf("Source and target are the same directory %s, set STORAGE_BACKEND=s3 first", *from)
+	}
+
+	ctx := context.Background()
+	target, err := routes.NewStorage(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.StorageBackend, err)
+	}
+	source := storageService.NewLocalStorage(*from)
+
+	var copied, skipped, failed int
+	err = source.List(ctx, "", func(obj storageService.ObjectInfo) error {
+		done, err := copyObject(ctx, source, target, obj)
+		if err != nil {
+			log.Printf("❌ %s: %v", obj.Key, err)
+			failed++
+			return nil
+		}
+		if done {
+			copied++
+		} else {
+			skipped++
+		}
+		if *deleteSource {
+			if err := source.Delete(ctx, obj.Key); err != nil {
+				log.Printf("⚠️ %s: copied, but failed to delete source: %v", obj.Key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Failed to list %s: %v", *from, err)
+	}
+
+	log.Printf("Copied %d, already present %d, failed %d", copied, skipped, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// copyObject копирует файл, если в целевом хранилище его нет или размер отличается.
+func copyObject(ctx context.Context, source, target storageService.Storage, obj storageService.ObjectInfo) (bool, error) {
+	existing, err := target.Stat(ctx, obj.Key)
+	if err == nil && existing.Size == obj.Size {
+		return false, nil
+	} else if err != nil && !errors.Is(err, storageService.ErrNotExist) {
+		return false, err
+	}
+
+	r, info, err := source.Get(ctx, obj.Key)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if putter, ok := target.(storageService.ModTimePutter); ok {
+		return true, putter.PutWithModTime(ctx, obj.Key, r, info.Size, contentType, info.ModTime)
+	}
+	return true, target.Put(ctx, obj.Key, r, info.Size, contentType)
+}
@@ -2,13 +2,23 @@
 package config
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
 )
 
+// insecureDefaultJWTSecret — секрет, который раньше был зашит в код.
+// Он есть в истории репозитория, поэтому с ним сервер не запускается.
+const insecureDefaultJWTSecret = "0hn/a5hwoWLn4nrmogQo+zDCM7h9203J4Iwhkp7b2ns="
+
 // type contextKey string
 
 // const (
@@ -23,6 +33,53 @@ type Config struct {
 	RedisAddr        string
 	RedisPassword    string
 	RedisDB          int
+
+	// Адреса и подсети прокси (nginx), чьим X-Real-IP и X-Forwarded-For верим
+	TrustedProxies []string
+
+	// JWT: ключ подписи (RSA/Ed25519 PEM) и старые ключи на период ротации
+	JwtPrivateKeyFile string
+	JwtPreviousKeys   []string
+
+	// Ключ подписи ссылок на загрузки. Без UPLOADS_SIGNING_KEY выводится из
+	// JWT_SECRET через HKDF: утечка одного не раскрывает другой.
+	UploadsSigningKey string
+	SignedURLTTL      time.Duration
+
+	// Проверка селфи при открытии смены
+	SelfieMaxClockSkew    time.Duration
+	SelfieRequireExif     bool
+	SelfieReuseWindow     time.Duration
+	SelfieMaxHashDistance int
+
+	// Обработка и хранение загрузок
+	UploadMaxDimension   int
+	UploadThumbDimension int
+	// Срок хранения селфи, фото заданий и отчётов. По умолчанию 0 — хранить
+	// бессрочно: селфи нужны при спорах о часах и корректировках смен.
+	// Включается явно, например UPLOAD_RETENTION_DAYS=180; после включения
+	// старые файлы удаляются при старте и дальше раз в сутки.
+	UploadRetention time.Duration
+
+	// Хранилище загрузок: local (каталог на диске) или s3
+	StorageBackend   string
+	StorageLocalRoot string
+	S3Endpoint       string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3Bucket         string
+	S3Region         string
+	S3UseSSL         bool
+	S3PresignedURLs  bool // false — файлы отдаются через /uploads этого сервера
+
+	// Табель: округление каждой смены и пороги переработки
+	TimesheetRoundingMinutes     int
+	TimesheetRoundingMode        string // nearest, up или down
+	TimesheetDailyOvertimeHours  int
+	TimesheetWeeklyOvertimeHours int // 0 — недельная переработка не считается
+
+	// Сервисы самокатов в отчёте о смене — как бот пишет их в accepted_scooters.service
+	ScooterServices []string
 }
 
 func NewConfig() *Config {
@@ -30,12 +87,41 @@ func NewConfig() *Config {
 	_ = godotenv.Load(".env")
 
 	dsn := getEnv("DATABASE_DSN", "")
-	jwtSecret := getEnv("JWT_SECRET", "0hn/a5hwoWLn4nrmogQo+zDCM7h9203J4Iwhkp7b2ns=")
+	jwtSecret := getEnv("JWT_SECRET", "")
+	jwtPrivateKeyFile := getEnv("JWT_PRIVATE_KEY_FILE", "")
+	jwtPreviousKeys := splitList(getEnv("JWT_PREVIOUS_KEYS", ""))
 	port := getEnv("SERVER_PORT", "6066")
 	telegramBotToken := getEnv("TELEGRAM_BOT_TOKEN", "")
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
 	redisPassword := getEnv("REDIS_PASSWORD", "")
 	redisDB := parseInt(getEnv("REDIS_DB", "0"))
+	trustedProxies := splitList(getEnv("TRUSTED_PROXIES", "127.0.0.1,::1"))
+	uploadsSigningKey := getEnv("UPLOADS_SIGNING_KEY", "")
+	if uploadsSigningKey == "" && jwtSecret != "" {
+		uploadsSigningKey = deriveKey(jwtSecret, "eom uploads signing key")
+	}
+	signedURLTTL := time.Duration(parseInt(getEnv("SIGNED_URL_TTL_MINUTES", "15"))) * time.Minute
+	selfieMaxClockSkew := time.Duration(parseInt(getEnv("SELFIE_MAX_CLOCK_SKEW_MINUTES", "10"))) * time.Minute
+	selfieRequireExif := getEnv("SELFIE_REQUIRE_EXIF", "false") == "true"
+	selfieReuseWindow := time.Duration(parseInt(getEnv("SELFIE_REUSE_WINDOW_DAYS", "30"))) * 24 * time.Hour
+	selfieMaxHashDistance := parseInt(getEnv("SELFIE_MAX_HASH_DISTANCE", "6"))
+	uploadMaxDimension := parseInt(getEnv("UPLOAD_MAX_DIMENSION", "1600"))
+	uploadThumbDimension := parseInt(getEnv("UPLOAD_THUMB_DIMENSION", "320"))
+	uploadRetention := time.Duration(parseInt(getEnv("UPLOAD_RETENTION_DAYS", "0"))) * 24 * time.Hour
+	storageBackend := getEnv("STORAGE_BACKEND", "local")
+	storageLocalRoot := getEnv("STORAGE_LOCAL_ROOT", "./uploads")
+	s3Endpoint := getEnv("S3_ENDPOINT", "")
+	s3AccessKey := getEnv("S3_ACCESS_KEY", "")
+	s3SecretKey := getEnv("S3_SECRET_KEY", "")
+	s3Bucket := getEnv("S3_BUCKET", "")
+	s3Region := getEnv("S3_REGION", "us-east-1")
+	s3UseSSL := getEnv("S3_USE_SSL", "true") == "true"
+	s3PresignedURLs := getEnv("S3_PRESIGNED_URLS", "true") == "true"
+	timesheetRoundingMinutes := parseInt(getEnv("TIMESHEET_ROUNDING_MINUTES", "15"))
+	timesheetRoundingMode := getEnv("TIMESHEET_ROUNDING_MODE", "nearest")
+	timesheetDailyOvertimeHours := parseInt(getEnv("TIMESHEET_DAILY_OVERTIME_HOURS", "8"))
+	timesheetWeeklyOvertimeHours := parseInt(getEnv("TIMESHEET_WEEKLY_OVERTIME_HOURS", "40"))
+	scooterServices := splitList(getEnv("SCOOTER_SERVICES", "JET,YANDEX,WHOOSH,BOLT"))
 
 	return &Config{
 		DatabaseDSN:      dsn,
@@ -45,9 +131,90 @@ func NewConfig() *Config {
 		RedisAddr:        redisAddr,
 		RedisPassword:    redisPassword,
 		RedisDB:          redisDB,
+
+		TrustedProxies: trustedProxies,
+
+		JwtPrivateKeyFile: jwtPrivateKeyFile,
+		JwtPreviousKeys:   jwtPreviousKeys,
+
+		UploadsSigningKey: uploadsSigningKey,
+		SignedURLTTL:      signedURLTTL,
+
+		SelfieMaxClockSkew:    selfieMaxClockSkew,
+		SelfieRequireExif:     selfieRequireExif,
+		SelfieReuseWindow:     selfieReuseWindow,
+		SelfieMaxHashDistance: selfieMaxHashDistance,
+
+		UploadMaxDimension:   uploadMaxDimension,
+		UploadThumbDimension: uploadThumbDimension,
+		UploadRetention:      uploadRetention,
+
+		StorageBackend:   storageBackend,
+		StorageLocalRoot: storageLocalRoot,
+		S3Endpoint:       s3Endpoint,
+		S3AccessKey:      s3AccessKey,
+		S3SecretKey:      s3SecretKey,
+		S3Bucket:         s3Bucket,
+		S3Region:         s3Region,
+		S3UseSSL:         s3UseSSL,
+		S3PresignedURLs:  s3PresignedURLs,
+
+		TimesheetRoundingMinutes:     timesheetRoundingMinutes,
+		TimesheetRoundingMode:        timesheetRoundingMode,
+		TimesheetDailyOvertimeHours:  timesheetDailyOvertimeHours,
+		TimesheetWeeklyOvertimeHours: timesheetWeeklyOvertimeHours,
+
+		ScooterServices: scooterServices,
 	}
 }
 
+// deriveKey выводит из секрета отдельный ключ под назначение purpose.
+func deriveKey(secret, purpose string) string {
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, purpose, 32)
+	if err != nil {
+		// Ошибка возможна только при длине ключа больше 255*32 байт
+		panic(err)
+	}
+	return hex.EncodeToString(key)
+}
+
+// Validate проверяет настройки, без которых сервер запускать нельзя.
+func (c *Config) Validate() error {
+	if c.JwtSecret == insecureDefaultJWTSecret || c.UploadsSigningKey == insecureDefaultJWTSecret {
+		return errors.New("JWT_SECRET is set to the old built-in default, generate a new secret or key")
+	}
+	if c.JwtSecret == "" && c.JwtPrivateKeyFile == "" {
+		return errors.New("either JWT_PRIVATE_KEY_FILE or JWT_SECRET must be set")
+	}
+	if c.JwtSecret != "" && len(c.JwtSecret) < 32 {
+		return errors.New("JWT_SECRET must be at least 32 characters")
+	}
+	if c.UploadsSigningKey == "" {
+		return errors.New("UPLOADS_SIGNING_KEY must be set when JWT_SECRET is not")
+	}
+	if c.UploadMaxDimension <= 0 || c.UploadThumbDimension <= 0 {
+		return errors.New("UPLOAD_MAX_DIMENSION and UPLOAD_THUMB_DIMENSION must be positive numbers")
+	}
+	switch c.StorageBackend {
+	case "local":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+			return errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set for STORAGE_BACKEND=s3")
+		}
+	default:
+		return errors.New("STORAGE_BACKEND must be either local or s3")
+	}
+	switch c.TimesheetRoundingMode {
+	case "nearest", "up", "down":
+	default:
+		return errors.New("TIMESHEET_ROUNDING_MODE must be nearest, up or down")
+	}
+	if c.TimesheetRoundingMinutes < 0 || c.TimesheetDailyOvertimeHours < 0 || c.TimesheetWeeklyOvertimeHours < 0 {
+		return errors.New("TIMESHEET_ROUNDING_MINUTES and TIMESHEET_*_OVERTIME_HOURS must not be negative")
+	}
+	return nil
+}
+
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -55,6 +222,16 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func parseInt(s string) int {
 	n, _ := strconv.Atoi(s)
 	return n
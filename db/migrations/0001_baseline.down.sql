@@ -0,0 +1,8 @@
+-- Откат базовой схемы удаляет все данные приложения
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS slots;
+DROP TABLE IF EXISTS available_time_slots;
+DROP TABLE IF EXISTS zones;
+DROP TABLE IF EXISTS app_versions;
+DROP TABLE IF EXISTS maps;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,149 @@
+-- Базовая схема — то, что раньше применял db/schema.sql при каждом старте.
+-- Все операторы идемпотентны: на существующей базе миграция только
+-- отмечается в schema_migrations.
+
+-- Таблица пользователей
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username TEXT UNIQUE NOT NULL,
+    password_hash TEXT,
+    first_name TEXT,
+    telegram_id BIGINT UNIQUE,
+    role TEXT NOT NULL DEFAULT 'user',
+    status TEXT DEFAULT 'active',
+    is_active BOOLEAN DEFAULT TRUE,
+    avatar_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Таблица карт
+CREATE TABLE IF NOT EXISTS maps (
+    id SERIAL PRIMARY KEY,
+    city TEXT NOT NULL,
+    description TEXT,
+    file_name TEXT NOT NULL,
+    file_size BIGINT NOT NULL,
+    upload_date TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Таблица версий приложения
+CREATE TABLE IF NOT EXISTS app_versions (
+    id SERIAL PRIMARY KEY,
+    platform TEXT NOT NULL,
+    version TEXT NOT NULL,
+    build_number INTEGER NOT NULL,
+    release_notes TEXT,
+    download_url TEXT NOT NULL,
+    min_sdk_version INTEGER DEFAULT 0,
+    is_mandatory BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_app_versions_platform_version ON app_versions(platform, version);
+CREATE INDEX IF NOT EXISTS idx_app_versions_active ON app_versions(is_active);
+CREATE INDEX IF NOT EXISTS idx_app_versions_build ON app_versions(build_number);
+
+-- Первый релиз заводим только в пустую таблицу: версии, загруженные
+-- через админку, не трогаем
+INSERT INTO app_versions (platform, version, build_number, release_notes, download_url, is_mandatory, is_active, created_at, updated_at)
+SELECT v.platform, '1.0.0', 100, 'Первый релиз приложения', v.download_url, FALSE, TRUE, NOW(), NOW()
+FROM (VALUES
+    ('android', 'https://eom-sharing.duckdns.org/uploads/app/app-release.apk'),
+    ('ios', 'https://eom-sharing.duckdns.org/uploads/app/app-release.ipa')
+) AS v(platform, download_url)
+WHERE NOT EXISTS (SELECT 1 FROM app_versions a WHERE a.platform = v.platform);
+
+
+CREATE TABLE IF NOT EXISTS zones (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Таблица доступных временных слотов
+CREATE TABLE IF NOT EXISTS available_time_slots (
+    id SERIAL PRIMARY KEY,
+    slot_time_range TEXT UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Таблица смен (слотов)
+CREATE TABLE IF NOT EXISTS slots (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE,
+    slot_time_range TEXT NOT NULL,
+    position TEXT NOT NULL,
+    zone TEXT NOT NULL,
+    selfie_path TEXT,
+    worked_duration INTEGER, -- в секундах
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Индексы для производительности
+CREATE INDEX IF NOT EXISTS idx_slots_user_id ON slots(user_id);
+CREATE INDEX IF NOT EXISTS idx_slots_active ON slots(user_id, end_time) WHERE end_time IS NULL;
+CREATE INDEX IF NOT EXISTS idx_slots_zone ON slots(zone);
+CREATE INDEX IF NOT EXISTS idx_slots_time_range ON slots(slot_time_range);
+
+
+INSERT INTO available_time_slots (slot_time_range, description) VALUES
+    ('07:00-15:00', 'Утренняя смена'),
+    ('15:00-23:00', 'Вечерняя смена'),
+    ('07:00-23:00', 'Полная смена')
+ON CONFLICT (slot_time_range) DO NOTHING;
+
+-- Журнал действий администраторов
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INTEGER,
+    actor_role TEXT,
+    action TEXT NOT NULL,
+    target_type TEXT,
+    target_id TEXT,
+    before JSONB,
+    after JSONB,
+    ip TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id);
+
+-- Мягкое удаление пользователей: смены не должны пропадать вместе с сотрудником
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'slots_user_id_fkey' AND confdeltype = 'c'
+    ) THEN
+        ALTER TABLE slots DROP CONSTRAINT slots_user_id_fkey;
+        ALTER TABLE slots ADD CONSTRAINT slots_user_id_fkey
+            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+    END IF;
+END $$;
+
+-- Слияние дубликатов: удалённая запись ссылается на основной аккаунт
+ALTER TABLE users ADD COLUMN IF NOT EXISTS merged_into_user_id INTEGER REFERENCES users(id);
+
+-- Жизненный цикл пароля: временные пароли от администратора
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
+
+-- Очередь заявок: причина решения и кто его принял
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_changed_by INTEGER REFERENCES users(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS registration_submitted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_users_pending ON users(status) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- Откат ничего не удаляет. Миграция лишь описывает колонки и таблицы, которые
+-- код использовал и до неё (roles, positions, promo_codes, active_promo_brand,
+-- users.last_name и др.): в рабочих базах они созданы раньше и хранят данные,
+-- а IF NOT EXISTS не позволяет отличить их от созданных этой миграцией.
+-- Удалить их при необходимости можно только вручную.
@@ -0,0 +1,65 @@
+-- Колонки и таблицы, которые код использует, но ни одна схема не создавала
+
+-- Профиль и промокоды пользователя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_name TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS zone TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS promo_codes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Справочник ролей
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('superadmin', 'Полный доступ ко всем функциям'),
+    ('admin', 'Административные функции'),
+    ('coordinator', 'Координатор зон'),
+    ('supervisor', 'Супервайзер'),
+    ('scout', 'Скаут'),
+    ('user', 'Обычный пользователь')
+ON CONFLICT (name) DO NOTHING;
+
+-- Геопозиции сотрудников
+CREATE TABLE IF NOT EXISTS positions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    speed DOUBLE PRECISION,
+    accuracy DOUBLE PRECISION,
+    battery INT CHECK (battery BETWEEN 0 AND 100),
+    event TEXT DEFAULT 'heartbeat',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_positions_user_id ON positions(user_id);
+CREATE INDEX IF NOT EXISTS idx_positions_created_at ON positions(created_at);
+
+-- Промокоды партнёров
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    brand VARCHAR(20) NOT NULL CHECK (brand IN ('JET', 'YANDEX', 'WHOOSH', 'BOLT')),
+    promo_code VARCHAR(100) NOT NULL,
+    valid_until DATE NOT NULL, -- до какого числа можно выдать
+    assigned_to_user_id INTEGER,
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    created_by_admin_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_brand_valid ON promo_codes(brand, valid_until);
+CREATE INDEX IF NOT EXISTS idx_promo_unclaimed ON promo_codes(brand, valid_until, assigned_to_user_id)
+    WHERE assigned_to_user_id IS NULL;
+
+-- Активный бренд промокодов: не больше одной строки, upsert идёт
+-- через ON CONFLICT ((brand IS NOT NULL))
+CREATE TABLE IF NOT EXISTS active_promo_brand (
+    brand VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_active_promo_brand_single ON active_promo_brand((brand IS NOT NULL));
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_slots_active ON slots(user_id, end_time) WHERE end_time IS NULL;
+DROP INDEX IF EXISTS idx_slots_one_open_per_user;
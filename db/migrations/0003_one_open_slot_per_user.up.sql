@@ -0,0 +1,16 @@
+-- Не больше одной открытой смены на пользователя. Дубликаты, которые успели
+-- появиться из-за двойного нажатия, закрываются в момент начала более поздней смены.
+UPDATE slots s
+SET end_time = d.next_start,
+    worked_duration = GREATEST(EXTRACT(EPOCH FROM (d.next_start - s.start_time))::INTEGER, 0)
+FROM (
+    SELECT id, LEAD(start_time) OVER (PARTITION BY user_id ORDER BY start_time, id) AS next_start
+    FROM slots
+    WHERE end_time IS NULL
+) d
+WHERE s.id = d.id AND d.next_start IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_slots_one_open_per_user ON slots(user_id) WHERE end_time IS NULL;
+
+-- Уникальный индекс покрывает поиск активной смены
+DROP INDEX IF EXISTS idx_slots_active;
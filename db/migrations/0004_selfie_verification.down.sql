@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_slots_selfie_phash_recent;
+
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_verification;
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_phash;
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_lon;
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_lat;
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_taken_at;
@@ -0,0 +1,9 @@
+-- Метаданные селфи: EXIF вырезается из файла, но время и место съёмки остаются в смене
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_taken_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_lat DOUBLE PRECISION;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_lon DOUBLE PRECISION;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_phash BIGINT;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_verification JSONB;
+
+-- Поиск повторно отправленных фото идёт по недавним сменам
+CREATE INDEX IF NOT EXISTS idx_slots_selfie_phash_recent ON slots(start_time) WHERE selfie_phash IS NOT NULL;
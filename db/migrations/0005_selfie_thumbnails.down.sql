@@ -0,0 +1 @@
+ALTER TABLE slots DROP COLUMN IF EXISTS selfie_thumb_path;
@@ -0,0 +1,2 @@
+-- Миниатюра селфи для списков в админке; у старых смен её нет, отдаётся оригинал
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS selfie_thumb_path TEXT;
@@ -0,0 +1,4 @@
+ALTER TABLE available_time_slots DROP COLUMN IF EXISTS max_break_minutes;
+ALTER TABLE available_time_slots DROP COLUMN IF EXISTS max_breaks;
+ALTER TABLE slots DROP COLUMN IF EXISTS break_duration;
+DROP TABLE IF EXISTS slot_breaks;
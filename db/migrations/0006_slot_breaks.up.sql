@@ -0,0 +1,19 @@
+-- Перерывы внутри смены; worked_duration считается без них
+CREATE TABLE IF NOT EXISTS slot_breaks (
+    id SERIAL PRIMARY KEY,
+    slot_id INTEGER NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    auto_closed BOOLEAN NOT NULL DEFAULT FALSE -- закрыт по лимиту длительности, а не сотрудником
+);
+
+CREATE INDEX IF NOT EXISTS idx_slot_breaks_slot ON slot_breaks(slot_id);
+-- Не больше одного незавершённого перерыва на смену
+CREATE UNIQUE INDEX IF NOT EXISTS idx_slot_breaks_one_open ON slot_breaks(slot_id) WHERE ended_at IS NULL;
+
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS break_duration INTEGER NOT NULL DEFAULT 0; -- в секундах
+
+-- Лимиты перерывов по типу смены
+ALTER TABLE available_time_slots ADD COLUMN IF NOT EXISTS max_breaks INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE available_time_slots ADD COLUMN IF NOT EXISTS max_break_minutes INTEGER NOT NULL DEFAULT 30;
+UPDATE available_time_slots SET max_breaks = 2, max_break_minutes = 60 WHERE slot_time_range = '07:00-23:00';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_slots_start_time;
+DROP TABLE IF EXISTS timesheet_periods;
@@ -0,0 +1,13 @@
+-- Утверждённые табели по месяцам. Нет строки — табель открыт и считается по сменам на лету.
+CREATE TABLE IF NOT EXISTS timesheet_periods (
+    period_start DATE PRIMARY KEY, -- первое число месяца
+    status TEXT NOT NULL CHECK (status IN ('approved', 'locked')), -- locked — передан в расчёт зарплаты
+    snapshot JSONB NOT NULL, -- табель на момент утверждения
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    locked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Табель выбирает закрытые смены по времени начала
+CREATE INDEX IF NOT EXISTS idx_slots_start_time ON slots(start_time);
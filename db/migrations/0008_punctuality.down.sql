@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS shift_assignments;
+ALTER TABLE slots DROP COLUMN IF EXISTS end_reason;
+ALTER TABLE slots DROP COLUMN IF EXISTS early_leave_minutes;
+ALTER TABLE slots DROP COLUMN IF EXISTS late_minutes;
@@ -0,0 +1,21 @@
+-- Пунктуальность смены: опоздание и ранний уход в минутах (0 — в пределах допуска)
+-- и кто закрыл смену: user — сотрудник, admin — администратор, auto — по окончании слота
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS late_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS early_leave_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS end_reason TEXT CHECK (end_reason IN ('user', 'admin', 'auto'));
+
+-- Плановые выходы: кто и в какой слот должен выйти в этот день.
+-- slot_id — смена, которой выход отработан; NULL после конца слота — невыход.
+CREATE TABLE IF NOT EXISTS shift_assignments (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shift_date DATE NOT NULL,
+    slot_time_range TEXT NOT NULL,
+    zone TEXT NOT NULL DEFAULT '',
+    slot_id INTEGER REFERENCES slots(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shift_assignments_unique ON shift_assignments(user_id, shift_date, slot_time_range);
+CREATE INDEX IF NOT EXISTS idx_shift_assignments_date ON shift_assignments(shift_date);
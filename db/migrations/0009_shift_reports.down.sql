@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_slots_zone_end_time;
+DROP TABLE IF EXISTS shift_reports;
@@ -0,0 +1,12 @@
+-- Отчёт сотрудника при закрытии смены; заметки видит следующий скаут в той же зоне
+CREATE TABLE IF NOT EXISTS shift_reports (
+    slot_id INTEGER PRIMARY KEY REFERENCES slots(id) ON DELETE CASCADE,
+    scooters JSONB NOT NULL DEFAULT '{}', -- сервис (как в accepted_scooters.service) → собрано самокатов
+    issues JSONB NOT NULL DEFAULT '[]',   -- найденные проблемы
+    notes TEXT NOT NULL DEFAULT '',
+    photos JSONB NOT NULL DEFAULT '[]',   -- пути /uploads/reports/...
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Отчёты ищутся по зоне и концу смены
+CREATE INDEX IF NOT EXISTS idx_slots_zone_end_time ON slots(zone, end_time);
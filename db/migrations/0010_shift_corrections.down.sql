@@ -0,0 +1,3 @@
+ALTER TABLE slots DROP COLUMN IF EXISTS voided_at;
+ALTER TABLE slots DROP COLUMN IF EXISTS corrected_at;
+DROP TABLE IF EXISTS shift_corrections;
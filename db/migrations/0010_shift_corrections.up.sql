@@ -0,0 +1,21 @@
+-- Правки смен администратором: закрытие, исправление времени, зоны и слота,
+-- аннулирование. before — значения до правки (у первой правки — исходные),
+-- after — после; строки не меняются и не удаляются.
+CREATE TABLE IF NOT EXISTS shift_corrections (
+    id SERIAL PRIMARY KEY,
+    slot_id INTEGER NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('force_end', 'edit', 'void')),
+    reason TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    before JSONB NOT NULL,
+    after JSONB NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_corrections_slot ON shift_corrections(slot_id);
+
+-- Последняя правка и аннулирование. Аннулированная смена остаётся в slots,
+-- но не попадает в табель, пунктуальность и отчёты.
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS corrected_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP WITH TIME ZONE;
@@ -6,13 +6,14 @@ import (
 	"net/http"
 
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
 )
 
 // GetActiveShiftsForAllHandler возвращает активные смены всех пользователей.
-func GetActiveShiftsForAllHandler(db *sql.DB) http.HandlerFunc {
+func GetActiveShiftsForAllHandler(db *sql.DB, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		rows, err := db.Query(`
-			SELECT s.id, s.user_id, u.username, s.start_time, s.slot_time_range, s.position, s.zone, s.selfie_path
+			SELECT s.id, s.user_id, u.username, s.start_time, s.slot_time_range, s.position, s.zone, s.selfie_path, COALESCE(s.selfie_thumb_path, '')
 			FROM slots s
 			JOIN users u ON s.user_id = u.id
 			WHERE s.end_time IS NULL
@@ -27,8 +28,8 @@ func GetActiveShiftsForAllHandler(db *sql.DB) http.HandlerFunc {
 		var shifts []map[string]interface{}
 		for rows.Next() {
 			var id, userID int
-			var username, startTime, slotTimeRange, position, zone, selfie string
-			if err := rows.Scan(&id, &userID, &username, &startTime, &slotTimeRange, &position, &zone, &selfie); err != nil {
+			var username, startTime, slotTimeRange, position, zone, selfie, selfieThumb string
+			if err := rows.Scan(&id, &userID, &username, &startTime, &slotTimeRange, &position, &zone, &selfie, &selfieThumb); err != nil {
 				log.Printf("Error scanning row: %v", err)
 				continue
 			}
@@ -40,7 +41,8 @@ func GetActiveShiftsForAllHandler(db *sql.DB) http.HandlerFunc {
 				"slot_time_range": slotTimeRange,
 				"position":        position,
 				"zone":            zone,
-				"selfie":          selfie,
+				"selfie":          signer.Sign(selfie),
+				"selfie_thumb":    signer.SignPreview(selfieThumb, selfie),
 			})
 		}
 		response.RespondWithJSON(w, http.StatusOK, shifts)
@@ -3,11 +3,18 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	telegramService "github.com/evn/eom_backendl/internal/services/telegram"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -16,7 +23,7 @@ type CreateUserRequest struct {
 	FirstName string `json:"first_name"`
 }
 
-func CreateUserHandler(db *sql.DB) http.HandlerFunc {
+func CreateUserHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var input CreateUserRequest
 
@@ -26,7 +33,7 @@ func CreateUserHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if input.Username == "" {
-			response.RespondWithError(w, http.StatusBadRequest, "Username is required")
+			response.RespondWithValidation(w, response.FieldError{Field: "username", Code: response.FieldRequired})
 			return
 		}
 
@@ -42,22 +49,43 @@ func CreateUserHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		_, err = db.Exec(
-			"INSERT INTO users (username, first_name, role) VALUES ($1, $2, $3)",
+		// Скаут входит по временному паролю и сразу меняет его
+		password, passwordHash, err := newTemporaryPassword()
+		if err != nil {
+			log.Printf("Failed to generate temporary password: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "DB error creating user")
+			return
+		}
+
+		var newUserID int
+		err = db.QueryRow(`
+			INSERT INTO users (username, first_name, role, password_hash, must_change_password)
+			VALUES ($1, $2, $3, $4, TRUE) RETURNING id`,
 			input.Username,
 			input.FirstName,
 			"scout",
-		)
+			passwordHash,
+		).Scan(&newUserID)
 		if err != nil {
 			log.Printf("DB error creating user: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "DB error creating user")
 			return
 		}
 
-		response.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": "User created successfully"})
+		auditLog.Record(r, "user.create", "user", strconv.Itoa(newUserID), nil, map[string]string{
+			"username":   input.Username,
+			"first_name": input.FirstName,
+			"role":       "scout",
+		})
+
+		response.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+			"message":            "User created successfully",
+			"user_id":            newUserID,
+			"temporary_password": password,
+		})
 	}
 }
-func UpdateUserRoleHandler(db *sql.DB) http.HandlerFunc {
+func UpdateUserRoleHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDStr := chi.URLParam(r, "userID")
 		userID, err := strconv.Atoi(userIDStr)
@@ -77,21 +105,42 @@ func UpdateUserRoleHandler(db *sql.DB) http.HandlerFunc {
 		var roleExists int
 		err = db.QueryRow("SELECT COUNT(*) FROM roles WHERE name = $1", update.Role).Scan(&roleExists)
 		if err != nil || roleExists == 0 {
-			response.RespondWithError(w, http.StatusBadRequest, "Role does not exist")
+			response.RespondWithValidation(w, response.FieldError{Field: "role", Code: response.FieldInvalid, Params: map[string]interface{}{"value": update.Role}})
+			return
+		}
+
+		var oldRole string
+		err = db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&oldRole)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to load role of user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user role")
 			return
 		}
 
 		_, err = db.Exec("UPDATE users SET role = $1 WHERE id = $2", update.Role, userID)
 		if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user role: "+err.Error())
+			log.Printf("Failed to update role of user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user role")
 			return
 		}
 
+		auditLog.Record(r, "user.role_change", "user", strconv.Itoa(userID),
+			map[string]string{"role": oldRole},
+			map[string]string{"role": update.Role})
+
+		// Роль зашита в токен — старые токены должны перестать работать
+		if oldRole != update.Role {
+			forceLogout(r, jwtService, userID)
+		}
+
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User role updated successfully"})
 	}
 }
 
-func UpdateUserStatusHandler(db *sql.DB) http.HandlerFunc {
+func UpdateUserStatusHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService, bot *telegramService.BotClient) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Получаем userID из URL
 		userIDStr := chi.URLParam(r, "userID")
@@ -103,7 +152,8 @@ func UpdateUserStatusHandler(db *sql.DB) http.HandlerFunc {
 
 		// Декодируем тело запроса
 		var req struct {
-			Status string `json:"status"` // Ожидаем "active" или "pending"
+			Status string `json:"status"` // active, pending, rejected или blocked
+			Reason string `json:"reason"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
@@ -111,30 +161,71 @@ func UpdateUserStatusHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Проверяем, что статус допустимый
-		if req.Status != "active" && req.Status != "pending" {
-			response.RespondWithError(w, http.StatusBadRequest, "Invalid status value. Must be 'active' or 'pending'")
+		if !validStatuses[req.Status] {
+			response.RespondWithValidation(w, response.FieldError{Field: "status", Code: response.FieldOneOf, Params: map[string]interface{}{"allowed": "active, pending, rejected, blocked"}})
+			return
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if (req.Status == StatusRejected || req.Status == StatusBlocked) && req.Reason == "" {
+			response.RespondWithError(w, http.StatusBadRequest, "Reason is required")
 			return
 		}
 
 		// Подготавливаем значения для БД
 		isActive := 0
-		if req.Status == "active" {
+		if req.Status == StatusActive {
 			isActive = 1
 		}
 
+		var before struct {
+			Status     sql.NullString
+			IsActive   sql.NullBool
+			TelegramID sql.NullInt64
+		}
+		err = db.QueryRow("SELECT status, is_active, telegram_id FROM users WHERE id = $1", userID).
+			Scan(&before.Status, &before.IsActive, &before.TelegramID)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to load status of user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user status")
+			return
+		}
+
 		// Обновляем запись в БД
-		_, err = db.Exec("UPDATE users SET status = $1, is_active = $2 WHERE id = $3", req.Status, isActive, userID)
+		_, err = db.Exec(`
+			UPDATE users
+			SET status = $1, is_active = $2, status_reason = NULLIF($3, ''),
+			    status_changed_at = NOW(), status_changed_by = $4
+			WHERE id = $5`,
+			req.Status, isActive, req.Reason, actorID(r), userID,
+		)
 		if err != nil {
 			log.Printf("Failed to update user %d status: %v", userID, err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user status")
 			return
 		}
 
+		auditLog.Record(r, "user.status_change", "user", strconv.Itoa(userID),
+			map[string]interface{}{"status": before.Status.String, "is_active": before.IsActive.Bool},
+			map[string]interface{}{"status": req.Status, "is_active": isActive == 1, "reason": req.Reason})
+
+		if isActive == 0 {
+			forceLogout(r, jwtService, userID)
+		}
+		if before.Status.String != req.Status {
+			notifyStatusChange(bot, userID, before.TelegramID, req.Status, req.Reason)
+		}
+
 		// Отправляем успешный ответ
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User status updated successfully"})
 	}
 }
-func DeleteUserHandler(db *sql.DB) http.HandlerFunc {
+
+// DeleteUserHandler помечает пользователя удалённым. Строка в users остаётся,
+// чтобы смены и отчёты продолжали ссылаться на сотрудника.
+func DeleteUserHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDStr := chi.URLParam(r, "userID")
 		userID, err := strconv.Atoi(userIDStr)
@@ -143,30 +234,243 @@ func DeleteUserHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		result, err := db.Exec("DELETE FROM users WHERE id = $1", userID)
-		if err != nil {
+		var before struct {
+			Username  string
+			FirstName sql.NullString
+			Role      string
+			Status    sql.NullString
+			DeletedAt sql.NullTime
+		}
+		err = db.QueryRow("SELECT username, first_name, role, status, deleted_at FROM users WHERE id = $1", userID).
+			Scan(&before.Username, &before.FirstName, &before.Role, &before.Status, &before.DeletedAt)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to load user %d before delete: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete user")
+			return
+		}
+		if before.DeletedAt.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "user_already_deleted")
+			return
+		}
+
+		var deletedAt time.Time
+		err = db.QueryRow(`
+			UPDATE users SET deleted_at = NOW(), is_active = FALSE
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING deleted_at
+		`, userID).Scan(&deletedAt)
+		if err == sql.ErrNoRows {
+			response.RespondWithCode(w, http.StatusConflict, "user_already_deleted")
+			return
+		} else if err != nil {
 			log.Printf("Failed to delete user: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete user")
 			return
 		}
 
-		rowsAffected, err := result.RowsAffected()
+		auditLog.Record(r, "user.delete", "user", strconv.Itoa(userID), map[string]string{
+			"username":   before.Username,
+			"first_name": before.FirstName.String,
+			"role":       before.Role,
+			"status":     before.Status.String,
+		}, map[string]interface{}{"deleted_at": deletedAt})
+		forceLogout(r, jwtService, userID)
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
+	}
+}
+
+// RestoreUserHandler снимает пометку об удалении. Обезличенных пользователей
+// восстановить нельзя — их данные уже стёрты.
+func RestoreUserHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		var deletedAt, anonymizedAt sql.NullTime
+		var status sql.NullString
+		var mergedInto sql.NullInt64
+		err = db.QueryRow("SELECT deleted_at, anonymized_at, status, merged_into_user_id FROM users WHERE id = $1", userID).
+			Scan(&deletedAt, &anonymizedAt, &status, &mergedInto)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to load user %d before restore: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to restore user")
+			return
+		}
+		if !deletedAt.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "user_not_deleted")
+			return
+		}
+		if anonymizedAt.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "user_anonymized")
+			return
+		}
+		if mergedInto.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "user_merged")
+			return
+		}
+
+		isActive := status.String == "active"
+		_, err = db.Exec("UPDATE users SET deleted_at = NULL, is_active = $1 WHERE id = $2", isActive, userID)
 		if err != nil {
-			log.Printf("Failed to get rows affected: %v", err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to check deletion status")
+			log.Printf("Failed to restore user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to restore user")
 			return
 		}
 
-		if rowsAffected == 0 {
+		auditLog.Record(r, "user.restore", "user", strconv.Itoa(userID),
+			map[string]interface{}{"deleted_at": deletedAt.Time},
+			map[string]interface{}{"deleted_at": nil, "is_active": isActive})
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User restored successfully"})
+	}
+}
+
+// AnonymizeUserHandler стирает персональные данные по запросу сотрудника:
+// профиль, историю геопозиций и селфи смен (файлы, миниатюры, координаты съёмки).
+// Смены остаются, но ссылаются на обезличенную запись.
+func AnonymizeUserHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService, store storageService.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		tx, err := db.BeginTx(r.Context(), nil)
+		if err != nil {
+			log.Printf("Failed to begin anonymize transaction: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		defer tx.Rollback()
+
+		var before struct {
+			Username     string
+			FirstName    sql.NullString
+			AnonymizedAt sql.NullTime
+		}
+		err = tx.QueryRow("SELECT username, first_name, anonymized_at FROM users WHERE id = $1 FOR UPDATE", userID).
+			Scan(&before.Username, &before.FirstName, &before.AnonymizedAt)
+		if err == sql.ErrNoRows {
 			response.RespondWithError(w, http.StatusNotFound, "User not found")
 			return
+		} else if err != nil {
+			log.Printf("Failed to load user %d before anonymize: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+		if before.AnonymizedAt.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "user_already_anonymized")
+			return
 		}
 
-		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
+		selfies, err := userSelfiePaths(tx, userID)
+		if err != nil {
+			log.Printf("Failed to load selfies of user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+
+		anonUsername := fmt.Sprintf("deleted_user_%d", userID)
+		for _, step := range []struct {
+			name  string
+			query string
+			args  []interface{}
+		}{
+			{"user", `
+				UPDATE users SET
+					username = $1,
+					first_name = 'Удалённый пользователь',
+					last_name = NULL,
+					phone = NULL,
+					avatar_url = NULL,
+					telegram_id = NULL,
+					password_hash = NULL,
+					is_active = FALSE,
+					deleted_at = COALESCE(deleted_at, NOW()),
+					anonymized_at = NOW()
+				WHERE id = $2`, []interface{}{anonUsername, userID}},
+			{"positions", "DELETE FROM positions WHERE user_id = $1", []interface{}{strconv.Itoa(userID)}},
+			{"selfies", `
+				UPDATE slots SET
+					selfie_path = '',
+					selfie_thumb_path = NULL,
+					selfie_taken_at = NULL,
+					selfie_lat = NULL,
+					selfie_lon = NULL,
+					selfie_phash = NULL,
+					selfie_verification = NULL
+				WHERE user_id = $1`, []interface{}{userID}},
+		} {
+			if _, err := tx.Exec(step.query, step.args...); err != nil {
+				log.Printf("Failed to anonymize %s of user %d: %v", step.name, userID, err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Failed to anonymize user")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("Failed to commit anonymize of user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to anonymize user")
+			return
+		}
+
+		// Файлы удаляем после коммита, чтобы смены не ссылались на несуществующие фото
+		for _, uploadPath := range selfies {
+			key, ok := storageService.KeyFromPath(uploadPath)
+			if !ok {
+				continue
+			}
+			if err := store.Delete(r.Context(), key); err != nil {
+				log.Printf("Failed to delete selfie %s of user %d: %v", key, userID, err)
+			}
+		}
+
+		// Персональные данные в журнал не пишем — только факт обезличивания.
+		auditLog.Record(r, "user.anonymize", "user", strconv.Itoa(userID), nil,
+			map[string]interface{}{"username": anonUsername, "deleted_selfies": len(selfies)})
+		forceLogout(r, jwtService, userID)
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User anonymized successfully"})
 	}
 }
 
-func CreateRoleHandler(db *sql.DB) http.HandlerFunc {
+// userSelfiePaths — пути к селфи и миниатюрам всех смен пользователя.
+func userSelfiePaths(tx *sql.Tx, userID int) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT COALESCE(selfie_path, ''), COALESCE(selfie_thumb_path, '')
+		FROM slots WHERE user_id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var selfie, thumb string
+		if err := rows.Scan(&selfie, &thumb); err != nil {
+			return nil, err
+		}
+		for _, path := range []string{selfie, thumb} {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, rows.Err()
+}
+
+func CreateRoleHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var newRole struct {
 			Name string `json:"name"`
@@ -178,15 +482,18 @@ func CreateRoleHandler(db *sql.DB) http.HandlerFunc {
 
 		_, err := db.Exec("INSERT INTO roles (name) VALUES ($1)", newRole.Name)
 		if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to create new role: "+err.Error())
+			log.Printf("Failed to create role %q: %v", newRole.Name, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to create new role")
 			return
 		}
 
+		auditLog.Record(r, "role.create", "role", newRole.Name, nil, map[string]string{"name": newRole.Name})
+
 		response.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": "Role created successfully"})
 	}
 }
 
-func DeleteRoleHandler(db *sql.DB) http.HandlerFunc {
+func DeleteRoleHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var roleToDelete struct {
 			Name string `json:"name"`
@@ -197,16 +504,27 @@ func DeleteRoleHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if roleToDelete.Name == "user" || roleToDelete.Name == "superadmin" {
-			response.RespondWithError(w, http.StatusBadRequest, "Cannot delete this role")
+			response.RespondWithCode(w, http.StatusBadRequest, "role_protected")
 			return
 		}
 
 		_, err := db.Exec("DELETE FROM roles WHERE name = $1", roleToDelete.Name)
 		if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete role: "+err.Error())
+			log.Printf("Failed to delete role %q: %v", roleToDelete.Name, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete role")
 			return
 		}
 
+		auditLog.Record(r, "role.delete", "role", roleToDelete.Name, map[string]string{"name": roleToDelete.Name}, nil)
+
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Role deleted successfully"})
 	}
 }
+
+// forceLogout завершает все сессии пользователя. Ошибка Redis не отменяет
+// уже сохранённое изменение, поэтому только логируется.
+func forceLogout(r *http.Request, jwtService *authService.JWTService, userID int) {
+	if err := jwtService.RevokeAllSessions(r.Context(), userID); err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", userID, err)
+	}
+}
@@ -8,17 +8,51 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/evn/eom_backendl/internal/pkg/listing"
 	"github.com/evn/eom_backendl/internal/pkg/response"
 )
 
-// ListAdminUsersHandler возвращает список всех пользователей для админов
+// AdminUsersSpec — фильтры и сортировки списка пользователей; from/to — по дате создания.
+var AdminUsersSpec = listing.Spec{
+	Sorts: map[string]string{
+		"created_at": "COALESCE(created_at, to_timestamp(0))",
+		"username":   "username",
+		"role":       "role",
+		"status":     "COALESCE(status, '')",
+	},
+	DefaultSort: "-created_at",
+	IDColumn:    "id",
+	Filters:     []string{listing.FilterDate, listing.FilterRole, listing.FilterStatus},
+	Columns:     listing.Columns{Date: "created_at", Role: "role", Status: "status"},
+}
+
+// ListAdminUsersHandler возвращает список всех пользователей для админов.
+// Удалённые пользователи скрыты, если не передан ?include_deleted=true.
+// Список постраничный, фильтры и сортировка — см. AdminUsersSpec.
 func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query(`
-			SELECT id, username, first_name, role, status, is_active, created_at, promo_codes
-			FROM users 
-			ORDER BY created_at DESC
-		`)
+		params, err := listing.Parse(r, AdminUsersSpec)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
+			return
+		}
+		q := listing.NewQuery(AdminUsersSpec, params)
+		if r.URL.Query().Get("include_deleted") != "true" {
+			q.Where("deleted_at IS NULL")
+		}
+
+		var total int
+		where, args := q.Count()
+		if err := db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+			log.Printf("Database count error: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
+			return
+		}
+
+		page, args := q.Page()
+		rows, err := db.QueryContext(r.Context(), `
+			SELECT id, username, first_name, role, status, is_active, created_at, promo_codes, deleted_at, `+q.SortValue()+`
+			FROM users`+page, args...)
 		if err != nil {
 			log.Printf("Database query error: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch users")
@@ -26,9 +60,11 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 		}
 		defer rows.Close()
 
-		var users []map[string]interface{}
+		users := []map[string]interface{}{}
+		var keys []listing.Key
 
 		for rows.Next() {
+			var key listing.Key
 			var user struct {
 				ID         int            `json:"id"`
 				Username   string         `json:"username"`
@@ -38,6 +74,7 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 				IsActive   bool           `json:"is_active"`
 				CreatedAt  time.Time      `json:"created_at"`
 				PromoCodes []byte         `json:"promo_codes"`
+				DeletedAt  sql.NullTime   `json:"deleted_at"`
 			}
 
 			err := rows.Scan(
@@ -49,6 +86,8 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 				&user.IsActive,
 				&user.CreatedAt,
 				&user.PromoCodes,
+				&user.DeletedAt,
+				&key.Value,
 			)
 			if err != nil {
 				log.Printf("Error scanning user row: %v", err)
@@ -56,6 +95,9 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 				return
 			}
 
+			key.ID = user.ID
+			keys = append(keys, key)
+
 			firstName := ""
 			if user.FirstName.Valid {
 				firstName = user.FirstName.String
@@ -72,6 +114,11 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 				promoCodes = make(map[string][]string)
 			}
 
+			var deletedAt interface{}
+			if user.DeletedAt.Valid {
+				deletedAt = user.DeletedAt.Time.Format(time.RFC3339)
+			}
+
 			users = append(users, map[string]interface{}{
 				"id":          user.ID,
 				"username":    user.Username,
@@ -81,6 +128,7 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 				"is_active":   user.IsActive,
 				"created_at":  user.CreatedAt.Format(time.RFC3339),
 				"promo_codes": promoCodes,
+				"deleted_at":  deletedAt,
 			})
 		}
 
@@ -91,6 +139,7 @@ func ListAdminUsersHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		response.RespondWithJSON(w, http.StatusOK, users)
+		n, result := q.Result(keys, total)
+		response.RespondWithPage(w, result, users[:n])
 	}
 }
@@ -0,0 +1,198 @@
+// handlers/approvals.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	telegramService "github.com/evn/eom_backendl/internal/services/telegram"
+	"github.com/go-chi/chi/v5"
+)
+
+// Статусы пользователя. rejected — заявка отклонена,
+// blocked — доступ закрыт администратором.
+const (
+	StatusPending  = "pending"
+	StatusActive   = "active"
+	StatusRejected = "rejected"
+	StatusBlocked  = "blocked"
+)
+
+var validStatuses = map[string]bool{
+	StatusPending:  true,
+	StatusActive:   true,
+	StatusRejected: true,
+	StatusBlocked:  true,
+}
+
+// ListPendingApprovalsHandler — очередь заявок с данными, которые заполнил пользователь.
+func ListPendingApprovalsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := db.Query(`
+			SELECT id, username, first_name, last_name, phone, telegram_id, avatar_url,
+			       created_at, registration_submitted_at
+			FROM users
+			WHERE status = 'pending' AND deleted_at IS NULL
+			ORDER BY COALESCE(registration_submitted_at, created_at)
+		`)
+		if err != nil {
+			log.Printf("Failed to load pending approvals: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load pending approvals")
+			return
+		}
+		defer rows.Close()
+
+		type pendingUser struct {
+			ID                      int        `json:"id"`
+			Username                string     `json:"username"`
+			FirstName               *string    `json:"first_name"`
+			LastName                *string    `json:"last_name"`
+			Phone                   *string    `json:"phone"`
+			TelegramID              *int64     `json:"telegram_id"`
+			AvatarURL               *string    `json:"avatar_url"`
+			CreatedAt               time.Time  `json:"created_at"`
+			RegistrationSubmittedAt *time.Time `json:"registration_submitted_at"`
+		}
+
+		users := []pendingUser{}
+		for rows.Next() {
+			var u pendingUser
+			if err := rows.Scan(&u.ID, &u.Username, &u.FirstName, &u.LastName, &u.Phone,
+				&u.TelegramID, &u.AvatarURL, &u.CreatedAt, &u.RegistrationSubmittedAt); err != nil {
+				log.Printf("Failed to scan pending user: %v", err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Failed to load pending approvals")
+				return
+			}
+			users = append(users, u)
+		}
+		if err := rows.Err(); err != nil {
+			log.Printf("Failed to iterate pending approvals: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load pending approvals")
+			return
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, users)
+	}
+}
+
+// ApproveUserHandler одобряет заявку из очереди.
+func ApproveUserHandler(db *sql.DB, auditLog *auditService.AuditLogger, bot *telegramService.BotClient) http.HandlerFunc {
+	return decideApprovalHandler(db, auditLog, nil, bot, StatusActive)
+}
+
+// RejectUserHandler отклоняет заявку; причина обязательна и уходит пользователю.
+func RejectUserHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService, bot *telegramService.BotClient) http.HandlerFunc {
+	return decideApprovalHandler(db, auditLog, jwtService, bot, StatusRejected)
+}
+
+func decideApprovalHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService, bot *telegramService.BotClient, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if status == StatusRejected && req.Reason == "" {
+			response.RespondWithError(w, http.StatusBadRequest, "Reason is required")
+			return
+		}
+
+		// Решение принимается только по заявке в очереди — повторный клик не меняет статус
+		var telegramID sql.NullInt64
+		err = db.QueryRow(`
+			UPDATE users
+			SET status = $1, is_active = $2, status_reason = NULLIF($3, ''),
+			    status_changed_at = NOW(), status_changed_by = $4
+			WHERE id = $5 AND status = 'pending' AND deleted_at IS NULL
+			RETURNING telegram_id`,
+			status, status == StatusActive, req.Reason, actorID(r), userID,
+		).Scan(&telegramID)
+		if err == sql.ErrNoRows {
+			response.RespondWithCode(w, http.StatusConflict, "user_not_pending")
+			return
+		} else if err != nil {
+			log.Printf("Failed to set status %s for user %d: %v", status, userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user status")
+			return
+		}
+
+		action := "user.registration_approve"
+		if status == StatusRejected {
+			action = "user.registration_reject"
+		}
+		auditLog.Record(r, action, "user", strconv.Itoa(userID),
+			map[string]string{"status": StatusPending},
+			map[string]string{"status": status, "reason": req.Reason})
+
+		if status != StatusActive && jwtService != nil {
+			forceLogout(r, jwtService, userID)
+		}
+		notifyStatusChange(bot, userID, telegramID, status, req.Reason)
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{
+			"message": "User status updated successfully",
+			"status":  status,
+		})
+	}
+}
+
+// actorID — id администратора для status_changed_by (NULL, если не определён).
+func actorID(r *http.Request) interface{} {
+	if id, ok := middleware.GetUserIDFromContext(r.Context()); ok {
+		return id
+	}
+	return nil
+}
+
+// notifyStatusChange сообщает пользователю о решении в Telegram. Отправка
+// идёт в фоне: ответ администратору не ждёт Telegram API.
+func notifyStatusChange(bot *telegramService.BotClient, userID int, telegramID sql.NullInt64, status, reason string) {
+	if bot == nil || !telegramID.Valid {
+		return
+	}
+
+	var text string
+	switch status {
+	case StatusActive:
+		text = "Ваша заявка одобрена. Теперь вы можете войти в приложение."
+	case StatusRejected:
+		text = "Ваша заявка отклонена."
+	case StatusBlocked:
+		text = "Ваш доступ к приложению заблокирован."
+	default:
+		return
+	}
+	if reason != "" {
+		text += fmt.Sprintf("\nПричина: %s", reason)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := bot.SendMessage(ctx, telegramID.Int64, text); err != nil {
+			log.Printf("Failed to notify user %d about status %s: %v", userID, status, err)
+		}
+	}()
+}
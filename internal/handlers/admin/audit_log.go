@@ -0,0 +1,149 @@
+// handlers/audit_log.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/csvsafe"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+const maxAuditExportRows = 50000
+
+// ListAuditLogHandler возвращает записи журнала с фильтрами
+// actor_id, action, target_type, target_id, from, to (RFC3339), limit, offset.
+func ListAuditLogHandler(db *sql.DB) http.HandlerFunc {
+	repo := repositories.NewAuditRepository(db)
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
+			return
+		}
+		if filter.Limit <= 0 || filter.Limit > 500 {
+			filter.Limit = 100
+		}
+
+		entries, err := repo.List(r.Context(), filter)
+		if err != nil {
+			log.Printf("DB error listing audit log: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if entries == nil {
+			entries = []models.AuditEntry{}
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, entries)
+	}
+}
+
+// ExportAuditLogHandler выгружает журнал в CSV с теми же фильтрами.
+func ExportAuditLogHandler(db *sql.DB) http.HandlerFunc {
+	repo := repositories.NewAuditRepository(db)
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
+			return
+		}
+		filter.Limit = maxAuditExportRows
+		filter.Offset = 0
+
+		entries, err := repo.List(r.Context(), filter)
+		if err != nil {
+			log.Printf("DB error exporting audit log: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		filename := fmt.Sprintf("audit_log_%s.csv", time.Now().Format("20060102_150405"))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"id", "created_at", "actor_id", "actor_role", "action", "target_type", "target_id", "before", "after", "ip"})
+		for _, e := range entries {
+			actorID := ""
+			if e.ActorID != nil {
+				actorID = strconv.Itoa(*e.ActorID)
+			}
+			writer.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.CreatedAt.Format(time.RFC3339),
+				actorID,
+				csvsafe.Cell(e.ActorRole),
+				csvsafe.Cell(e.Action),
+				csvsafe.Cell(e.TargetType),
+				csvsafe.Cell(e.TargetID),
+				csvsafe.Cell(string(e.Before)),
+				csvsafe.Cell(string(e.After)),
+				csvsafe.Cell(e.IP),
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			log.Printf("Error writing audit CSV: %v", err)
+		}
+	}
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+	}
+
+	if v := q.Get("actor_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, invalidParam("actor_id", response.FieldInvalid, nil)
+		}
+		filter.ActorID = &id
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, invalidParam("from", response.FieldInvalidFormat, rfc3339)
+		}
+		filter.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, invalidParam("to", response.FieldInvalidFormat, rfc3339)
+		}
+		filter.To = &t
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return filter, invalidParam("limit", response.FieldInvalid, nil)
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return filter, invalidParam("offset", response.FieldInvalid, nil)
+		}
+		filter.Offset = n
+	}
+	return filter, nil
+}
+
+var rfc3339 = map[string]interface{}{"format": "RFC3339"}
+
+func invalidParam(field, code string, params map[string]interface{}) error {
+	return response.Invalid(nil, response.FieldError{Field: field, Code: code, Params: params})
+}
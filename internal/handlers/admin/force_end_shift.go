@@ -2,16 +2,24 @@
 package handlers
 
 import (
-	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/evn/eom_backendl/internal/models"
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	"github.com/go-chi/chi/v5"
 )
 
-func ForceEndShiftHandler(db *sql.DB) http.HandlerFunc {
+// ForceEndShiftHandler закрывает активную смену пользователя. В теле —
+// код причины (reason), комментарий и, если нужно, фактическое время ухода
+// end_time (RFC 3339); без него смена закрывается сейчас.
+func ForceEndShiftHandler(shifts *shiftService.ShiftService, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIDStr := chi.URLParam(r, "userID")
 		userID, err := strconv.Atoi(userIDStr)
@@ -20,37 +28,46 @@ func ForceEndShiftHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var slotID int
-		var startTime time.Time
-		err = db.QueryRow(`
-            SELECT id, start_time 
-            FROM slots 
-            WHERE user_id = $1 AND end_time IS NULL
-        `, userID).Scan(&slotID, &startTime)
-		if err == sql.ErrNoRows {
-			response.RespondWithError(w, http.StatusNotFound, "No active slot found for the user")
+		var req struct {
+			correctionRequest
+			EndTime *time.Time `json:"end_time"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
 			return
-		} else if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		}
+		correction, ok := req.correction(w, r)
+		if !ok {
 			return
 		}
 
-		endTime := time.Now()
-		duration := int(endTime.Sub(startTime).Seconds())
-
-		_, err = db.Exec(`
-            UPDATE slots 
-            SET end_time = $1, worked_duration = $2 
-            WHERE id = $3
-        `, endTime, duration, slotID)
-		if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		shift, applied, err := shifts.ForceEnd(r.Context(), userID, req.EndTime, correction)
+		if errors.Is(err, shiftService.ErrNoActiveShift) {
+			response.RespondWithError(w, http.StatusNotFound, "No active slot found for the user")
+			return
+		} else if err != nil {
+			respondCorrectionError(w, err, "force-end slot of user "+userIDStr)
 			return
 		}
 
+		auditLog.Record(r, "shift.force_end", "slot", strconv.Itoa(shift.ID), applied.Before, correctionAudit(applied))
+
 		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 			"message":     "Slot ended",
-			"worked_time": response.FormatDuration(duration),
+			"worked_time": response.FormatDuration(shift.WorkedDuration),
+			"slot":        correctedShiftJSON(*shift),
 		})
 	}
 }
+
+// correctedShiftJSON — смена после правки администратора.
+func correctedShiftJSON(shift models.Shift) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          shift.ID,
+		"user_id":     shift.UserID,
+		"username":    shift.Username,
+		"values":      shift.Values(),
+		"worked_time": response.FormatDuration(shift.WorkedDuration),
+		"flags":       shift.Flags(),
+	}
+}
@@ -0,0 +1,59 @@
+// handlers/login_lockout.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// UnlockLoginHandler снимает блокировку входа по паролю с пользователя.
+// Необязательное поле ip снимает и блокировку адреса.
+func UnlockLoginHandler(db *sql.DB, auditLog *auditService.AuditLogger, loginLimiter *authService.LoginLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		var req struct {
+			IP string `json:"ip"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+		}
+
+		var username string
+		err = db.QueryRow("SELECT username FROM users WHERE id = $1", userID).Scan(&username)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to load user %d for login unlock: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to unlock login")
+			return
+		}
+
+		if err := loginLimiter.Unlock(r.Context(), username, req.IP); err != nil {
+			log.Printf("Failed to unlock login for user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to unlock login")
+			return
+		}
+
+		auditLog.Record(r, "security.login_unlock", "user", strconv.Itoa(userID), nil,
+			map[string]string{"username": username, "ip": req.IP})
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Login unlocked"})
+	}
+}
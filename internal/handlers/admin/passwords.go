@@ -0,0 +1,70 @@
+// handlers/passwords.go
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// IssueTemporaryPasswordHandler выдаёт одноразовый пароль. Он показывается
+// один раз, при первом входе его нужно сменить; все сессии завершаются.
+func IssueTemporaryPasswordHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		password, passwordHash, err := newTemporaryPassword()
+		if err != nil {
+			log.Printf("Failed to generate temporary password: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to issue temporary password")
+			return
+		}
+
+		res, err := db.Exec(`
+			UPDATE users
+			SET password_hash = $1, must_change_password = TRUE, password_changed_at = NOW()
+			WHERE id = $2 AND deleted_at IS NULL`,
+			passwordHash, userID,
+		)
+		if err != nil {
+			log.Printf("Failed to set temporary password for user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to issue temporary password")
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+
+		forceLogout(r, jwtService, userID)
+		auditLog.Record(r, "user.temporary_password", "user", strconv.Itoa(userID), nil, nil)
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":            userID,
+			"temporary_password": password,
+		})
+	}
+}
+
+// newTemporaryPassword возвращает временный пароль и его хэш.
+func newTemporaryPassword() (string, string, error) {
+	password, err := authService.GenerateTemporaryPassword()
+	if err != nil {
+		return "", "", err
+	}
+	passwordHash, err := authService.HashPassword(password)
+	if err != nil {
+		return "", "", err
+	}
+	return password, passwordHash, nil
+}
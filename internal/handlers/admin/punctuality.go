@@ -0,0 +1,62 @@
+// handlers/punctuality.go
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+)
+
+// maxPunctualityDays — самый длинный период отчёта.
+const maxPunctualityDays = 93
+
+// PunctualityReportHandler — пунктуальность скаутов за период from..to
+// (YYYY-MM-DD, включительно; по умолчанию последние 30 дней): опоздания,
+// ранние уходы, автозакрытые смены и невыходы на плановые смены.
+func PunctualityReportHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		today := time.Now()
+		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+		from, to := today.AddDate(0, 0, -29), today
+
+		q := r.URL.Query()
+		if v := q.Get("from"); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				response.RespondWithError(w, http.StatusBadRequest, "Invalid 'from' date, expected YYYY-MM-DD")
+				return
+			}
+			from = t
+		}
+		if v := q.Get("to"); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				response.RespondWithError(w, http.StatusBadRequest, "Invalid 'to' date, expected YYYY-MM-DD")
+				return
+			}
+			to = t
+		}
+		if to.Before(from) || to.Sub(from) > maxPunctualityDays*24*time.Hour {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid period: 'to' must be after 'from' and within 93 days")
+			return
+		}
+
+		report, err := shifts.Punctuality(r.Context(), from, to)
+		if err != nil {
+			log.Printf("Failed to build punctuality report: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"from":          from.Format("2006-01-02"),
+			"to":            to.Format("2006-01-02"),
+			"grace_minutes": int(shiftService.PunctualityGrace / time.Minute),
+			"scouts":        report.Scouts,
+			"no_shows":      report.NoShows,
+		})
+	}
+}
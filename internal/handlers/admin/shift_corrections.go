@@ -0,0 +1,173 @@
+// handlers/shift_corrections.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	"github.com/go-chi/chi/v5"
+)
+
+// correctionRequest — причина правки смены в теле запроса.
+type correctionRequest struct {
+	Reason  string `json:"reason"` // код, см. models.CorrectionReasons
+	Comment string `json:"comment"`
+}
+
+func (req correctionRequest) correction(w http.ResponseWriter, r *http.Request) (shiftService.Correction, bool) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return shiftService.Correction{}, false
+	}
+	if req.Reason == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Reason is required")
+		return shiftService.Correction{}, false
+	}
+	return shiftService.Correction{AdminID: adminID, Reason: req.Reason, Comment: req.Comment}, true
+}
+
+// respondCorrectionError переводит ошибку правки смены в HTTP-ответ.
+func respondCorrectionError(w http.ResponseWriter, err error, what string) {
+	switch {
+	case errors.Is(err, shiftService.ErrInvalidCorrection):
+		response.RespondWithBadRequest(w, err)
+	case errors.Is(err, shiftService.ErrInvalidZone):
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid zone")
+	case errors.Is(err, shiftService.ErrInvalidTimeSlot):
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid slot time range")
+	case errors.Is(err, shiftService.ErrShiftNotFound):
+		response.RespondWithError(w, http.StatusNotFound, "Slot not found")
+	case errors.Is(err, shiftService.ErrShiftNotEnded):
+		response.RespondWithError(w, http.StatusConflict, "Slot is still active, end it first")
+	case errors.Is(err, shiftService.ErrShiftVoided):
+		response.RespondWithError(w, http.StatusConflict, "Slot is voided")
+	case errors.Is(err, shiftService.ErrPeriodClosed):
+		response.RespondWithError(w, http.StatusConflict, "Табель за этот месяц утверждён: снимите утверждение, чтобы править смены")
+	default:
+		log.Printf("Failed to %s: %v", what, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+	}
+}
+
+// correctionAudit — состояние смены после правки для журнала действий.
+func correctionAudit(c *models.ShiftCorrection) map[string]interface{} {
+	return map[string]interface{}{"values": c.After, "reason": c.Reason, "comment": c.Comment, "correction_id": c.ID}
+}
+
+func slotIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	shiftID, err := strconv.Atoi(chi.URLParam(r, "slotID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid slot ID")
+		return 0, false
+	}
+	return shiftID, true
+}
+
+// EditShiftHandler правит закрытую смену: start_time, end_time (RFC 3339),
+// zone, slot_time_range — передаются только меняемые поля; reason обязателен.
+// Отработанное время и пунктуальность пересчитываются.
+func EditShiftHandler(shifts *shiftService.ShiftService, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID, ok := slotIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			correctionRequest
+			StartTime     *time.Time `json:"start_time"`
+			EndTime       *time.Time `json:"end_time"`
+			Zone          *string    `json:"zone"`
+			SlotTimeRange *string    `json:"slot_time_range"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		correction, ok := req.correction(w, r)
+		if !ok {
+			return
+		}
+		if req.StartTime == nil && req.EndTime == nil && req.Zone == nil && req.SlotTimeRange == nil {
+			response.RespondWithCode(w, http.StatusBadRequest, "nothing_to_change")
+			return
+		}
+
+		shift, applied, err := shifts.Edit(r.Context(), shiftID, shiftService.ShiftEdit{
+			StartTime:     req.StartTime,
+			EndTime:       req.EndTime,
+			Zone:          req.Zone,
+			SlotTimeRange: req.SlotTimeRange,
+		}, correction)
+		if err != nil {
+			respondCorrectionError(w, err, "edit slot "+strconv.Itoa(shiftID))
+			return
+		}
+
+		auditLog.Record(r, "shift.edit", "slot", strconv.Itoa(shift.ID), applied.Before, correctionAudit(applied))
+		response.RespondWithJSON(w, http.StatusOK, correctedShiftJSON(*shift))
+	}
+}
+
+// VoidShiftHandler аннулирует смену: она перестаёт учитываться в табеле,
+// пунктуальности и отчётах. В теле — reason и comment.
+func VoidShiftHandler(shifts *shiftService.ShiftService, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID, ok := slotIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		var req correctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		correction, ok := req.correction(w, r)
+		if !ok {
+			return
+		}
+
+		shift, applied, err := shifts.Void(r.Context(), shiftID, correction)
+		if err != nil {
+			respondCorrectionError(w, err, "void slot "+strconv.Itoa(shiftID))
+			return
+		}
+
+		auditLog.Record(r, "shift.void", "slot", strconv.Itoa(shift.ID), applied.Before, correctionAudit(applied))
+		response.RespondWithJSON(w, http.StatusOK, correctedShiftJSON(*shift))
+	}
+}
+
+// ShiftCorrectionsHandler — история правок смены и её исходные значения.
+func ShiftCorrectionsHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shiftID, ok := slotIDParam(w, r)
+		if !ok {
+			return
+		}
+
+		history, err := shifts.Corrections(r.Context(), shiftID)
+		if err != nil {
+			respondCorrectionError(w, err, "load corrections of slot "+strconv.Itoa(shiftID))
+			return
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"slot":        correctedShiftJSON(history.Shift),
+			"original":    history.Original,
+			"corrections": history.Corrections,
+		})
+	}
+}
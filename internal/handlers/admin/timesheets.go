@@ -0,0 +1,176 @@
+// handlers/timesheets.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	timesheetService "github.com/evn/eom_backendl/internal/services/timesheet"
+	"github.com/go-chi/chi/v5"
+)
+
+// timesheetPeriod читает месяц {period} (2006-01) из URL.
+func timesheetPeriod(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	month, err := timesheetService.ParsePeriod(chi.URLParam(r, "period"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid period, use YYYY-MM")
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// respondTimesheetError переводит ошибку утверждения табеля в HTTP-ответ.
+func respondTimesheetError(w http.ResponseWriter, err error, month time.Time) {
+	switch {
+	case errors.Is(err, timesheetService.ErrPeriodNotOver):
+		response.RespondWithError(w, http.StatusConflict, "Месяц ещё не закончился")
+	case errors.Is(err, timesheetService.ErrOpenShifts):
+		response.RespondWithError(w, http.StatusConflict, "В этом месяце есть незакрытые смены")
+	case errors.Is(err, timesheetService.ErrNotApproved):
+		response.RespondWithError(w, http.StatusConflict, "Табель не утверждён")
+	case errors.Is(err, timesheetService.ErrPeriodLocked):
+		response.RespondWithError(w, http.StatusConflict, "Табель закрыт после расчёта зарплаты")
+	default:
+		log.Printf("Timesheet %s failed: %v", month.Format("2006-01"), err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+	}
+}
+
+// timesheetAudit — состояние табеля для журнала действий.
+func timesheetAudit(ts *models.Timesheet) map[string]interface{} {
+	return map[string]interface{}{"status": ts.Status, "employees": len(ts.Employees), "rules": ts.Rules}
+}
+
+// GetTimesheetHandler возвращает табель за месяц: часы по дням, неделям и
+// месяцу для каждого сотрудника с разбивкой по типу смены и зоне.
+func GetTimesheetHandler(timesheets *timesheetService.TimesheetService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, ok := timesheetPeriod(w, r)
+		if !ok {
+			return
+		}
+
+		ts, err := timesheets.Get(r.Context(), month)
+		if err != nil {
+			respondTimesheetError(w, err, month)
+			return
+		}
+		response.RespondWithJSON(w, http.StatusOK, ts)
+	}
+}
+
+// ExportTimesheetHandler выгружает табель в XLSX (по умолчанию) или CSV (?format=csv).
+func ExportTimesheetHandler(timesheets *timesheetService.TimesheetService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, ok := timesheetPeriod(w, r)
+		if !ok {
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "xlsx"
+		}
+		if format != "xlsx" && format != "csv" {
+			response.RespondWithValidation(w, response.FieldError{Field: "format", Code: response.FieldOneOf, Params: map[string]interface{}{"allowed": "xlsx, csv"}})
+			return
+		}
+
+		ts, err := timesheets.Get(r.Context(), month)
+		if err != nil {
+			respondTimesheetError(w, err, month)
+			return
+		}
+
+		filename := fmt.Sprintf("timesheet_%s_%s.%s", ts.Period, ts.Status, format)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			err = timesheetService.WriteCSV(w, ts)
+		} else {
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			err = timesheetService.WriteXLSX(w, ts)
+		}
+		if err != nil {
+			log.Printf("Error writing timesheet %s export: %v", ts.Period, err)
+		}
+	}
+}
+
+// ApproveTimesheetHandler утверждает табель за прошедший месяц. Цифры
+// сохраняются снимком; до закрытия табель можно утвердить повторно.
+func ApproveTimesheetHandler(timesheets *timesheetService.TimesheetService, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, ok := timesheetPeriod(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		ts, err := timesheets.Approve(r.Context(), month, userID)
+		if err != nil {
+			respondTimesheetError(w, err, month)
+			return
+		}
+
+		auditLog.Record(r, "timesheet.approve", "timesheet", ts.Period, nil, timesheetAudit(ts))
+		response.RespondWithJSON(w, http.StatusOK, ts)
+	}
+}
+
+// ReopenTimesheetHandler снимает утверждение, чтобы табель можно было поправить.
+func ReopenTimesheetHandler(timesheets *timesheetService.TimesheetService, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, ok := timesheetPeriod(w, r)
+		if !ok {
+			return
+		}
+
+		if err := timesheets.Reopen(r.Context(), month); err != nil {
+			respondTimesheetError(w, err, month)
+			return
+		}
+
+		period := month.Format("2006-01")
+		auditLog.Record(r, "timesheet.reopen", "timesheet", period,
+			map[string]interface{}{"status": models.TimesheetApproved},
+			map[string]interface{}{"status": models.TimesheetOpen})
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Timesheet reopened", "period": period})
+	}
+}
+
+// LockTimesheetHandler закрывает утверждённый табель после расчёта зарплаты.
+// Закрытый табель нельзя ни переутвердить, ни открыть снова.
+func LockTimesheetHandler(timesheets *timesheetService.TimesheetService, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, ok := timesheetPeriod(w, r)
+		if !ok {
+			return
+		}
+		userID, ok := middleware.GetUserIDFromContext(r.Context())
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		ts, err := timesheets.Lock(r.Context(), month, userID)
+		if err != nil {
+			respondTimesheetError(w, err, month)
+			return
+		}
+
+		auditLog.Record(r, "timesheet.lock", "timesheet", ts.Period,
+			map[string]interface{}{"status": models.TimesheetApproved}, timesheetAudit(ts))
+		response.RespondWithJSON(w, http.StatusOK, ts)
+	}
+}
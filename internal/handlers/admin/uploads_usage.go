@@ -0,0 +1,57 @@
+// handlers/uploads_usage.go
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+)
+
+// UploadsUsageHandler показывает, сколько места занимает каждый вид загрузок,
+// и срок хранения селфи и фото заданий.
+func UploadsUsageHandler(store storageService.Storage, retention *mediaService.RetentionPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := mediaService.Usage(r.Context(), store)
+		if err != nil {
+			log.Printf("Failed to collect uploads usage: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to collect uploads usage")
+			return
+		}
+
+		var total int64
+		for _, kind := range usage {
+			total += kind.Bytes + kind.ThumbBytes
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"kinds":          usage,
+			"total_bytes":    total,
+			"retention_days": int(retention.MaxAge().Hours() / 24),
+			"retained_kinds": mediaService.RetainedKinds,
+		})
+	}
+}
+
+// RunUploadsCleanupHandler запускает удаление старых загрузок, не дожидаясь ночного прохода.
+func RunUploadsCleanupHandler(retention *mediaService.RetentionPolicy, auditLog *auditService.AuditLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !retention.Enabled() {
+			response.RespondWithCode(w, http.StatusConflict, "retention_disabled")
+			return
+		}
+
+		result, err := retention.Run(r.Context())
+		if err != nil {
+			log.Printf("Uploads cleanup failed: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Uploads cleanup failed")
+			return
+		}
+
+		auditLog.Record(r, "uploads.cleanup", "uploads", "", nil, result)
+		response.RespondWithJSON(w, http.StatusOK, result)
+	}
+}
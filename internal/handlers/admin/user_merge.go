@@ -0,0 +1,206 @@
+// handlers/user_merge.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// MergeUsersHandler переносит данные дубликата (source_user_id) в пользователя
+// из URL: смены, плановые выходы, геопозиции, промокоды и Telegram. Дубликат
+// помечается удалённым со ссылкой на основной аккаунт.
+func MergeUsersHandler(db *sql.DB, auditLog *auditService.AuditLogger, jwtService *authService.JWTService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		targetID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid User ID")
+			return
+		}
+
+		var req struct {
+			SourceUserID int `json:"source_user_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SourceUserID == 0 {
+			response.RespondWithValidation(w, response.FieldError{Field: "source_user_id", Code: response.FieldRequired})
+			return
+		}
+		sourceID := req.SourceUserID
+		if sourceID == targetID {
+			response.RespondWithCode(w, http.StatusBadRequest, "merge_same_user")
+			return
+		}
+
+		tx, err := db.BeginTx(r.Context(), nil)
+		if err != nil {
+			log.Printf("Failed to begin merge transaction: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+		defer tx.Rollback()
+
+		// Блокируем обе записи в одном порядке, чтобы встречные слияния не взаимоблокировались
+		rows, err := tx.Query(`
+			SELECT id, username, telegram_id, deleted_at IS NOT NULL
+			FROM users WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+			sourceID, targetID,
+		)
+		if err != nil {
+			log.Printf("Failed to lock users %d and %d: %v", sourceID, targetID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+		type mergeUser struct {
+			Username   string
+			TelegramID sql.NullInt64
+			Deleted    bool
+		}
+		found := make(map[int]mergeUser)
+		for rows.Next() {
+			var id int
+			var u mergeUser
+			if err := rows.Scan(&id, &u.Username, &u.TelegramID, &u.Deleted); err != nil {
+				rows.Close()
+				log.Printf("Failed to scan user during merge: %v", err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+				return
+			}
+			found[id] = u
+		}
+		rows.Close()
+
+		source, okSource := found[sourceID]
+		target, okTarget := found[targetID]
+		if !okSource || !okTarget {
+			response.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if source.Deleted || target.Deleted {
+			response.RespondWithCode(w, http.StatusConflict, "merge_deleted_user")
+			return
+		}
+		if source.TelegramID.Valid && target.TelegramID.Valid {
+			response.RespondWithCode(w, http.StatusConflict, "merge_both_telegram")
+			return
+		}
+
+		var sourceActive bool
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM slots WHERE user_id = $1 AND end_time IS NULL)", sourceID).Scan(&sourceActive)
+		if err != nil {
+			log.Printf("Failed to check active slot of user %d: %v", sourceID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+		if sourceActive {
+			response.RespondWithCode(w, http.StatusConflict, "merge_active_shift")
+			return
+		}
+
+		// Плановый выход уникален по (сотрудник, день, слот). При совпадении остаётся
+		// выход основного аккаунта; отметка об отработке переходит к нему от дубликата.
+		_, err = tx.Exec(`
+			UPDATE shift_assignments t SET slot_id = COALESCE(t.slot_id, s.slot_id)
+			FROM shift_assignments s
+			WHERE t.user_id = $1 AND s.user_id = $2
+			  AND t.shift_date = s.shift_date AND t.slot_time_range = s.slot_time_range`,
+			targetID, sourceID,
+		)
+		if err == nil {
+			_, err = tx.Exec(`
+				DELETE FROM shift_assignments s
+				USING shift_assignments t
+				WHERE s.user_id = $2 AND t.user_id = $1
+				  AND t.shift_date = s.shift_date AND t.slot_time_range = s.slot_time_range`,
+				targetID, sourceID,
+			)
+		}
+		if err != nil {
+			log.Printf("Failed to resolve shift assignment conflicts of users %d and %d: %v", sourceID, targetID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+
+		moved := make(map[string]int64)
+		for _, step := range []struct {
+			name  string
+			query string
+			args  []interface{}
+		}{
+			{"slots", "UPDATE slots SET user_id = $1 WHERE user_id = $2", []interface{}{targetID, sourceID}},
+			{"shift_assignments", "UPDATE shift_assignments SET user_id = $1 WHERE user_id = $2", []interface{}{targetID, sourceID}},
+			{"positions", "UPDATE positions SET user_id = $1 WHERE user_id = $2", []interface{}{strconv.Itoa(targetID), strconv.Itoa(sourceID)}},
+			{"promo_codes", "UPDATE promo_codes SET assigned_to_user_id = $1 WHERE assigned_to_user_id = $2", []interface{}{targetID, sourceID}},
+		} {
+			res, err := tx.Exec(step.query, step.args...)
+			if err != nil {
+				log.Printf("Failed to move %s from user %d to %d: %v", step.name, sourceID, targetID, err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+				return
+			}
+			moved[step.name], _ = res.RowsAffected()
+		}
+
+		// Сначала освобождаем telegram_id у дубликата: колонка уникальная
+		_, err = tx.Exec(`
+			UPDATE users SET
+				telegram_id = NULL,
+				is_active = FALSE,
+				deleted_at = NOW(),
+				merged_into_user_id = $1
+			WHERE id = $2`,
+			targetID, sourceID,
+		)
+		if err != nil {
+			log.Printf("Failed to mark user %d as merged: %v", sourceID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+
+		// Промокоды основного аккаунта важнее: при совпадении бренда остаются его коды
+		_, err = tx.Exec(`
+			UPDATE users t SET
+				telegram_id = COALESCE(t.telegram_id, $3),
+				promo_codes = COALESCE(s.promo_codes, '{}'::jsonb) || COALESCE(t.promo_codes, '{}'::jsonb),
+				phone = COALESCE(t.phone, s.phone),
+				last_name = COALESCE(t.last_name, s.last_name),
+				avatar_url = COALESCE(t.avatar_url, s.avatar_url)
+			FROM users s
+			WHERE t.id = $1 AND s.id = $2`,
+			targetID, sourceID, source.TelegramID,
+		)
+		if err != nil {
+			log.Printf("Failed to merge profile of user %d into %d: %v", sourceID, targetID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("Failed to commit merge of user %d into %d: %v", sourceID, targetID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to merge users")
+			return
+		}
+
+		forceLogout(r, jwtService, sourceID)
+		auditLog.Record(r, "user.merge", "user", strconv.Itoa(targetID),
+			map[string]interface{}{
+				"source_user_id":     sourceID,
+				"source_username":    source.Username,
+				"source_telegram_id": source.TelegramID.Int64,
+			},
+			map[string]interface{}{"moved": moved},
+		)
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Users merged successfully",
+			"user_id": targetID,
+			"moved":   moved,
+		})
+	}
+}
@@ -4,26 +4,31 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/evn/eom_backendl/internal/middleware"
 	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
 	"github.com/evn/eom_backendl/internal/pkg/response"
 	"github.com/evn/eom_backendl/internal/repositories"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
 	"github.com/go-chi/chi/v5"
 )
 
 type AppVersionHandler struct {
-	repo *repositories.AppVersionRepository
-	db   *sql.DB // Добавляем DB для доступа к пользователям
+	repo     *repositories.AppVersionRepository
+	db       *sql.DB // Добавляем DB для доступа к пользователям
+	auditLog *auditService.AuditLogger
 }
 
-func NewAppVersionHandler(db *sql.DB) *AppVersionHandler {
+func NewAppVersionHandler(db *sql.DB, auditLog *auditService.AuditLogger) *AppVersionHandler {
 	return &AppVersionHandler{
-		repo: repositories.NewAppVersionRepository(db),
-		db:   db,
+		repo:     repositories.NewAppVersionRepository(db),
+		db:       db,
+		auditLog: auditLog,
 	}
 }
 
@@ -48,7 +53,8 @@ func (h *AppVersionHandler) CheckVersionHandler(w http.ResponseWriter, r *http.R
 
 	versionResp, err := h.repo.CheckVersion(req.Platform, req.CurrentVersion, req.BuildNumber)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to check version: "+err.Error())
+		log.Printf("Failed to check version: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to check version")
 		return
 	}
 
@@ -68,7 +74,8 @@ func (h *AppVersionHandler) GetLatestVersionHandler(w http.ResponseWriter, r *ht
 
 	version, err := h.repo.GetLatestVersion(platform)
 	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "No version found: "+err.Error())
+		log.Printf("No version found for platform %s: %v", platform, err)
+		response.RespondWithError(w, http.StatusNotFound, "Version not found")
 		return
 	}
 
@@ -77,26 +84,20 @@ func (h *AppVersionHandler) GetLatestVersionHandler(w http.ResponseWriter, r *ht
 
 // ListVersionsHandler возвращает список всех версий (для админов)
 func (h *AppVersionHandler) ListVersionsHandler(w http.ResponseWriter, r *http.Request) {
-	platform := r.URL.Query().Get("platform")
-
-	var versions []models.AppVersion
-	var err error
-
-	if platform != "" {
-		versions, err = h.repo.GetAllVersions(platform)
-	} else {
-		// Получаем все версии для всех платформ
-		androidVersions, _ := h.repo.GetAllVersions("android")
-		iosVersions, _ := h.repo.GetAllVersions("ios")
-		versions = append(androidVersions, iosVersions...)
+	params, err := listing.Parse(r, repositories.AppVersionsSpec)
+	if err != nil {
+		response.RespondWithBadRequest(w, err)
+		return
 	}
 
+	versions, page, err := h.repo.ListVersions(r.Context(), r.URL.Query().Get("platform"), params)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to list versions: "+err.Error())
+		log.Printf("Failed to list versions: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to list versions")
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, versions)
+	response.RespondWithPage(w, page, versions)
 }
 
 // CreateVersionHandler создает новую версию (только для superadmin)
@@ -114,10 +115,13 @@ func (h *AppVersionHandler) CreateVersionHandler(w http.ResponseWriter, r *http.
 	}
 
 	if err := h.repo.CreateVersion(&version); err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create version: "+err.Error())
+		log.Printf("Failed to create version: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create version")
 		return
 	}
 
+	h.auditLog.Record(r, "app_version.create", "app_version", strconv.Itoa(version.ID), nil, version)
+
 	response.RespondWithJSON(w, http.StatusCreated, version)
 }
 
@@ -142,12 +146,21 @@ func (h *AppVersionHandler) UpdateVersionHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	before, err := h.repo.GetVersionByID(id)
+	if err != nil {
+		response.RespondWithError(w, http.StatusNotFound, "Version not found")
+		return
+	}
+
 	version.ID = id
 	if err := h.repo.UpdateVersion(&version); err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update version: "+err.Error())
+		log.Printf("Failed to update version %d: %v", id, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update version")
 		return
 	}
 
+	h.auditLog.Record(r, "app_version.update", "app_version", strconv.Itoa(id), before, version)
+
 	response.RespondWithJSON(w, http.StatusOK, version)
 }
 
@@ -166,11 +179,20 @@ func (h *AppVersionHandler) DeleteVersionHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	before, err := h.repo.GetVersionByID(id)
+	if err != nil {
+		response.RespondWithError(w, http.StatusNotFound, "Version not found")
+		return
+	}
+
 	if err := h.repo.DeleteVersion(id); err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete version: "+err.Error())
+		log.Printf("Failed to delete version %d: %v", id, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete version")
 		return
 	}
 
+	h.auditLog.Record(r, "app_version.delete", "app_version", strconv.Itoa(id), before, nil)
+
 	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Version deleted successfully"})
 }
 
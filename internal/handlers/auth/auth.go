@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/evn/eom_backendl/internal/middleware"
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
 	services "github.com/evn/eom_backendl/internal/services/auth"
 )
 
@@ -21,16 +24,44 @@ type AuthHandler struct {
 	db                  *sql.DB
 	jwtService          *services.JWTService
 	telegramAuthService *services.TelegramAuthService
+	auditLog            *auditService.AuditLogger
+	loginLimiter        *services.LoginLimiter
 }
 
-func NewAuthHandler(db *sql.DB, jwtService *services.JWTService, tgService *services.TelegramAuthService) *AuthHandler {
+func NewAuthHandler(db *sql.DB, jwtService *services.JWTService, tgService *services.TelegramAuthService, auditLog *auditService.AuditLogger, loginLimiter *services.LoginLimiter) *AuthHandler {
 	return &AuthHandler{
 		db:                  db,
 		jwtService:          jwtService,
 		telegramAuthService: tgService,
+		auditLog:            auditLog,
+		loginLimiter:        loginLimiter,
 	}
 }
 
+// sessionMetaFromRequest собирает сведения об устройстве для реестра сессий.
+// Мобильное приложение передаёт X-Device-ID и X-Device-Name.
+func sessionMetaFromRequest(r *http.Request) services.SessionMeta {
+	deviceName := r.Header.Get("X-Device-Name")
+	if deviceName == "" {
+		deviceName = r.UserAgent()
+	}
+	return services.SessionMeta{
+		DeviceID:   r.Header.Get("X-Device-ID"),
+		DeviceName: deviceName,
+		IP:         middleware.ClientIP(r),
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// startSession создаёт сессию и выпускает для неё пару токенов.
+func (h *AuthHandler) startSession(r *http.Request, userID int, username, role string, mustChangePassword bool) (string, string, error) {
+	sessionID, err := h.jwtService.CreateSession(r.Context(), userID, sessionMetaFromRequest(r))
+	if err != nil {
+		return "", "", err
+	}
+	return h.jwtService.GenerateToken(userID, username, role, sessionID, mustChangePassword)
+}
+
 func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	type RequestBody struct {
 		RefreshToken string `json:"refresh_token"`
@@ -47,20 +78,44 @@ func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	userID, err := h.jwtService.ValidateRefreshToken(body.RefreshToken)
+	userID, sessionID, err := h.jwtService.ConsumeRefreshToken(r.Context(), body.RefreshToken)
+	if errors.Is(err, services.ErrRefreshTokenReused) {
+		log.Printf("⚠️ Refresh token reuse for user %d, session %s revoked: %v", userID, sessionID, err)
+		h.auditLog.Record(r, "security.refresh_token_reuse", "user", strconv.Itoa(userID), nil,
+			map[string]string{"session_id": sessionID})
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
 		return
 	}
 
 	var username, role string
-	err = h.db.QueryRow("SELECT username, role FROM users WHERE id = $1", userID).Scan(&username, &role)
-	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "User not found")
+	var mustChangePassword bool
+	err = h.db.QueryRow(
+		"SELECT username, role, must_change_password FROM users WHERE id = $1 AND deleted_at IS NULL", userID,
+	).Scan(&username, &role, &mustChangePassword)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not found")
+		return
+	} else if err != nil {
+		log.Printf("Database error loading user %d on refresh: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
 		return
 	}
 
-	accessToken, refreshToken, err := h.jwtService.GenerateToken(userID, username, role)
+	// Токены, выпущенные до реестра сессий, получают сессию при первом обновлении
+	if sessionID == "" {
+		sessionID, err = h.jwtService.CreateSession(r.Context(), userID, sessionMetaFromRequest(r))
+		if err != nil {
+			log.Printf("Failed to create session for user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
+			return
+		}
+	}
+
+	accessToken, refreshToken, err := h.jwtService.GenerateToken(userID, username, role, sessionID, mustChangePassword)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Could not generate token")
 		return
@@ -72,6 +127,12 @@ func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request
 	})
 }
 
+// JWKSHandler публикует открытые ключи, которыми боты проверяют наши токены.
+func (h *AuthHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.RespondWithJSON(w, http.StatusOK, h.jwtService.JWKS())
+}
+
 func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var regData struct {
 		Username  string `json:"username"`
@@ -84,6 +145,11 @@ func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := services.ValidatePassword(regData.Password); err != nil {
+		respondInvalidPassword(w, err)
+		return
+	}
+
 	var count int
 	err := h.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = $1", regData.Username).Scan(&count)
 	if err != nil {
@@ -131,36 +197,60 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := middleware.ClientIP(r)
+	// Попытка засчитывается до проверки пароля, чтобы параллельные запросы
+	// не успели перебрать пароли до блокировки
+	wait, lockouts, err := h.loginLimiter.Attempt(r.Context(), loginData.Username, ip)
+	if err != nil {
+		log.Printf("Login limiter check failed: %v", err)
+		response.RespondWithError(w, http.StatusServiceUnavailable, "Login temporarily unavailable")
+		return
+	}
+	if wait > 0 {
+		respondLoginLocked(w, wait)
+		return
+	}
+
 	var user struct {
 		ID           int
 		Username     string
-		PasswordHash string
+		PasswordHash sql.NullString
 		Role         string
 		Status       string
+		StatusReason string
+		MustChange   bool
 	}
 
 	row := h.db.QueryRow(`
-		SELECT id, username, password_hash, role, status
+		SELECT id, username, password_hash, role, status, COALESCE(status_reason, ''), must_change_password
 		FROM users
-		WHERE LOWER(username) = LOWER($1)`,
+		WHERE LOWER(username) = LOWER($1) AND deleted_at IS NULL`,
 		loginData.Username,
 	)
 
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Status)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			response.RespondWithError(w, http.StatusUnauthorized, "Invalid credentials")
-		} else {
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error: "+err.Error())
-		}
+	err = row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Status, &user.StatusReason, &user.MustChange)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Database error on login: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	if !services.CheckPasswordHash(loginData.Password, user.PasswordHash) {
+	// Несуществующий логин считается такой же неудачей, чтобы по ответам
+	// нельзя было отличить его от неверного пароля
+	if err != nil || !user.PasswordHash.Valid || !services.CheckPasswordHash(loginData.Password, user.PasswordHash.String) {
+		h.recordLockouts(r, lockouts)
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
+	if err := h.loginLimiter.Reset(r.Context(), loginData.Username, ip); err != nil {
+		log.Printf("Failed to reset login failures for %s: %v", user.Username, err)
+	}
+
+	if respondNotAdmitted(w, user.Status, user.StatusReason) {
+		return
+	}
+
 	if user.Status == "pending" && user.Role != "superadmin" {
 		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 			"status":   user.Status,
@@ -172,19 +262,58 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, refreshToken, err := h.jwtService.GenerateToken(user.ID, user.Username, user.Role)
+	token, refreshToken, err := h.startSession(r, user.ID, user.Username, user.Role, user.MustChange)
 	if err != nil {
+		log.Printf("Failed to start session for user %d: %v", user.ID, err)
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	response.RespondWithJSON(w, http.StatusOK, map[string]string{
-		"token":         token,
-		"refresh_token": refreshToken,
-		"role":          user.Role,
+	// С временным паролем токен годится только для POST /api/profile/password
+	response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"token":                token,
+		"refresh_token":        refreshToken,
+		"role":                 user.Role,
+		"must_change_password": user.MustChange,
 	})
 }
 
+// respondNotAdmitted отвечает 403 отклонённым и заблокированным пользователям
+// вместе с причиной, которую указал администратор.
+func respondNotAdmitted(w http.ResponseWriter, status, reason string) bool {
+	var code string
+	switch status {
+	case "rejected":
+		code = "registration_rejected"
+	case "blocked":
+		code = "account_blocked"
+	default:
+		return false
+	}
+	response.RespondWithJSON(w, http.StatusForbidden, struct {
+		response.ErrorBody
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}{response.NewErrorBody(w, code), status, reason})
+	return true
+}
+
+// recordLockouts пишет в журнал блокировки, выставленные неудачным входом.
+func (h *AuthHandler) recordLockouts(r *http.Request, lockouts []services.Lockout) {
+	for _, lockout := range lockouts {
+		log.Printf("⚠️ Login locked for %s %s after %d failures", lockout.Scope, lockout.Key, lockout.Failures)
+		h.auditLog.Record(r, "security.login_lockout", "login_"+lockout.Scope, lockout.Key, nil, map[string]interface{}{
+			"failures":           lockout.Failures,
+			"locked_for_seconds": int(lockout.Duration.Seconds()),
+		})
+	}
+}
+
+func respondLoginLocked(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	response.RespondWithError(w, http.StatusTooManyRequests, "Too many login attempts, try again later")
+}
+
 func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request) {
 	var tgData map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&tgData); err != nil {
@@ -196,7 +325,7 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 	validatedData, err := h.telegramAuthService.ValidateAndExtract(tgData)
 	if err != nil {
 		log.Printf("Telegram auth validation failed: %v", err)
-		response.RespondWithError(w, http.StatusUnauthorized, "Telegram auth failed: "+err.Error())
+		response.RespondWithError(w, http.StatusUnauthorized, "Telegram auth failed")
 		return
 	}
 
@@ -209,7 +338,7 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 	tgIDStr := validatedData["id"]
 	if tgIDStr == "" {
 		log.Println("Missing 'id' in validated Telegram data")
-		response.RespondWithError(w, http.StatusBadRequest, "Missing Telegram user ID")
+		response.RespondWithCode(w, http.StatusBadRequest, "telegram_auth_failed")
 		return
 	}
 
@@ -220,21 +349,72 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	h.telegramLogin(w, r, telegramProfile{
+		ID:        int64(tgID),
+		Username:  validatedData["username"],
+		FirstName: validatedData["first_name"],
+		PhotoURL:  validatedData["photo_url"],
+		Phone:     validatedData["phone"],
+	})
+}
+
+// TelegramWebAppAuthHandler — вход из Telegram Mini App по initData.
+func (h *AuthHandler) TelegramWebAppAuthHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		InitData string `json:"init_data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InitData == "" {
+		response.RespondWithValidation(w, response.FieldError{Field: "init_data", Code: response.FieldRequired})
+		return
+	}
+
+	tgUser, err := h.telegramAuthService.ValidateWebAppData(req.InitData)
+	if err != nil {
+		log.Printf("Telegram WebApp auth validation failed: %v", err)
+		response.RespondWithError(w, http.StatusUnauthorized, "Telegram auth failed")
+		return
+	}
+
+	h.telegramLogin(w, r, telegramProfile{
+		ID:        tgUser.ID,
+		Username:  tgUser.Username,
+		FirstName: tgUser.FirstName,
+		PhotoURL:  tgUser.PhotoURL,
+	})
+}
+
+// telegramProfile — данные пользователя Telegram после проверки подписи.
+type telegramProfile struct {
+	ID        int64
+	Username  string
+	FirstName string
+	PhotoURL  string
+	Phone     string
+}
+
+// telegramLogin находит пользователя по telegram_id или создаёт нового
+// в статусе pending; подтверждённым выдаёт токены.
+func (h *AuthHandler) telegramLogin(w http.ResponseWriter, r *http.Request, profile telegramProfile) {
+	tgID := profile.ID
+	tgIDStr := strconv.FormatInt(tgID, 10)
+
 	var user struct {
-		ID         int
-		Username   string
-		FirstName  string
-		TelegramID sql.NullInt64
-		Role       string
-		Status     string
+		ID           int
+		Username     string
+		FirstName    string
+		TelegramID   sql.NullInt64
+		Role         string
+		Status       string
+		StatusReason string
+		DeletedAt    sql.NullTime
 	}
 
-	err = h.db.QueryRow(`
-		SELECT id, username, first_name, telegram_id, role, status
+	err := h.db.QueryRow(`
+		SELECT id, username, first_name, telegram_id, role, status, COALESCE(status_reason, ''), deleted_at
 		FROM users
 		WHERE telegram_id = $1`,
 		tgID,
-	).Scan(&user.ID, &user.Username, &user.FirstName, &user.TelegramID, &user.Role, &user.Status)
+	).Scan(&user.ID, &user.Username, &user.FirstName, &user.TelegramID, &user.Role, &user.Status, &user.StatusReason, &user.DeletedAt)
 
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		log.Printf("Database error finding user by telegram_id %d: %v", tgID, err)
@@ -242,19 +422,24 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if err == nil && user.DeletedAt.Valid {
+		response.RespondWithError(w, http.StatusForbidden, "Account has been deleted")
+		return
+	}
+
 	if errors.Is(err, sql.ErrNoRows) {
-		tgUsername := validatedData["username"]
+		tgUsername := profile.Username
 		if tgUsername == "" {
 			tgUsername = "tg_user_" + tgIDStr
 		}
 
-		firstName := validatedData["first_name"]
+		firstName := profile.FirstName
 		if firstName == "" {
 			firstName = tgUsername
 		}
 
-		photoURL := validatedData["photo_url"]
-		phone := validatedData["phone"]
+		photoURL := profile.PhotoURL
+		phone := profile.Phone
 
 		err = h.db.QueryRow(`
 			INSERT INTO users (telegram_id, username, first_name, avatar_url, phone, role, status, is_active)
@@ -273,17 +458,19 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 			return
 		}
 
-		user.TelegramID = sql.NullInt64{Int64: int64(tgID), Valid: true}
+		user.TelegramID = sql.NullInt64{Int64: tgID, Valid: true}
 		user.Role = "user"
 		user.Status = "pending"
 	} else {
 		_, err = h.db.Exec(`
 			UPDATE users 
-			SET first_name = $1, avatar_url = $2, phone = $3 
+			SET first_name = COALESCE(NULLIF($1, ''), first_name),
+				avatar_url = COALESCE(NULLIF($2, ''), avatar_url),
+				phone = COALESCE(NULLIF($3, ''), phone)
 			WHERE id = $4`,
-			validatedData["first_name"],
-			validatedData["photo_url"],
-			validatedData["phone"],
+			profile.FirstName,
+			profile.PhotoURL,
+			profile.Phone,
 			user.ID,
 		)
 		if err != nil {
@@ -291,6 +478,10 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	if respondNotAdmitted(w, user.Status, user.StatusReason) {
+		return
+	}
+
 	if user.Status == "pending" && user.Role != "superadmin" {
 		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 			"status":      user.Status,
@@ -304,7 +495,7 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, refreshToken, err := h.jwtService.GenerateToken(user.ID, user.Username, user.Role)
+	token, refreshToken, err := h.startSession(r, user.ID, user.Username, user.Role, false)
 	if err != nil {
 		log.Printf("Failed to generate JWT tokens for user ID %d: %v", user.ID, err)
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
@@ -323,6 +514,16 @@ func (h *AuthHandler) TelegramAuthHandler(w http.ResponseWriter, r *http.Request
 	})
 }
 func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	userID, hasUser := middleware.GetUserIDFromContext(r.Context())
+	sessionID, hasSession := middleware.GetSessionIDFromContext(r.Context())
+	if hasUser && hasSession {
+		if err := h.jwtService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+			log.Printf("Failed to revoke session %s on logout: %v", sessionID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+	}
+
 	response.RespondWithJSON(w, http.StatusOK, map[string]string{
 		"message": "Logged out successfully",
 	})
@@ -337,11 +538,11 @@ func (h *AuthHandler) TelegramAuthCallbackHandler(w http.ResponseWriter, r *http
 	}
 
 	if _, ok := data["id"]; !ok {
-		response.RespondWithError(w, http.StatusBadRequest, "Missing 'id'")
+		response.RespondWithCode(w, http.StatusBadRequest, "telegram_auth_failed")
 		return
 	}
 	if _, ok := data["hash"]; !ok {
-		response.RespondWithError(w, http.StatusBadRequest, "Missing 'hash'")
+		response.RespondWithCode(w, http.StatusBadRequest, "telegram_auth_failed")
 		return
 	}
 
@@ -359,7 +560,8 @@ func (h *AuthHandler) TelegramAuthCallbackHandler(w http.ResponseWriter, r *http
 		strings.NewReader(string(jsonData)),
 	)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Internal service error: "+err.Error())
+		log.Printf("Telegram callback: auth request failed: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Internal service error")
 		return
 	}
 	defer resp.Body.Close()
@@ -397,11 +599,12 @@ func (h *AuthHandler) TelegramAuthCallbackHandler(w http.ResponseWriter, r *http
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(html))
 	} else {
-		errorMsg, _ := result["error"].(string)
-		if errorMsg == "" {
-			errorMsg = "Authorization failed"
+		// Код ошибки отдаём тот же, что вернул /api/auth/telegram
+		code, _ := result["code"].(string)
+		if code == "" {
+			code = "telegram_auth_failed"
 		}
-		response.RespondWithError(w, resp.StatusCode, errorMsg)
+		response.RespondWithCode(w, resp.StatusCode, code)
 	}
 }
 
@@ -424,18 +627,20 @@ func (h *AuthHandler) CompleteRegistrationHandler(w http.ResponseWriter, r *http
 		Phone     string `json:"phone"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&regData); err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid request data: "+err.Error())
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
 		return
 	}
 	if regData.FirstName == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "First name is required")
+		response.RespondWithValidation(w, response.FieldError{Field: "first_name", Code: response.FieldRequired})
 		return
 	}
 
-	_, err := h.db.Exec(`
+	// Заблокированный пользователь не может снова встать в очередь
+	res, err := h.db.Exec(`
 		UPDATE users 
-		SET first_name = $1, last_name = $2, phone = $3, status = 'pending', is_active = FALSE 
-		WHERE id = $4`,
+		SET first_name = $1, last_name = $2, phone = $3, status = 'pending', is_active = FALSE,
+		    status_reason = NULL, registration_submitted_at = NOW()
+		WHERE id = $4 AND status IS DISTINCT FROM 'blocked'`,
 		regData.FirstName,
 		regData.LastName,
 		regData.Phone,
@@ -446,6 +651,10 @@ func (h *AuthHandler) CompleteRegistrationHandler(w http.ResponseWriter, r *http
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user profile")
 		return
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		response.RespondWithError(w, http.StatusForbidden, "Account is blocked")
+		return
+	}
 
 	log.Printf("User %d completed registration and is now pending approval", userID)
 
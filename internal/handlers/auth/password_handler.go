@@ -0,0 +1,262 @@
+// handlers/password_handler.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	services "github.com/evn/eom_backendl/internal/services/auth"
+)
+
+type PasswordHandler struct {
+	db           *sql.DB
+	jwtService   *services.JWTService
+	resetService *services.PasswordResetService
+	loginLimiter *services.LoginLimiter
+	auditLog     *auditService.AuditLogger
+}
+
+func NewPasswordHandler(db *sql.DB, jwtService *services.JWTService, resetService *services.PasswordResetService, loginLimiter *services.LoginLimiter, auditLog *auditService.AuditLogger) *PasswordHandler {
+	return &PasswordHandler{
+		db:           db,
+		jwtService:   jwtService,
+		resetService: resetService,
+		loginLimiter: loginLimiter,
+		auditLog:     auditLog,
+	}
+}
+
+// ChangePassword меняет пароль текущего пользователя. Пользователь без пароля
+// (вошёл через Telegram) задаёт его без current_password. Остальные сессии
+// завершаются, текущая получает новые токены.
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	var user struct {
+		Username     string
+		Role         string
+		PasswordHash sql.NullString
+	}
+	err := h.db.QueryRow(
+		"SELECT username, role, password_hash FROM users WHERE id = $1 AND deleted_at IS NULL", userID,
+	).Scan(&user.Username, &user.Role, &user.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	} else if err != nil {
+		log.Printf("DB error loading user %d for password change: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if user.PasswordHash.Valid && user.PasswordHash.String != "" {
+		if !services.CheckPasswordHash(req.CurrentPassword, user.PasswordHash.String) {
+			response.RespondWithError(w, http.StatusUnauthorized, "Current password is incorrect")
+			return
+		}
+		if req.NewPassword == req.CurrentPassword {
+			response.RespondWithError(w, http.StatusBadRequest, "New password must differ from the current one")
+			return
+		}
+	}
+
+	if err := services.ValidatePassword(req.NewPassword); err != nil {
+		respondInvalidPassword(w, err)
+		return
+	}
+
+	if err := h.setPassword(userID, req.NewPassword); err != nil {
+		log.Printf("Failed to change password of user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	sessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+	if _, err := h.jwtService.RevokeOtherSessions(r.Context(), userID, sessionID); err != nil {
+		log.Printf("Failed to revoke other sessions of user %d: %v", userID, err)
+	}
+	h.auditLog.Record(r, "user.password_change", "user", strconv.Itoa(userID), nil, nil)
+
+	// Текущий токен мог нести pwd_change — выдаём новую пару без него
+	if sessionID == "" {
+		sessionID, err = h.jwtService.CreateSession(r.Context(), userID, sessionMetaFromRequest(r))
+		if err != nil {
+			log.Printf("Failed to create session for user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+	}
+	token, refreshToken, err := h.jwtService.GenerateToken(userID, user.Username, user.Role, sessionID, false)
+	if err != nil {
+		log.Printf("Failed to generate tokens for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"message":       "Password changed successfully",
+		"token":         token,
+		"refresh_token": refreshToken,
+	})
+}
+
+// RequestReset отправляет код сброса в Telegram. Ответ одинаковый для любых
+// логинов, чтобы по нему нельзя было проверить существование аккаунта.
+func (h *PasswordHandler) RequestReset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		response.RespondWithValidation(w, response.FieldError{Field: "username", Code: response.FieldRequired})
+		return
+	}
+
+	// Ответ один и тот же во всех случаях, иначе по нему видно, есть ли
+	// такой логин и привязан ли к нему Telegram
+	const message = "If the account exists and has Telegram linked, a reset code has been sent"
+
+	allowed, err := h.resetService.AllowRequest(r.Context(), req.Username, middleware.ClientIP(r))
+	if err != nil {
+		log.Printf("Password reset limiter failed: %v", err)
+		response.RespondWithError(w, http.StatusServiceUnavailable, "Password reset temporarily unavailable")
+		return
+	}
+	if !allowed {
+		log.Printf("Password reset requests limit reached for %q from %s", req.Username, middleware.ClientIP(r))
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+		return
+	}
+
+	var userID int
+	var telegramID sql.NullInt64
+	err = h.db.QueryRow(
+		"SELECT id, telegram_id FROM users WHERE LOWER(username) = LOWER($1) AND deleted_at IS NULL", req.Username,
+	).Scan(&userID, &telegramID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("DB error on password reset request: %v", err)
+		}
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+		return
+	}
+	if !telegramID.Valid {
+		response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+		return
+	}
+
+	err = h.resetService.SendCode(r.Context(), userID, telegramID.Int64)
+	if errors.Is(err, services.ErrResetCooldown) {
+		log.Printf("Password reset code for user %d was sent recently, not sending again", userID)
+	} else if err != nil {
+		log.Printf("Failed to send password reset code to user %d: %v", userID, err)
+	} else {
+		h.auditLog.Record(r, "user.password_reset_request", "user", strconv.Itoa(userID), nil, nil)
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
+
+// ConfirmReset задаёт новый пароль по коду из Telegram и завершает все сессии.
+func (h *PasswordHandler) ConfirmReset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username    string `json:"username"`
+		Code        string `json:"code"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" || req.Code == "" {
+		response.RespondWithCode(w, http.StatusBadRequest, "missing_fields")
+		return
+	}
+
+	if err := services.ValidatePassword(req.NewPassword); err != nil {
+		respondInvalidPassword(w, err)
+		return
+	}
+
+	var userID int
+	var username string
+	err := h.db.QueryRow(
+		"SELECT id, username FROM users WHERE LOWER(username) = LOWER($1) AND deleted_at IS NULL", req.Username,
+	).Scan(&userID, &username)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid or expired code")
+		return
+	} else if err != nil {
+		log.Printf("DB error on password reset confirm: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if err := h.resetService.VerifyCode(r.Context(), userID, req.Code); err != nil {
+		if !errors.Is(err, services.ErrInvalidResetCode) {
+			log.Printf("Failed to verify reset code of user %d: %v", userID, err)
+		}
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid or expired code")
+		return
+	}
+
+	if err := h.setPassword(userID, req.NewPassword); err != nil {
+		log.Printf("Failed to reset password of user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	if err := h.jwtService.RevokeAllSessions(r.Context(), userID); err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", userID, err)
+	}
+	if err := h.loginLimiter.Unlock(r.Context(), username, ""); err != nil {
+		log.Printf("Failed to unlock login of user %d: %v", userID, err)
+	}
+	h.auditLog.Record(r, "user.password_reset", "user", strconv.Itoa(userID), nil, nil)
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Password reset successfully"})
+}
+
+// setPassword сохраняет новый пароль и снимает требование смены.
+func (h *PasswordHandler) setPassword(userID int, password string) error {
+	passwordHash, err := services.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec(`
+		UPDATE users
+		SET password_hash = $1, must_change_password = FALSE, password_changed_at = NOW()
+		WHERE id = $2`,
+		passwordHash, userID,
+	)
+	return err
+}
+
+// respondInvalidPassword отвечает кодом причины, по которой пароль не прошёл ValidatePassword.
+func respondInvalidPassword(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrPasswordTooShort):
+		response.RespondWithCode(w, http.StatusBadRequest, "password_too_short")
+	case errors.Is(err, services.ErrPasswordTooLong):
+		response.RespondWithCode(w, http.StatusBadRequest, "password_too_long")
+	case errors.Is(err, services.ErrPasswordTooWeak):
+		response.RespondWithCode(w, http.StatusBadRequest, "password_too_weak")
+	default:
+		response.RespondWithCode(w, http.StatusBadRequest, response.CodeBadRequest)
+	}
+}
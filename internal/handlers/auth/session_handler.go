@@ -0,0 +1,131 @@
+// handlers/session_handler.go
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	services "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+type SessionHandler struct {
+	jwtService *services.JWTService
+	auditLog   *auditService.AuditLogger
+}
+
+func NewSessionHandler(jwtService *services.JWTService, auditLog *auditService.AuditLogger) *SessionHandler {
+	return &SessionHandler{jwtService: jwtService, auditLog: auditLog}
+}
+
+type sessionView struct {
+	services.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions возвращает сессии текущего пользователя.
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	currentSID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	sessions, err := h.jwtService.ListSessions(r.Context(), userID)
+	if err != nil {
+		log.Printf("Failed to list sessions for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to load sessions")
+		return
+	}
+
+	views := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		views = append(views, sessionView{Session: s, Current: s.ID == currentSID})
+	}
+	response.RespondWithJSON(w, http.StatusOK, views)
+}
+
+// RevokeSession завершает одну из сессий текущего пользователя.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessionID := chi.URLParam(r, "sessionID")
+	session, err := h.jwtService.GetSession(r.Context(), sessionID)
+	if err != nil || session.UserID != userID {
+		response.RespondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if err := h.jwtService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		log.Printf("Failed to revoke session %s of user %d: %v", sessionID, userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей.
+func (h *SessionHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	currentSID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	revoked, err := h.jwtService.RevokeOtherSessions(r.Context(), userID, currentSID)
+	if err != nil {
+		log.Printf("Failed to revoke sessions of user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Other sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// ListUserSessions — сессии выбранного пользователя для администратора.
+func (h *SessionHandler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.jwtService.ListSessions(r.Context(), userID)
+	if err != nil {
+		log.Printf("Failed to list sessions for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to load sessions")
+		return
+	}
+	response.RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeUserSessions — принудительный выход пользователя со всех устройств.
+func (h *SessionHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.jwtService.RevokeAllSessions(r.Context(), userID); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+	h.auditLog.Record(r, "user.sessions_revoke", "user", strconv.Itoa(userID), nil, nil)
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "All sessions revoked"})
+}
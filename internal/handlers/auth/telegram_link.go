@@ -0,0 +1,150 @@
+// handlers/telegram_link.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+// LinkTelegramHandler привязывает Telegram к текущему пользователю.
+// Тело — initData Mini App ({"init_data": "..."}) или поля Login Widget.
+func (h *AuthHandler) LinkTelegramHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var data map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	profile, err := h.verifyTelegramData(data)
+	if err != nil {
+		log.Printf("Telegram link validation failed for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusUnauthorized, "Telegram auth failed")
+		return
+	}
+
+	var ownerID int
+	err = h.db.QueryRow("SELECT id FROM users WHERE telegram_id = $1", profile.ID).Scan(&ownerID)
+	if err == nil && ownerID != userID {
+		// Дубликат разбирает администратор через слияние аккаунтов
+		response.RespondWithCode(w, http.StatusConflict, "telegram_linked_to_other")
+		return
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("DB error checking telegram_id %d: %v", profile.ID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	res, err := h.db.Exec(`
+		UPDATE users
+		SET telegram_id = $1, avatar_url = COALESCE(avatar_url, NULLIF($2, ''))
+		WHERE id = $3 AND deleted_at IS NULL AND (telegram_id IS NULL OR telegram_id = $1)`,
+		profile.ID, profile.PhotoURL, userID,
+	)
+	if err != nil {
+		log.Printf("Failed to link telegram_id %d to user %d: %v", profile.ID, userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to link Telegram")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		response.RespondWithCode(w, http.StatusConflict, "telegram_already_linked")
+		return
+	}
+
+	h.auditLog.Record(r, "user.telegram_link", "user", strconv.Itoa(userID), nil,
+		map[string]int64{"telegram_id": profile.ID})
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":     "Telegram linked successfully",
+		"telegram_id": profile.ID,
+	})
+}
+
+// UnlinkTelegramHandler отвязывает Telegram. Без пароля пользователь
+// потерял бы доступ к аккаунту, поэтому такой случай запрещён.
+func (h *AuthHandler) UnlinkTelegramHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var telegramID sql.NullInt64
+	var passwordHash sql.NullString
+	err := h.db.QueryRow(
+		"SELECT telegram_id, password_hash FROM users WHERE id = $1 AND deleted_at IS NULL", userID,
+	).Scan(&telegramID, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	} else if err != nil {
+		log.Printf("DB error loading user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if !telegramID.Valid {
+		response.RespondWithCode(w, http.StatusConflict, "telegram_not_linked")
+		return
+	}
+	if !passwordHash.Valid || passwordHash.String == "" {
+		response.RespondWithCode(w, http.StatusConflict, "password_required_to_unlink")
+		return
+	}
+
+	if _, err := h.db.Exec("UPDATE users SET telegram_id = NULL WHERE id = $1", userID); err != nil {
+		log.Printf("Failed to unlink Telegram from user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to unlink Telegram")
+		return
+	}
+
+	h.auditLog.Record(r, "user.telegram_unlink", "user", strconv.Itoa(userID),
+		map[string]int64{"telegram_id": telegramID.Int64}, nil)
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Telegram unlinked successfully"})
+}
+
+// verifyTelegramData проверяет подпись данных Telegram: initData Mini App
+// или поля Login Widget.
+func (h *AuthHandler) verifyTelegramData(data map[string]string) (telegramProfile, error) {
+	if initData := data["init_data"]; initData != "" {
+		tgUser, err := h.telegramAuthService.ValidateWebAppData(initData)
+		if err != nil {
+			return telegramProfile{}, err
+		}
+		return telegramProfile{
+			ID:        tgUser.ID,
+			Username:  tgUser.Username,
+			FirstName: tgUser.FirstName,
+			PhotoURL:  tgUser.PhotoURL,
+		}, nil
+	}
+
+	validated, err := h.telegramAuthService.ValidateAndExtract(data)
+	if err != nil {
+		return telegramProfile{}, err
+	}
+	tgID, err := strconv.ParseInt(validated["id"], 10, 64)
+	if err != nil {
+		return telegramProfile{}, fmt.Errorf("invalid Telegram ID format: %s", validated["id"])
+	}
+	return telegramProfile{
+		ID:        tgID,
+		Username:  validated["username"],
+		FirstName: validated["first_name"],
+		PhotoURL:  validated["photo_url"],
+	}, nil
+}
@@ -62,7 +62,7 @@ func (h *GeoTrackHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	toStr := r.URL.Query().Get("to")
 
 	if userID == "" || fromStr == "" || toStr == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "Missing required query params: user_id, from, to")
+		response.RespondWithCode(w, http.StatusBadRequest, "missing_fields")
 		return
 	}
 
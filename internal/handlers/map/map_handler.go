@@ -2,25 +2,28 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
 	"github.com/go-chi/chi/v5"
-		"github.com/evn/eom_backendl/internal/pkg/response"
-
 )
 
 type MapHandler struct {
-	db *sql.DB
+	db       *sql.DB
+	auditLog *auditService.AuditLogger
+	store    storageService.Storage
 }
 
-func NewMapHandler(db *sql.DB) *MapHandler {
-	return &MapHandler{db: db}
+func NewMapHandler(db *sql.DB, auditLog *auditService.AuditLogger, store storageService.Storage) *MapHandler {
+	return &MapHandler{db: db, auditLog: auditLog, store: store}
 }
 
 type Map struct {
@@ -49,7 +52,7 @@ func (h *MapHandler) UploadMapHandler(w http.ResponseWriter, r *http.Request) {
 
 	city := r.FormValue("city")
 	if city == "" {
-		response.RespondWithError(w, http.StatusBadRequest, "City is required")
+		response.RespondWithValidation(w, response.FieldError{Field: "city", Code: response.FieldRequired})
 		return
 	}
 
@@ -58,21 +61,14 @@ func (h *MapHandler) UploadMapHandler(w http.ResponseWriter, r *http.Request) {
 	file, handler, err := r.FormFile("geojson_file")
 	if err != nil {
 		log.Printf("Error retrieving file: %v", err)
-		response.RespondWithError(w, http.StatusBadRequest, "Error retrieving file")
+		response.RespondWithValidation(w, response.FieldError{Field: "geojson_file", Code: response.FieldRequired})
 		return
 	}
 	defer file.Close()
 
 	ext := filepath.Ext(handler.Filename)
 	if ext != ".geojson" && ext != ".json" {
-		response.RespondWithError(w, http.StatusBadRequest, "Only .geojson and .json files are allowed")
-		return
-	}
-
-	mapDir := "./uploads/maps"
-	if err := os.MkdirAll(mapDir, 0755); err != nil {
-		log.Printf("Error creating map directory: %v", err)
-		response.RespondWithError(w, http.StatusInternalServerError, "Error creating directory")
+		response.RespondWithValidation(w, response.FieldError{Field: "geojson_file", Code: response.FieldInvalidFormat, Params: map[string]interface{}{"format": ".geojson, .json"}})
 		return
 	}
 
@@ -91,75 +87,85 @@ func (h *MapHandler) UploadMapHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Генерируем имя файла с использованием реального ID
 	filename := fmt.Sprintf("map_%d%s", mapID, ext)
-	filePath := filepath.Join(mapDir, filename)
+	fileKey := "maps/" + filename
+	fileSize := handler.Size
 
-	// Создаём файл на диске
-	dst, err := os.Create(filePath)
-	if err != nil {
-		log.Printf("Error creating file: %v", err)
+	if err := h.store.Put(r.Context(), fileKey, file, fileSize, "application/geo+json"); err != nil {
+		log.Printf("Error saving map file: %v", err)
 		// Откат: удаляем запись из БД
 		h.db.Exec("DELETE FROM maps WHERE id = $1", mapID)
-		response.RespondWithError(w, http.StatusInternalServerError, "Error creating file")
-		return
-	}
-	defer dst.Close()
-
-	// Копируем содержимое
-	if _, err := io.Copy(dst, file); err != nil {
-		log.Printf("Error copying file: %v", err)
-		dst.Close()
-		os.Remove(filePath)
-		h.db.Exec("DELETE FROM maps WHERE id = $1", mapID)
 		response.RespondWithError(w, http.StatusInternalServerError, "Error saving file")
 		return
 	}
 
-	// Получаем размер файла
-	fileInfo, err := dst.Stat()
-	if err != nil {
-		log.Printf("Error getting file info: %v", err)
-		dst.Close()
-		os.Remove(filePath)
-		h.db.Exec("DELETE FROM maps WHERE id = $1", mapID)
-		response.RespondWithError(w, http.StatusInternalServerError, "Error reading file")
-		return
-	}
-
 	// Обновляем запись в БД с реальными данными файла
 	_, err = h.db.Exec(`
 		UPDATE maps
 		SET file_name = $1, file_size = $2
 		WHERE id = $3
-	`, filename, fileInfo.Size(), mapID)
+	`, filename, fileSize, mapID)
 	if err != nil {
 		log.Printf("Error updating map record: %v", err)
-		dst.Close()
-		os.Remove(filePath)
+		h.store.Delete(r.Context(), fileKey)
 		h.db.Exec("DELETE FROM maps WHERE id = $1", mapID)
 		response.RespondWithError(w, http.StatusInternalServerError, "Error finalizing map upload")
 		return
 	}
 
+	h.auditLog.Record(r, "map.upload", "map", strconv.Itoa(mapID), nil, map[string]interface{}{
+		"city":        city,
+		"description": description,
+		"file_name":   filename,
+		"file_size":   fileSize,
+	})
+
 	responseData := map[string]interface{}{
 		"id":          mapID,
 		"city":        city,
 		"description": description,
 		"file_name":   filename,
-		"file_size":   fileInfo.Size(),
+		"file_size":   fileSize,
 		"message":     "Map uploaded successfully",
 	}
 	response.RespondWithJSON(w, http.StatusCreated, responseData)
 }
 
 // GetMapsHandler возвращает список всех загруженных карт
+// MapsSpec — сортировки списка карт; from/to — по дате загрузки.
+var MapsSpec = listing.Spec{
+	Sorts: map[string]string{
+		"upload_date": "COALESCE(upload_date, to_timestamp(0))",
+		"city":        "city",
+	},
+	DefaultSort: "-upload_date",
+	IDColumn:    "id",
+	Filters:     []string{listing.FilterDate},
+	Columns:     listing.Columns{Date: "upload_date"},
+}
+
+// GetMapsHandler — карты постранично (см. MapsSpec).
 func (h *MapHandler) GetMapsHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := listing.Parse(r, MapsSpec)
+	if err != nil {
+		response.RespondWithBadRequest(w, err)
+		return
+	}
+	q := listing.NewQuery(MapsSpec, params)
+
+	var total int
+	where, args := q.Count()
+	if err := h.db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM maps"+where, args...).Scan(&total); err != nil {
+		log.Printf("Database count error: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	page, args := q.Page()
 	query := `
-		SELECT id, city, description, file_name, file_size, upload_date
-		FROM maps
-		ORDER BY upload_date DESC
-	`
+		SELECT id, city, COALESCE(description, ''), file_name, file_size, upload_date, ` + q.SortValue() + `
+		FROM maps` + page
 
-	rows, err := h.db.Query(query)
+	rows, err := h.db.QueryContext(r.Context(), query, args...)
 	if err != nil {
 		log.Printf("Database query error: %v", err)
 		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
@@ -167,17 +173,28 @@ func (h *MapHandler) GetMapsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
-	var maps []Map
+	maps := []Map{}
+	var keys []listing.Key
 	for rows.Next() {
 		var m Map
-		if err := rows.Scan(&m.ID, &m.City, &m.Description, &m.FileName, &m.FileSize, &m.UploadDate); err != nil {
+		var key listing.Key
+		if err := rows.Scan(&m.ID, &m.City, &m.Description, &m.FileName, &m.FileSize, &m.UploadDate, &key.Value); err != nil {
 			log.Printf("Error scanning row: %v", err)
-			continue
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
 		}
+		key.ID = m.ID
+		keys = append(keys, key)
 		maps = append(maps, m)
 	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Row iteration error: %v", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
 
-	response.RespondWithJSON(w, http.StatusOK, maps)
+	n, result := q.Result(keys, total)
+	response.RespondWithPage(w, result, maps[:n])
 }
 
 // GetMapByIDHandler возвращает информацию о конкретной карте
@@ -218,8 +235,13 @@ func (h *MapHandler) DeleteMapHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var fileName string
-	err = h.db.QueryRow("SELECT file_name FROM maps WHERE id = $1", id).Scan(&fileName)
+	var before Map
+	err = h.db.QueryRow(`
+		SELECT id, city, description, file_name, file_size, upload_date
+		FROM maps
+		WHERE id = $1
+	`, id).Scan(&before.ID, &before.City, &before.Description, &before.FileName, &before.FileSize, &before.UploadDate)
+	fileName := before.FileName
 	if err != nil {
 		if err == sql.ErrNoRows {
 			response.RespondWithError(w, http.StatusNotFound, "Map not found")
@@ -237,11 +259,10 @@ func (h *MapHandler) DeleteMapHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filePath := filepath.Join("./uploads/maps", fileName)
-	if err := os.Remove(filePath); err != nil {
-		if !os.IsNotExist(err) {
-			log.Printf("Warning: failed to delete map file %s: %v", filePath, err)
-		}
+	h.auditLog.Record(r, "map.delete", "map", strconv.Itoa(id), before, nil)
+
+	if err := h.store.Delete(r.Context(), "maps/"+fileName); err != nil {
+		log.Printf("Warning: failed to delete map file %s: %v", fileName, err)
 	}
 
 	response.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Map deleted successfully"})
@@ -249,17 +270,22 @@ func (h *MapHandler) DeleteMapHandler(w http.ResponseWriter, r *http.Request) {
 
 // ServeMapFileHandler отдает файл карты для скачивания
 func (h *MapHandler) ServeMapFileHandler(w http.ResponseWriter, r *http.Request) {
-	filename := chi.URLParam(r, "filename")
-	filePath := filepath.Join("./uploads/maps", filename)
+	filename := filepath.Base(chi.URLParam(r, "filename"))
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	obj, info, err := h.store.Get(r.Context(), "maps/"+filename)
+	if errors.Is(err, storageService.ErrNotExist) {
 		response.RespondWithError(w, http.StatusNotFound, "File not found")
 		return
+	} else if err != nil {
+		log.Printf("Storage error reading map %s: %v", filename, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Storage error")
+		return
 	}
+	defer obj.Close()
 
 	w.Header().Set("Content-Type", "application/geo+json")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
-	http.ServeFile(w, r, filePath)
+	http.ServeContent(w, r, filename, info.ModTime, obj)
 }
 
 // CreateMapsTable создает таблицу для хранения информации о картах
@@ -0,0 +1,130 @@
+// handlers/uploads_handler.go
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// publicUploadDirs — каталоги, которые отдаются без подписи (сборки приложения).
+var publicUploadDirs = map[string]bool{
+	"app": true,
+}
+
+type UploadsHandler struct {
+	db     *sql.DB
+	signer *mediaService.URLSigner
+	store  storageService.Storage
+}
+
+func NewUploadsHandler(db *sql.DB, signer *mediaService.URLSigner, store storageService.Storage) *UploadsHandler {
+	return &UploadsHandler{db: db, signer: signer, store: store}
+}
+
+// ServeUploadHandler отдаёт файл из хранилища только по действующей подписанной ссылке.
+func (h *UploadsHandler) ServeUploadHandler(w http.ResponseWriter, r *http.Request) {
+	rel, ok := storageService.CleanKey(chi.URLParam(r, "*"))
+	if !ok {
+		response.RespondWithError(w, http.StatusNotFound, "File not found")
+		return
+	}
+
+	dir := strings.SplitN(rel, "/", 2)[0]
+	if !publicUploadDirs[dir] {
+		q := r.URL.Query()
+		if err := h.signer.Verify("/uploads/"+rel, q.Get("expires"), q.Get("sig")); err != nil {
+			response.RespondWithError(w, http.StatusForbidden, "Invalid or expired link")
+			return
+		}
+		w.Header().Set("Cache-Control", "private, max-age=300")
+	}
+
+	obj, info, err := h.store.Get(r.Context(), rel)
+	if errors.Is(err, storageService.ErrNotExist) {
+		response.RespondWithError(w, http.StatusNotFound, "File not found")
+		return
+	} else if err != nil {
+		log.Printf("Storage error reading %s: %v", rel, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Storage error")
+		return
+	}
+	defer obj.Close()
+
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	http.ServeContent(w, r, path.Base(rel), info.ModTime, obj)
+}
+
+// SignUploadHandler выдаёт временную ссылку на файл после проверки доступа.
+func (h *UploadsHandler) SignUploadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	role, _ := middleware.GetUserRoleFromContext(r.Context())
+
+	rel, ok := storageService.CleanKey(strings.TrimPrefix(r.URL.Query().Get("path"), "/uploads/"))
+	if !ok {
+		response.RespondWithValidation(w, response.FieldError{Field: "path", Code: response.FieldInvalid})
+		return
+	}
+	uploadPath := "/uploads/" + rel
+
+	allowed, err := h.canAccess(userID, role, rel)
+	if err != nil {
+		log.Printf("DB error checking access to %s for user %d: %v", uploadPath, userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !allowed {
+		response.RespondWithError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	response.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"url": h.signer.Sign(uploadPath),
+	})
+}
+
+// canAccess: персонал видит всё, остальные — только свои селфи, фото заданий и
+// отчётов о смене.
+func (h *UploadsHandler) canAccess(userID int, role, rel string) (bool, error) {
+	if middleware.IsStaff(role) {
+		return true, nil
+	}
+
+	parts := strings.SplitN(rel, "/", 2)
+	if len(parts) != 2 {
+		return false, nil
+	}
+
+	switch parts[0] {
+	case "selfies":
+		var exists bool
+		err := h.db.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM slots WHERE (selfie_path = $1 OR selfie_thumb_path = $1) AND user_id = $2)",
+			"/uploads/"+rel, userID,
+		).Scan(&exists)
+		return exists, err
+	case "tasks":
+		return strings.HasPrefix(parts[1], fmt.Sprintf("task_%d_", userID)), nil
+	case "reports":
+		name := strings.TrimPrefix(parts[1], "thumbs/")
+		return strings.HasPrefix(name, fmt.Sprintf("report_%d_", userID)), nil
+	default:
+		return false, nil
+	}
+}
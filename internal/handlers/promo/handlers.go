@@ -15,6 +15,7 @@ import (
 	"github.com/evn/eom_backendl/internal/middleware"
 	"github.com/evn/eom_backendl/internal/pkg/response"
 	"github.com/evn/eom_backendl/internal/repositories"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
 	"github.com/go-chi/chi/v5"
 	"github.com/xuri/excelize/v2"
 	"google.golang.org/api/option"
@@ -91,8 +92,12 @@ func ClaimPromoByBrandHandler(db *sql.DB) http.HandlerFunc {
 			codes, err = h.repo.ClaimSinglePromoForUser(brand, userID)
 		}
 
-		if err != nil {
-			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		if errors.Is(err, repositories.ErrNoPromoCodes) {
+			response.RespondWithCode(w, http.StatusBadRequest, "promo_sold_out")
+			return
+		} else if err != nil {
+			log.Printf("Ошибка выдачи промокода %s пользователю %d: %v", brand, userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Ошибка выдачи промокода")
 			return
 		}
 
@@ -136,12 +141,13 @@ func UploadPromoCodesHandler(db *sql.DB) http.HandlerFunc {
 				return
 			}
 			if req.GoogleSheetURL == "" {
-				response.RespondWithError(w, http.StatusBadRequest, "google_sheet_url обязателен")
+				response.RespondWithValidation(w, response.FieldError{Field: "google_sheet_url", Code: response.FieldRequired})
 				return
 			}
 			rows, err = readFromGoogleSheet(req.GoogleSheetURL)
 			if err != nil {
-				response.RespondWithError(w, http.StatusInternalServerError, "Ошибка чтения Google Sheets: "+err.Error())
+				log.Printf("Ошибка чтения Google Sheets: %v", err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Ошибка чтения Google Sheets")
 				return
 			}
 		} else {
@@ -154,14 +160,14 @@ func UploadPromoCodesHandler(db *sql.DB) http.HandlerFunc {
 
 			xlsx, err := excelize.OpenReader(file)
 			if err != nil {
-				response.RespondWithError(w, http.StatusBadRequest, "Неверный формат Excel")
+				response.RespondWithCode(w, http.StatusBadRequest, "invalid_excel")
 				return
 			}
 			rows, err = xlsx.GetRows("Sheet1")
 			if err != nil {
 				sheets := xlsx.GetSheetList()
 				if len(sheets) == 0 {
-					response.RespondWithError(w, http.StatusBadRequest, "Пустой Excel")
+					response.RespondWithCode(w, http.StatusBadRequest, "promo_file_empty")
 					return
 				}
 				rows, err = xlsx.GetRows(sheets[0])
@@ -173,12 +179,18 @@ func UploadPromoCodesHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if len(rows) < 2 {
-			response.RespondWithError(w, http.StatusBadRequest, "Файл должен содержать заголовок и хотя бы одну строку")
+			response.RespondWithCode(w, http.StatusBadRequest, "promo_file_empty")
 			return
 		}
 
-		if err := validateAndSavePromos(db, rows, userID); err != nil {
-			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		err = validateAndSavePromos(db, rows, userID)
+		var invalid *response.ValidationError
+		if errors.As(err, &invalid) {
+			response.RespondWithBadRequest(w, err)
+			return
+		} else if err != nil {
+			log.Printf("Ошибка сохранения промокодов: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Ошибка сохранения промокодов")
 			return
 		}
 
@@ -266,7 +278,7 @@ type SetActiveBrandRequest struct {
 	Days  int    `json:"days"`
 }
 
-func SetActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
+func SetActivePromoBrandHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok || !isAdmin(userID, db) {
@@ -291,7 +303,14 @@ func SetActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
 			days = 10
 		}
 
-		_, err := db.Exec(`
+		before, err := currentActiveBrand(db)
+		if err != nil {
+			log.Printf("Ошибка чтения активного бренда: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Ошибка сервера")
+			return
+		}
+
+		_, err = db.Exec(`
 			INSERT INTO active_promo_brand (brand, expires_at)
 			VALUES ($1, NOW() + $2 * INTERVAL '1 day')
 			ON CONFLICT ((brand IS NOT NULL)) DO UPDATE
@@ -303,13 +322,16 @@ func SetActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		auditLog.Record(r, "promo.brand_activate", "promo_brand", brand, before,
+			map[string]interface{}{"brand": brand, "days": days})
+
 		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 			"status": "ok",
 		})
 	}
 }
 
-func ClearActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
+func ClearActivePromoBrandHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.GetUserIDFromContext(r.Context())
 		if !ok || !isAdmin(userID, db) {
@@ -317,13 +339,22 @@ func ClearActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		_, err := db.Exec("DELETE FROM active_promo_brand")
+		before, err := currentActiveBrand(db)
+		if err != nil {
+			log.Printf("Ошибка чтения активного бренда: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Ошибка сервера")
+			return
+		}
+
+		_, err = db.Exec("DELETE FROM active_promo_brand")
 		if err != nil {
 			log.Printf("Ошибка очистки активного бренда: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Ошибка сервера")
 			return
 		}
 
+		auditLog.Record(r, "promo.brand_clear", "promo_brand", "", before, nil)
+
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "cleared"})
 	}
 }
@@ -360,15 +391,41 @@ func GetActivePromoBrandHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
+// currentActiveBrand возвращает действующий бренд для журнала или nil.
+func currentActiveBrand(db *sql.DB) (map[string]interface{}, error) {
+	var brand string
+	var expiresAt time.Time
+	err := db.QueryRow(`
+		SELECT brand, expires_at 
+		FROM active_promo_brand 
+		WHERE expires_at > NOW()
+	`).Scan(&brand, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"brand": brand, "expires_at": expiresAt}, nil
+}
+
+// promoBrands — бренды, для которых загружаются промокоды.
+var promoBrands = []string{"JET", "YANDEX", "WHOOSH", "BOLT"}
+
+// maxPromoCodeLength — длина колонки promo_codes.promo_code.
+const maxPromoCodeLength = 100
+
+// validateAndSavePromos проверяет строки файла и сохраняет промокоды одной
+// транзакцией. Ошибки в строках возвращаются как response.ValidationError
+// с полем rows[N], где N — номер строки в файле; прочие ошибки — ошибки БД.
 func validateAndSavePromos(db *sql.DB, rows [][]string, adminID int) error {
-	if len(rows) < 1 {
-		return fmt.Errorf("нет данных")
+	if len(rows) < 2 {
+		return response.Invalid(nil, response.FieldError{Field: "rows", Code: response.FieldRequired})
 	}
 
-	dataRows := rows[1:]
 	groups := make(map[string][]string)
+	var keys []string
 
-	for _, row := range dataRows {
+	for i, row := range rows[1:] {
 		if len(row) < 3 {
 			continue
 		}
@@ -380,24 +437,32 @@ func validateAndSavePromos(db *sql.DB, rows [][]string, adminID int) error {
 			continue
 		}
 
-		if brand != "JET" && brand != "YANDEX" && brand != "WHOOSH" && brand != "BOLT" {
-			return fmt.Errorf("неизвестный бренд: %s", brand)
+		field := fmt.Sprintf("rows[%d]", i+2)
+		if !isPromoBrand(brand) {
+			return response.Invalid(nil, response.FieldError{Field: field + ".brand", Code: response.FieldOneOf,
+				Params: map[string]interface{}{"allowed": strings.Join(promoBrands, ", "), "value": brand}})
 		}
-
-		_, err := time.Parse("2006-01-02", validStr)
-		if err != nil {
-			return fmt.Errorf("неверный формат даты (ожидается ГГГГ-ММ-ДД): %s", validStr)
+		if len([]rune(code)) > maxPromoCodeLength {
+			return response.Invalid(nil, response.FieldError{Field: field + ".promo_code", Code: response.FieldTooLong,
+				Params: map[string]interface{}{"max": maxPromoCodeLength}})
+		}
+		if _, err := time.Parse("2006-01-02", validStr); err != nil {
+			return response.Invalid(nil, response.FieldError{Field: field + ".valid_until", Code: response.FieldInvalidFormat,
+				Params: map[string]interface{}{"format": "YYYY-MM-DD", "value": validStr}})
 		}
 
 		key := brand + "|" + validStr
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
 		groups[key] = append(groups[key], code)
 	}
 
-	for key, codes := range groups {
-		parts := strings.Split(key, "|")
-		brand := parts[0]
-		if brand == "YANDEX" && len(codes)%2 != 0 {
-			return fmt.Errorf("у YANDEX должно быть чётное количество промокодов на дату %s", parts[1])
+	for _, key := range keys {
+		brand, validUntil, _ := strings.Cut(key, "|")
+		if brand == "YANDEX" && len(groups[key])%2 != 0 {
+			return response.Invalid(nil, response.FieldError{Field: "rows", Code: response.FieldEvenCount,
+				Params: map[string]interface{}{"brand": brand, "date": validUntil}})
 		}
 	}
 
@@ -407,18 +472,15 @@ func validateAndSavePromos(db *sql.DB, rows [][]string, adminID int) error {
 	}
 	defer tx.Rollback()
 
-	for key, codes := range groups {
-		parts := strings.Split(key, "|")
-		brand := parts[0]
-		validUntil := parts[1]
-
-		for _, code := range codes {
+	for _, key := range keys {
+		brand, validUntil, _ := strings.Cut(key, "|")
+		for _, code := range groups[key] {
 			_, err := tx.Exec(`
 				INSERT INTO promo_codes (brand, promo_code, valid_until, created_by_admin_id)
 				VALUES ($1, $2, $3, $4)
 			`, brand, code, validUntil, adminID)
 			if err != nil {
-				return fmt.Errorf("ошибка вставки: %w", err)
+				return fmt.Errorf("insert promo code: %w", err)
 			}
 		}
 	}
@@ -426,6 +488,15 @@ func validateAndSavePromos(db *sql.DB, rows [][]string, adminID int) error {
 	return tx.Commit()
 }
 
+func isPromoBrand(brand string) bool {
+	for _, b := range promoBrands {
+		if b == brand {
+			return true
+		}
+	}
+	return false
+}
+
 func readFromGoogleSheet(url string) ([][]string, error) {
 	re := regexp.MustCompile(`\/d\/([a-zA-Z0-9-_]+)`)
 	matches := re.FindStringSubmatch(url)
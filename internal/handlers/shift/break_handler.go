@@ -0,0 +1,80 @@
+// handlers/break_handler.go
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+)
+
+// respondBreakError переводит ошибку паузы/возобновления в HTTP-ответ.
+func respondBreakError(w http.ResponseWriter, err error, userID int) {
+	switch {
+	case errors.Is(err, shiftService.ErrNoActiveShift):
+		response.RespondWithError(w, http.StatusBadRequest, "No active slot found")
+	case errors.Is(err, shiftService.ErrAlreadyOnBreak):
+		response.RespondWithError(w, http.StatusConflict, "Перерыв уже начат")
+	case errors.Is(err, shiftService.ErrNotOnBreak):
+		response.RespondWithError(w, http.StatusConflict, "Нет активного перерыва")
+	case errors.Is(err, shiftService.ErrBreakLimitReached):
+		response.RespondWithError(w, http.StatusUnprocessableEntity, "Лимит перерывов для этой смены исчерпан")
+	default:
+		log.Printf("Failed to change break state for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+	}
+}
+
+// PauseSlotHandler начинает перерыв в активной смене. Время перерыва не
+// входит в отработанное.
+func PauseSlotHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		started, err := shifts.Pause(r.Context(), userID)
+		if err != nil {
+			respondBreakError(w, err, userID)
+			return
+		}
+
+		response.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+			"message":    "Break started",
+			"break_id":   started.ID,
+			"started_at": started.StartedAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// ResumeSlotHandler завершает текущий перерыв.
+func ResumeSlotHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		ended, err := shifts.Resume(r.Context(), userID)
+		if err != nil {
+			respondBreakError(w, err, userID)
+			return
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message":     "Break ended",
+			"break_id":    ended.ID,
+			"started_at":  ended.StartedAt.Format(time.RFC3339),
+			"ended_at":    ended.EndedAt.Format(time.RFC3339),
+			"break_time":  response.FormatDuration(ended.Seconds(*ended.EndedAt)),
+			"auto_closed": ended.AutoClosed,
+		})
+	}
+}
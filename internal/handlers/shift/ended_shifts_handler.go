@@ -2,36 +2,73 @@ package handlers
 
 import (
 	"database/sql"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
 	"log"
 	"net/http"
-	"github.com/evn/eom_backendl/internal/pkg/response"
-
 )
 
 type EndedShift struct {
-	ID            int    `json:"id"`
-	UserID        int    `json:"user_id"`
-	Username      string `json:"username"`
-	StartTime     string `json:"start_time"`
-	EndTime       string `json:"end_time"`
-	SlotTimeRange string `json:"slot_time_range"`
-	Position      string `json:"position"`
-	Zone          string `json:"zone"`
-	Selfie        string `json:"selfie"`
+	ID            int               `json:"id"`
+	UserID        int               `json:"user_id"`
+	Username      string            `json:"username"`
+	StartTime     string            `json:"start_time"`
+	EndTime       string            `json:"end_time"`
+	SlotTimeRange string            `json:"slot_time_range"`
+	Position      string            `json:"position"`
+	Zone          string            `json:"zone"`
+	Selfie        string            `json:"selfie"`
+	SelfieThumb   string            `json:"selfie_thumb"`
+	Flags         models.ShiftFlags `json:"flags"`
 }
 
-func GetEndedShiftsHandler(db *sql.DB) http.HandlerFunc {
+// EndedShiftsSpec — фильтры и сортировки списка закрытых смен; from/to — по началу смены.
+var EndedShiftsSpec = listing.Spec{
+	Sorts: map[string]string{
+		"end_time":   "s.end_time",
+		"start_time": "s.start_time",
+		"username":   "u.username",
+		"zone":       "s.zone",
+		"worked":     "COALESCE(s.worked_duration, 0)",
+	},
+	DefaultSort: "-end_time",
+	IDColumn:    "s.id",
+	Filters:     []string{listing.FilterDate, listing.FilterZone, listing.FilterUser},
+	Columns:     listing.Columns{Date: "s.start_time", Zone: "s.zone", UserID: "s.user_id"},
+}
+
+// GetEndedShiftsHandler — закрытые смены постранично (см. пакет listing).
+func GetEndedShiftsHandler(db *sql.DB, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		params, err := listing.Parse(r, EndedShiftsSpec)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
+			return
+		}
+		q := listing.NewQuery(EndedShiftsSpec, params)
+		q.Where("s.end_time IS NOT NULL")
+
+		var total int
+		where, args := q.Count()
+		err = db.QueryRowContext(r.Context(), `SELECT COUNT(*) FROM slots s JOIN users u ON s.user_id = u.id`+where, args...).Scan(&total)
+		if err != nil {
+			log.Printf("DB count error (ended shifts): %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		page, args := q.Page()
 		query := `
 			SELECT s.id, s.user_id, u.username, s.start_time, s.end_time, 
-			       s.slot_time_range, s.position, s.zone, s.selfie_path
+			       s.slot_time_range, s.position, s.zone, s.selfie_path, COALESCE(s.selfie_thumb_path, ''),
+			       s.late_minutes, s.early_leave_minutes, COALESCE(s.end_reason, ''), s.corrected_at, s.voided_at,
+			       ` + q.SortValue() + `
 			FROM slots s
-			JOIN users u ON s.user_id = u.id
-			WHERE s.end_time IS NOT NULL
-			ORDER BY s.end_time DESC
-		`
+			JOIN users u ON s.user_id = u.id` + page
 
-		rows, err := db.Query(query)
+		rows, err := db.QueryContext(r.Context(), query, args...)
 		if err != nil {
 			log.Printf("DB query error (ended shifts): %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
@@ -39,10 +76,13 @@ func GetEndedShiftsHandler(db *sql.DB) http.HandlerFunc {
 		}
 		defer rows.Close()
 
-		var shifts []EndedShift
+		shifts := []EndedShift{}
+		var keys []listing.Key
 		for rows.Next() {
 			var shift EndedShift
 			var endTime sql.NullString
+			var punctuality models.Shift
+			var key listing.Key
 			err := rows.Scan(
 				&shift.ID,
 				&shift.UserID,
@@ -53,12 +93,25 @@ func GetEndedShiftsHandler(db *sql.DB) http.HandlerFunc {
 				&shift.Position,
 				&shift.Zone,
 				&shift.Selfie,
+				&shift.SelfieThumb,
+				&punctuality.LateMinutes,
+				&punctuality.EarlyLeaveMinutes,
+				&punctuality.EndReason,
+				&punctuality.CorrectedAt,
+				&punctuality.VoidedAt,
+				&key.Value,
 			)
 			if err != nil {
 				log.Printf("Error scanning ended shift row: %v", err)
-				continue
+				response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+				return
 			}
+			key.ID = shift.ID
+			keys = append(keys, key)
 			shift.EndTime = endTime.String
+			shift.Flags = punctuality.Flags()
+			shift.SelfieThumb = signer.SignPreview(shift.SelfieThumb, shift.Selfie)
+			shift.Selfie = signer.Sign(shift.Selfie)
 			shifts = append(shifts, shift)
 		}
 
@@ -68,6 +121,7 @@ func GetEndedShiftsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		response.RespondWithJSON(w, http.StatusOK, shifts)
+		n, result := q.Result(keys, total)
+		response.RespondWithPage(w, result, shifts[:n])
 	}
 }
@@ -0,0 +1,252 @@
+// handlers/report_handler.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+)
+
+// maxReportPhotoSize — размер одного фото отчёта до перекодирования.
+const maxReportPhotoSize = 5 << 20
+
+var errInvalidPhoto = errors.New("invalid report photo")
+
+// endReportRequest — отчёт о смене в теле /api/slot/end.
+type endReportRequest struct {
+	Scooters map[string]int `json:"scooters"` // сервис → собрано самокатов
+	Issues   []string       `json:"issues"`
+	Notes    string         `json:"notes"`
+}
+
+// parseEndReport читает отчёт из тела запроса на закрытие смены: JSON или
+// multipart с полем report (тот же JSON) и файлами photos. Пустое тело —
+// смена закрывается без отчёта, как раньше.
+func parseEndReport(r *http.Request) (*models.ShiftReport, []*multipart.FileHeader, error) {
+	var req endReportRequest
+	var photos []*multipart.FileHeader
+
+	contentType := r.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "multipart/form-data"):
+		if err := r.ParseMultipartForm((shiftService.MaxReportPhotos + 1) * maxReportPhotoSize); err != nil {
+			return nil, nil, errors.New("File too large or malformed")
+		}
+		if raw := r.FormValue("report"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req); err != nil {
+				return nil, nil, errors.New("Invalid report JSON")
+			}
+		}
+		photos = r.MultipartForm.File["photos"]
+	case r.ContentLength != 0:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			return nil, nil, errors.New("Invalid report JSON")
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	return &models.ShiftReport{Scooters: req.Scooters, Issues: req.Issues, Notes: req.Notes}, photos, nil
+}
+
+// saveReportPhotos перекодирует фото отчёта и кладёт их в хранилище вместе с
+// миниатюрами. Возвращает пути для отчёта и ключи записанных файлов.
+func saveReportPhotos(ctx context.Context, images *mediaService.ImageProcessor, store storageService.Storage, userID int, photos []*multipart.FileHeader) ([]string, []string, error) {
+	files := make(map[string][]byte)
+	var paths []string
+	for i, header := range photos {
+		field := fmt.Sprintf("photos[%d]", i)
+		if header.Size > maxReportPhotoSize {
+			return nil, nil, response.Invalid(errInvalidPhoto, response.FieldError{Field: field, Code: response.FieldTooLarge, Params: map[string]interface{}{"max": "5 MB"}})
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if contentType := http.DetectContentType(data); contentType != "image/jpeg" && contentType != "image/png" {
+			return nil, nil, response.Invalid(errInvalidPhoto, response.FieldError{Field: field, Code: response.FieldInvalidImage})
+		}
+		img, err := images.Decode(data)
+		if errors.Is(err, mediaService.ErrImageTooLarge) {
+			return nil, nil, response.Invalid(errInvalidPhoto, response.FieldError{Field: field, Code: response.FieldTooLarge, Params: map[string]interface{}{"max": fmt.Sprintf("%d MP", mediaService.MaxImageMegapixels)}})
+		}
+		if err != nil {
+			return nil, nil, response.Invalid(errInvalidPhoto, response.FieldError{Field: field, Code: response.FieldInvalidImage})
+		}
+		processed, err := images.Process(img)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		photoPath := "/uploads/reports/" + generateSafeFilename("report", userID, ".jpg")
+		files[photoPath] = processed.Full
+		files[mediaService.ThumbPath(photoPath)] = processed.Thumb
+		paths = append(paths, photoPath)
+	}
+
+	saved, err := saveUploads(ctx, store, files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return paths, saved, nil
+}
+
+// reportJSON — отчёт с подписанными ссылками на фото.
+func reportJSON(report *models.ShiftReport, signer *mediaService.URLSigner) interface{} {
+	if report == nil {
+		return nil
+	}
+	photos := make([]map[string]string, 0, len(report.Photos))
+	for _, photo := range report.Photos {
+		photos = append(photos, map[string]string{
+			"url":   signer.Sign(photo),
+			"thumb": signer.SignPreview(mediaService.ThumbPath(photo), photo),
+		})
+	}
+	return map[string]interface{}{
+		"scooters":   report.Scooters,
+		"issues":     report.Issues,
+		"notes":      report.Notes,
+		"photos":     photos,
+		"created_at": report.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// reportedShiftJSON — закрытая смена вместе с отчётом для списков и передачи смены.
+func reportedShiftJSON(item models.ShiftWithReport, signer *mediaService.URLSigner) map[string]interface{} {
+	var endTime string
+	if item.Shift.EndTime != nil {
+		endTime = item.Shift.EndTime.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"slot_id":         item.Shift.ID,
+		"user_id":         item.Shift.UserID,
+		"username":        item.Shift.Username,
+		"zone":            item.Shift.Zone,
+		"slot_time_range": response.NormalizeSlot(item.Shift.SlotTimeRange),
+		"start_time":      item.Shift.StartTime.Format(time.RFC3339),
+		"end_time":        endTime,
+		"worked_time":     response.FormatDuration(item.Shift.WorkedDuration),
+		"flags":           item.Shift.Flags(),
+		"report":          reportJSON(item.Report, signer),
+	}
+}
+
+// handoverJSON — заметки предыдущей смены в зоне или nil.
+func handoverJSON(ctx context.Context, shifts *shiftService.ShiftService, signer *mediaService.URLSigner, zone string) interface{} {
+	item, err := shifts.Handover(ctx, zone)
+	if err != nil {
+		log.Printf("Failed to load handover for zone %s: %v", zone, err)
+		return nil
+	}
+	if item == nil {
+		return nil
+	}
+	return reportedShiftJSON(*item, signer)
+}
+
+// GetHandoverHandler — отчёт и заметки предыдущей смены в зоне (?zone=,
+// по умолчанию зона активной смены пользователя).
+func GetHandoverHandler(shifts *shiftService.ShiftService, signer *mediaService.URLSigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		zone := r.URL.Query().Get("zone")
+		if zone == "" {
+			active, err := shifts.Active(r.Context(), userID)
+			if errors.Is(err, shiftService.ErrNoActiveShift) {
+				response.RespondWithError(w, http.StatusBadRequest, "Zone is required when there is no active slot")
+				return
+			} else if err != nil {
+				log.Printf("DB error fetching user active shift %d: %v", userID, err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+			zone = active.Zone
+		}
+
+		item, err := shifts.Handover(r.Context(), zone)
+		if err != nil {
+			log.Printf("Failed to load handover for zone %s: %v", zone, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		var handover interface{}
+		if item != nil {
+			handover = reportedShiftJSON(*item, signer)
+		}
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"zone":     zone,
+			"handover": handover,
+		})
+	}
+}
+
+// GetShiftReportsHandler — закрытые смены за день (?date=YYYY-MM-DD, по
+// умолчанию сегодня) с отчётами, по зоне (?zone=) или по всем зонам.
+func GetShiftReportsHandler(shifts *shiftService.ShiftService, signer *mediaService.URLSigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		day := time.Now()
+		if v := r.URL.Query().Get("date"); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				response.RespondWithError(w, http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD")
+				return
+			}
+			day = t
+		}
+		zone := r.URL.Query().Get("zone")
+
+		items, err := shifts.Reports(r.Context(), zone, day)
+		if err != nil {
+			log.Printf("Failed to load shift reports for %s: %v", day.Format("2006-01-02"), err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+
+		result := []map[string]interface{}{}
+		scooters := map[string]int{}
+		missing := 0
+		for _, item := range items {
+			result = append(result, reportedShiftJSON(item, signer))
+			if item.Report == nil {
+				missing++
+				continue
+			}
+			for service, count := range item.Report.Scooters {
+				scooters[service] += count
+			}
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"date":            day.Format("2006-01-02"),
+			"zone":            zone,
+			"shifts":          result,
+			"scooters_total":  scooters,
+			"missing_reports": missing,
+		})
+	}
+}
@@ -4,14 +4,17 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -20,135 +23,106 @@ type GenerateShiftsRequest struct {
 	MorningCount int    `json:"morning_count"`
 	EveningCount int    `json:"evening_count"`
 	ScoutIDs     []int  `json:"scout_ids"`
+	Zone         string `json:"zone"` // необязательно
 }
 
-func GenerateShiftsHandler(db *sql.DB) http.HandlerFunc {
+// Слоты, по которым GenerateShiftsHandler раскладывает скаутов.
+const (
+	morningSlot = "07:00-15:00"
+	eveningSlot = "15:00-23:00"
+)
+
+// GenerateShiftsHandler планирует выходы скаутов на день: первые morning_count
+// из scout_ids — на утренний слот, следующие evening_count — на вечерний.
+// Смены сотрудники открывают сами; выход без смены попадёт в отчёт как невыход.
+func GenerateShiftsHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
+		if !ok {
+			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
 		var req GenerateShiftsRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			response.RespondWithError(w, http.StatusBadRequest, "Invalid JSON")
 			return
 		}
 
-		date, err := time.Parse("2006-01-02", req.Date)
-		if err != nil {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
 			response.RespondWithError(w, http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD")
 			return
 		}
-
-		validScouts, err := filterAvailableScouts(db, req.ScoutIDs, date)
-		if err != nil {
-			log.Printf("Error checking scout availability: %v", err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
-			return
+		var negative []response.FieldError
+		if req.MorningCount < 0 {
+			negative = append(negative, response.FieldError{Field: "morning_count", Code: response.FieldInvalid})
 		}
-
-		if len(validScouts) < req.MorningCount+req.EveningCount {
-			response.RespondWithError(w, http.StatusBadRequest, "Недостаточно доступных скаутов")
-			return
+		if req.EveningCount < 0 {
+			negative = append(negative, response.FieldError{Field: "evening_count", Code: response.FieldInvalid})
 		}
-
-		tx, err := db.Begin()
-		if err != nil {
-			response.RespondWithError(w, http.StatusInternalServerError, "Transaction error")
+		if len(negative) > 0 {
+			response.RespondWithValidation(w, negative...)
 			return
 		}
-		defer tx.Rollback()
 
-		slotTime := "07:00 - 15:00"
-		for i := 0; i < req.MorningCount; i++ {
-			if i >= len(validScouts) {
-				break
-			}
-			if err := createSlot(tx, validScouts[i], date, slotTime); err != nil {
-				log.Printf("Failed to create morning shift: %v", err)
-				response.RespondWithError(w, http.StatusInternalServerError, "Failed to create morning shift")
-				return
-			}
+		scouts := uniqueIDs(req.ScoutIDs)
+		if len(scouts) < req.MorningCount+req.EveningCount {
+			response.RespondWithError(w, http.StatusBadRequest, "Недостаточно доступных скаутов")
+			return
 		}
 
-		slotTime = "15:00 - 23:00"
-		for i := req.MorningCount; i < req.MorningCount+req.EveningCount; i++ {
-			if i >= len(validScouts) {
-				break
-			}
-			if err := createSlot(tx, validScouts[i], date, slotTime); err != nil {
-				log.Printf("Failed to create evening shift: %v", err)
-				response.RespondWithError(w, http.StatusInternalServerError, "Failed to create evening shift")
-				return
+		var planned []models.ShiftAssignment
+		for i, scoutID := range scouts[:req.MorningCount+req.EveningCount] {
+			slot := morningSlot
+			if i >= req.MorningCount {
+				slot = eveningSlot
 			}
+			planned = append(planned, models.ShiftAssignment{UserID: scoutID, ShiftDate: req.Date, SlotTimeRange: slot, Zone: req.Zone})
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Printf("Shift generation commit error: %v", err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Commit error")
+		created, err := shifts.Assign(r.Context(), planned, userID)
+		switch {
+		case errors.Is(err, shiftService.ErrInvalidTimeSlot):
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid time slot")
 			return
+		case errors.Is(err, shiftService.ErrInvalidZone):
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid zone")
+			return
+		case err != nil:
+			log.Printf("Failed to plan shifts for %s: %v", req.Date, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if created == nil {
+			created = []models.ShiftAssignment{}
 		}
 
-		response.RespondWithJSON(w, http.StatusOK, map[string]string{
-			"status":  "success",
-			"message": "Смены сгенерированы",
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"status":      "success",
+			"message":     "Смены сгенерированы",
+			"assignments": created,
+			"skipped":     len(planned) - len(created), // уже были в плане
 		})
 	}
 }
 
-func filterAvailableScouts(db *sql.DB, scoutIDs []int, date time.Time) ([]int, error) {
-	if len(scoutIDs) == 0 {
-		return []int{}, nil
-	}
-
-	placeholders := make([]string, len(scoutIDs))
-	args := make([]interface{}, len(scoutIDs)+1)
-	for i, id := range scoutIDs {
-		placeholders[i] = "$" + strconv.Itoa(i+1)
-		args[i] = id
-	}
-	args[len(scoutIDs)] = date.Format("2006-01-02")
-
-	query := fmt.Sprintf(`
-		SELECT user_id FROM slots 
-		WHERE user_id IN (%s) 
-		AND DATE(start_time) = $%d
-		AND end_time IS NULL
-	`, strings.Join(placeholders, ","), len(scoutIDs)+1)
-
-	rows, err := db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	busy := make(map[int]bool)
-	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			continue
-		}
-		busy[id] = true
-	}
-
-	var available []int
-	for _, id := range scoutIDs {
-		if !busy[id] {
-			available = append(available, id)
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var unique []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
 		}
 	}
-	return available, nil
-}
-
-func createSlot(tx *sql.Tx, userID int, date time.Time, slotTime string) error {
-	_, err := tx.Exec(`
-		INSERT INTO slots (user_id, start_time, slot_time_range, position, zone, selfie_path) 
-		VALUES ($1, $2, $3, 'Скаут', 'Центр', '')
-	`, userID, date.Format("2006-01-02 07:00:00"), slotTime)
-	return err
+	return unique
 }
 
-func GetShiftsByDateHandler(db *sql.DB) http.HandlerFunc {
+func GetShiftsByDateHandler(db *sql.DB, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		dateStr := chi.URLParam(r, "date")
 		if dateStr == "" {
-			response.RespondWithError(w, http.StatusBadRequest, "Date is required")
+			response.RespondWithValidation(w, response.FieldError{Field: "date", Code: response.FieldRequired})
 			return
 		}
 
@@ -169,6 +143,7 @@ func GetShiftsByDateHandler(db *sql.DB) http.HandlerFunc {
 				s.position,
 				s.zone,
 				s.selfie_path,
+				s.selfie_thumb_path,
 				s.end_time
 			FROM slots s
 			JOIN users u ON s.user_id = u.id
@@ -187,23 +162,24 @@ func GetShiftsByDateHandler(db *sql.DB) http.HandlerFunc {
 		var shifts []map[string]interface{}
 		for rows.Next() {
 			var id, userID int
-			var username, firstName, startTime, slotTimeRange, position, zone, selfie, endTime sql.NullString
-			if err := rows.Scan(&id, &userID, &username, &firstName, &startTime, &slotTimeRange, &position, &zone, &selfie, &endTime); err != nil {
+			var username, firstName, startTime, slotTimeRange, position, zone, selfie, selfieThumb, endTime sql.NullString
+			if err := rows.Scan(&id, &userID, &username, &firstName, &startTime, &slotTimeRange, &position, &zone, &selfie, &selfieThumb, &endTime); err != nil {
 				log.Printf("Error scanning shift row: %v", err)
 				continue
 			}
 
 			shift := map[string]interface{}{
-				"id":         id,
-				"user_id":    userID,
-				"username":   username.String,
-				"first_name": firstName.String,
-				"start_time": startTime.String,
-				"shift_type": getShiftTypeFromTimeRange(slotTimeRange.String),
-				"position":   position.String,
-				"zone":       zone.String,
-				"selfie":     selfie.String,
-				"end_time":   endTime.String,
+				"id":           id,
+				"user_id":      userID,
+				"username":     username.String,
+				"first_name":   firstName.String,
+				"start_time":   startTime.String,
+				"shift_type":   getShiftTypeFromTimeRange(slotTimeRange.String),
+				"position":     position.String,
+				"zone":         zone.String,
+				"selfie":       signer.Sign(selfie.String),
+				"selfie_thumb": signer.SignPreview(selfieThumb.String, selfie.String),
+				"end_time":     endTime.String,
 			}
 			shifts = append(shifts, shift)
 		}
@@ -2,22 +2,26 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
-	_ "image/jpeg"
-	_ "image/png"
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	selfieService "github.com/evn/eom_backendl/internal/services/selfie"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -25,21 +29,109 @@ import (
 // Вспомогательные функции
 // -------------------------------
 
-// generateSafeFilename генерирует уникальное имя файла для селфи
-func generateSafeFilename(userID int, ext string) string {
+// generateSafeFilename генерирует уникальное имя файла вида prefix_userID_hash.ext
+func generateSafeFilename(prefix string, userID int, ext string) string {
 	randomBytes := make([]byte, 8)
 	if _, err := rand.Read(randomBytes); err != nil {
-		return fmt.Sprintf("selfie_%d_%d%s", userID, time.Now().UnixNano(), ext)
+		return fmt.Sprintf("%s_%d_%d%s", prefix, userID, time.Now().UnixNano(), ext)
 	}
 	hash := fmt.Sprintf("%x", randomBytes)
-	return fmt.Sprintf("selfie_%d_%s%s", userID, hash, ext)
+	return fmt.Sprintf("%s_%d_%s%s", prefix, userID, hash, ext)
+}
+
+// saveUploads кладёт файлы в хранилище по путям вида /uploads/...; при ошибке
+// удаляет уже записанные.
+func saveUploads(ctx context.Context, store storageService.Storage, files map[string][]byte) ([]string, error) {
+	var saved []string
+	for uploadPath, data := range files {
+		key, ok := storageService.KeyFromPath(uploadPath)
+		if !ok {
+			removeUploads(ctx, store, saved)
+			return nil, fmt.Errorf("invalid upload path %q", uploadPath)
+		}
+		if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), http.DetectContentType(data)); err != nil {
+			removeUploads(ctx, store, saved)
+			return nil, err
+		}
+		saved = append(saved, key)
+	}
+	return saved, nil
+}
+
+func removeUploads(ctx context.Context, store storageService.Storage, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete upload %s: %v", key, err)
+		}
+	}
+}
+
+// respondStartError переводит ошибку открытия смены в HTTP-ответ.
+func respondStartError(w http.ResponseWriter, err error, userID int, slotTimeRange, zone string) {
+	switch {
+	case errors.Is(err, shiftService.ErrShiftAlreadyActive):
+		response.RespondWithError(w, http.StatusBadRequest, "Slot already active")
+	case errors.Is(err, shiftService.ErrOutsideStartWindow):
+		response.RespondWithError(w, http.StatusBadRequest, "Смену можно начать только за 20 минут до её начала или в течение смены")
+	case errors.Is(err, shiftService.ErrInvalidZone):
+		response.RespondWithValidation(w, response.FieldError{Field: "zone", Code: response.FieldInvalid, Params: map[string]interface{}{"value": zone}})
+	case errors.Is(err, shiftService.ErrInvalidTimeSlot):
+		response.RespondWithValidation(w, response.FieldError{Field: "slot_time_range", Code: response.FieldInvalid, Params: map[string]interface{}{"value": slotTimeRange}})
+	case errors.Is(err, shiftService.ErrUserNotFound):
+		response.RespondWithError(w, http.StatusNotFound, "User not found")
+	default:
+		log.Printf("Failed to start slot for user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+	}
+}
+
+// respondSelfieError переводит отказ проверки селфи в HTTP-ответ.
+func respondSelfieError(w http.ResponseWriter, err error, userID int) {
+	switch {
+	case errors.Is(err, mediaService.ErrImageTooLarge):
+		response.RespondWithValidation(w, response.FieldError{Field: "selfie", Code: response.FieldTooLarge, Params: map[string]interface{}{"max": fmt.Sprintf("%d MP", mediaService.MaxImageMegapixels)}})
+	case errors.Is(err, selfieService.ErrSelfieInvalid):
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid image")
+	case errors.Is(err, selfieService.ErrSelfieNoMetadata):
+		response.RespondWithError(w, http.StatusUnprocessableEntity, "Не удалось определить время съёмки селфи, сделайте фото камерой приложения")
+	case errors.Is(err, selfieService.ErrSelfieStale):
+		log.Printf("Stale selfie from user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusUnprocessableEntity, "Селфи нужно сделать непосредственно перед началом смены")
+	case errors.Is(err, selfieService.ErrSelfieReused):
+		log.Printf("Reused selfie from user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusUnprocessableEntity, "Это фото уже использовалось для другой смены")
+	case errors.Is(err, selfieService.ErrFaceMismatch):
+		log.Printf("Face mismatch on selfie of user %d", userID)
+		response.RespondWithError(w, http.StatusUnprocessableEntity, "Лицо на селфи не совпадает с профилем сотрудника")
+	default:
+		log.Printf("Failed to verify selfie of user %d: %v", userID, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to verify selfie")
+	}
+}
+
+// shiftHistoryItem — формат истории смен для мобильного приложения.
+func shiftHistoryItem(shift models.Shift) map[string]interface{} {
+	var endTime time.Time
+	if shift.EndTime != nil {
+		endTime = *shift.EndTime
+	}
+	return map[string]interface{}{
+		"date":             shift.StartTime.Format("2006-01-02"),
+		"selected_slot":    shift.SlotTimeRange,
+		"worked_time":      response.FormatDuration(shift.WorkedDuration),
+		"break_time":       response.FormatDuration(shift.BreakDuration),
+		"work_period":      fmt.Sprintf("%s–%s", shift.StartTime.Format("15:04"), endTime.Format("15:04")),
+		"transport_status": "Транспорт не указан",
+		"new_tasks":        0,
+		"flags":            shift.Flags(),
+	}
 }
 
 // -------------------------------
 // Обработчики
 // -------------------------------
 
-func StartSlotHandler(db *sql.DB) http.HandlerFunc {
+func StartSlotHandler(shifts *shiftService.ShiftService, verifier *selfieService.Verifier, images *mediaService.ImageProcessor, store storageService.Storage, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
 		if !ok {
@@ -47,39 +139,6 @@ func StartSlotHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var activeCount int
-		err := db.QueryRow("SELECT COUNT(*) FROM slots WHERE user_id = $1 AND end_time IS NULL", userID).Scan(&activeCount)
-		if err != nil {
-			log.Printf("DB error checking active slots for user %d: %v", userID, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
-			return
-		}
-		if activeCount > 0 {
-			response.RespondWithError(w, http.StatusBadRequest, "Slot already active")
-			return
-		}
-
-		var position string
-		err = db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&position)
-		if err != nil {
-			log.Printf("DB error fetching role for user %d: %v", userID, err)
-			position = "user"
-		}
-
-		positionMap := map[string]string{
-			"superadmin":  "Суперадмин",
-			"admin":       "Администратор",
-			"coordinator": "Координатор",
-			"scout":       "Скаут",
-			"user":        "Пользователь",
-		}
-
-		if readablePosition, exists := positionMap[position]; exists {
-			position = readablePosition
-		} else {
-			position = "Сотрудник"
-		}
-
 		if err := r.ParseMultipartForm(5 << 20); err != nil {
 			response.RespondWithError(w, http.StatusBadRequest, "File too large or malformed")
 			return
@@ -93,34 +152,8 @@ func StartSlotHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		if !canStartShift(slotTimeRange) {
-			response.RespondWithError(w, http.StatusBadRequest, "Смену можно начать только за 20 минут до её начала или в течение смены")
-			return
-		}
-
-		// Проверяем, существует ли зона
-		var zoneExists int
-		err = db.QueryRow("SELECT COUNT(*) FROM zones WHERE name = $1", zone).Scan(&zoneExists)
-		if err != nil {
-			log.Printf("DB error checking zone %s: %v", zone, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
-			return
-		}
-		if zoneExists == 0 {
-			response.RespondWithError(w, http.StatusBadRequest, "Invalid zone: "+zone)
-			return
-		}
-
-		// Проверяем, существует ли временной слот
-		var slotExists int
-		err = db.QueryRow("SELECT COUNT(*) FROM available_time_slots WHERE slot_time_range = $1", slotTimeRange).Scan(&slotExists)
-		if err != nil {
-			log.Printf("DB error checking time slot %s: %v", slotTimeRange, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
-			return
-		}
-		if slotExists == 0 {
-			response.RespondWithError(w, http.StatusBadRequest, "Invalid time slot: "+slotTimeRange)
+		if err := shifts.CheckStart(r.Context(), userID, slotTimeRange, zone); err != nil {
+			respondStartError(w, err, userID, slotTimeRange, zone)
 			return
 		}
 
@@ -132,99 +165,73 @@ func StartSlotHandler(db *sql.DB) http.HandlerFunc {
 		}
 		defer file.Close()
 
-		buff := make([]byte, 512)
-		_, err = file.Read(buff)
-		if err != nil && err != io.EOF {
+		data, err := io.ReadAll(file)
+		if err != nil {
 			response.RespondWithError(w, http.StatusInternalServerError, "Error reading file")
 			return
 		}
-		contentType := http.DetectContentType(buff)
-		if contentType != "image/jpeg" && contentType != "image/png" {
+		if contentType := http.DetectContentType(data); contentType != "image/jpeg" && contentType != "image/png" {
 			response.RespondWithError(w, http.StatusBadRequest, "Only JPEG and PNG images allowed")
 			return
 		}
 
-		file.Seek(0, 0)
-		ext := ".jpg"
-		if contentType == "image/png" {
-			ext = ".png"
-		}
-
-		filename := generateSafeFilename(userID, ext)
-		fullPath := filepath.Join("./uploads/selfies", filename)
-		if err := os.MkdirAll("./uploads/selfies", 0755); err != nil {
-			log.Printf("Failed to create uploads dir: %v", err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Server error")
+		selfie, err := verifier.Verify(r.Context(), userID, data)
+		if err != nil {
+			respondSelfieError(w, err, userID)
 			return
 		}
 
-		out, err := os.Create(fullPath)
+		// Перекодированный файл уже без EXIF: время и место съёмки остаются только в смене
+		processed, err := images.Process(selfie.Image)
 		if err != nil {
-			log.Printf("Failed to create file %s: %v", fullPath, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Server error")
+			log.Printf("Failed to process selfie for user %d: %v", userID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to process image")
 			return
 		}
-		defer out.Close()
 
-		_, err = io.Copy(out, file)
+		selfiePath := "/uploads/selfies/" + generateSafeFilename("selfie", userID, ".jpg")
+		thumbPath := mediaService.ThumbPath(selfiePath)
+		saved, err := saveUploads(r.Context(), store, map[string][]byte{
+			selfiePath: processed.Full,
+			thumbPath:  processed.Thumb,
+		})
 		if err != nil {
-			os.Remove(fullPath)
 			log.Printf("Failed to save selfie for user %d: %v", userID, err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to save image")
 			return
 		}
 
-		// Вставляем смену
-		result, err := db.Exec(`
-			INSERT INTO slots (user_id, start_time, slot_time_range, position, zone, selfie_path)
-			VALUES ($1, $2, $3, $4, $5, $6)
-		`, userID, time.Now(), slotTimeRange, position, zone, "/uploads/selfies/"+filename)
-
-		if err != nil {
-			os.Remove(fullPath)
-			log.Printf("DB error inserting slot for user %d: %v", userID, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
-			return
-		}
-
-		slotID, err := result.LastInsertId()
+		shift, err := shifts.Start(r.Context(), shiftService.StartInput{
+			UserID:        userID,
+			SlotTimeRange: slotTimeRange,
+			Zone:          zone,
+			SelfiePath:    selfiePath,
+			SelfieThumb:   thumbPath,
+			Selfie:        selfie.Meta,
+		})
 		if err != nil {
-			log.Printf("Failed to get slot ID: %v", err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to get slot ID")
+			removeUploads(context.Background(), store, saved)
+			respondStartError(w, err, userID, slotTimeRange, zone)
 			return
 		}
 
 		response.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
 			"message":         "Slot started successfully",
-			"selfie":          "/uploads/selfies/" + filename,
-			"id":              slotID,
+			"selfie":          signer.Sign(shift.SelfiePath),
+			"id":              shift.ID,
 			"user_id":         userID,
-			"slot_time_range": slotTimeRange,
-			"position":        position,
-			"zone":            zone,
-			"start_time":      time.Now().Format(time.RFC3339),
+			"slot_time_range": shift.SlotTimeRange,
+			"position":        shift.Position,
+			"zone":            shift.Zone,
+			"start_time":      shift.StartTime.Format(time.RFC3339),
+			"handover":        handoverJSON(r.Context(), shifts, signer, shift.Zone),
 		})
 	}
 }
 
-// canStartShift проверяет, можно ли начать смену в текущее время
-func canStartShift(slotTimeRange string) bool {
-	now := time.Now()
-	hour, min := now.Hour(), now.Minute()
-
-	switch slotTimeRange {
-	case "07:00-15:00":
-		return (hour == 6 && min >= 40) || (hour >= 7 && hour < 15) || (hour == 15 && min == 0)
-	case "15:00-23:00":
-		return (hour == 14 && min >= 40) || (hour >= 15 && hour < 23) || (hour == 23 && min == 0)
-	case "07:00-23:00":
-		return (hour == 6 && min >= 40) || (hour >= 7 && hour < 23) || (hour == 23 && min == 0)
-	default:
-		return false
-	}
-}
-
-func EndSlotHandler(db *sql.DB) http.HandlerFunc {
+// EndSlotHandler закрывает смену. В теле можно передать отчёт о смене: JSON
+// или multipart с полем report и фото photos (см. parseEndReport).
+func EndSlotHandler(shifts *shiftService.ShiftService, images *mediaService.ImageProcessor, store storageService.Storage, signer *mediaService.URLSigner, services []string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
 		if !ok {
@@ -232,120 +239,113 @@ func EndSlotHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var slotID int
-		var startTime time.Time
-		err := db.QueryRow(`
-			SELECT id, start_time FROM slots WHERE user_id = $1 AND end_time IS NULL
-		`, userID).Scan(&slotID, &startTime)
-		if err == sql.ErrNoRows {
-			response.RespondWithError(w, http.StatusBadRequest, "No active slot found")
-			return
-		} else if err != nil {
-			log.Printf("DB error fetching active slot for user %d: %v", userID, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+		report, photos, err := parseEndReport(r)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
 			return
 		}
+		if report != nil {
+			if len(photos) > shiftService.MaxReportPhotos {
+				response.RespondWithValidation(w, response.FieldError{Field: "photos", Code: response.FieldTooMany, Params: map[string]interface{}{"max": shiftService.MaxReportPhotos}})
+				return
+			}
+			if err := shiftService.NormalizeReport(report, services); err != nil {
+				response.RespondWithBadRequest(w, err)
+				return
+			}
+		}
 
-		endTime := time.Now()
-		duration := int(endTime.Sub(startTime).Seconds())
+		// Фото сохраняем, только если закрывать есть что
+		var saved []string
+		if len(photos) > 0 {
+			if _, err := shifts.Active(r.Context(), userID); errors.Is(err, shiftService.ErrNoActiveShift) {
+				response.RespondWithError(w, http.StatusBadRequest, "No active slot found")
+				return
+			} else if err != nil {
+				log.Printf("DB error fetching user active shift %d: %v", userID, err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Database error")
+				return
+			}
 
-		_, err = db.Exec(`
-			UPDATE slots SET end_time = $1, worked_duration = $2 WHERE id = $3
-		`, endTime, duration, slotID)
+			report.Photos, saved, err = saveReportPhotos(r.Context(), images, store, userID, photos)
+			if errors.Is(err, errInvalidPhoto) {
+				response.RespondWithBadRequest(w, err)
+				return
+			} else if err != nil {
+				log.Printf("Failed to save report photos for user %d: %v", userID, err)
+				response.RespondWithError(w, http.StatusInternalServerError, "Failed to save image")
+				return
+			}
+		}
+
+		shift, err := shifts.End(r.Context(), userID, report)
 		if err != nil {
-			log.Printf("DB error ending slot %d: %v", slotID, err)
+			removeUploads(context.Background(), store, saved)
+		}
+		if errors.Is(err, shiftService.ErrNoActiveShift) {
+			response.RespondWithError(w, http.StatusBadRequest, "No active slot found")
+			return
+		} else if err != nil {
+			log.Printf("Failed to end slot for user %d: %v", userID, err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
 			return
 		}
 
+		if report != nil && report.Empty() {
+			report = nil
+		}
 		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 			"message":     "Slot ended",
-			"worked_time": response.FormatDuration(duration),
+			"worked_time": response.FormatDuration(shift.WorkedDuration),
+			"break_time":  response.FormatDuration(shift.BreakDuration),
+			"flags":       shift.Flags(),
+			"report":      reportJSON(report, signer),
 		})
 	}
 }
 
-func GetActiveShiftsHandler(db *sql.DB) http.HandlerFunc {
+func GetActiveShiftsHandler(shifts *shiftService.ShiftService, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		rows, err := db.Query(`
-			SELECT 
-				s.id,
-				s.user_id,
-				u.username,
-				s.slot_time_range,
-				s.position,
-				s.zone,
-				s.start_time,
-				s.selfie_path
-			FROM slots s
-			JOIN users u ON s.user_id = u.id
-			WHERE s.end_time IS NULL
-		`)
+		active, err := shifts.ListActive(r.Context())
 		if err != nil {
 			log.Printf("DB error fetching active shifts: %v", err)
-			http.Error(w, `{"error":"Database error"}`, http.StatusInternalServerError)
+			response.RespondWithError(w, http.StatusInternalServerError, "Database error")
 			return
 		}
-		defer rows.Close()
 
-		var shifts []map[string]interface{}
-		for rows.Next() {
-			var id, userID int
-			var username, slotTimeRange, position, zone, selfiePath string
-			var startTime time.Time
-			if err := rows.Scan(&id, &userID, &username, &slotTimeRange, &position, &zone, &startTime, &selfiePath); err != nil {
-				log.Printf("Error scanning active shift row: %v", err)
-				continue
-			}
-			slotTimeRange = response.NormalizeSlot(slotTimeRange)
-			shifts = append(shifts, map[string]interface{}{
-				"id":              id,
-				"user_id":         userID,
-				"username":        username,
-				"slot_time_range": slotTimeRange,
-				"position":        position,
-				"zone":            zone,
-				"start_time":      startTime,
+		now := shifts.Now()
+		result := []map[string]interface{}{}
+		for _, shift := range active {
+			result = append(result, map[string]interface{}{
+				"id":              shift.ID,
+				"user_id":         shift.UserID,
+				"username":        shift.Username,
+				"slot_time_range": response.NormalizeSlot(shift.SlotTimeRange),
+				"position":        shift.Position,
+				"zone":            shift.Zone,
+				"start_time":      shift.StartTime,
 				"is_active":       true,
-				"selfie":          selfiePath,
+				"on_break":        shift.OpenBreak() != nil,
+				"break_time":      response.FormatDuration(shift.BreakSeconds(now)),
+				"selfie":          signer.Sign(shift.SelfiePath),
+				"selfie_thumb":    signer.SignPreview(shift.SelfieThumb, shift.SelfiePath),
 			})
 		}
-		if shifts == nil {
-			shifts = []map[string]interface{}{}
-		}
-		json.NewEncoder(w).Encode(shifts)
+		response.RespondWithJSON(w, http.StatusOK, result)
 	}
 }
 
-func GetUserActiveShiftHandler(db *sql.DB) http.HandlerFunc {
+func GetUserActiveShiftHandler(shifts *shiftService.ShiftService, signer *mediaService.URLSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
 		if !ok {
 			response.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-
-		var id int
-		var username, slotTimeRange, position, zone, selfiePath string
-		var startTime time.Time
-		err := db.QueryRow(`
-			SELECT 
-				s.id,
-				u.username,
-				s.slot_time_range,
-				s.position,
-				s.zone,
-				s.start_time,
-				s.selfie_path
-			FROM slots s
-			JOIN users u ON s.user_id = u.id
-			WHERE s.user_id = $1 AND s.end_time IS NULL
-		`, userID).Scan(&id, &username, &slotTimeRange, &position, &zone, &startTime, &selfiePath)
-
-		if err == sql.ErrNoRows {
-			w.Write([]byte("null"))
+
+		shift, err := shifts.Active(r.Context(), userID)
+		if errors.Is(err, shiftService.ErrNoActiveShift) {
+			response.RespondWithJSON(w, http.StatusOK, nil)
 			return
 		} else if err != nil {
 			log.Printf("DB error fetching user active shift %d: %v", userID, err)
@@ -353,22 +353,30 @@ func GetUserActiveShiftHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		activeShift := map[string]interface{}{
-			"id":              id,
-			"user_id":         userID,
-			"username":        username,
-			"slot_time_range": slotTimeRange,
-			"position":        position,
-			"zone":            zone,
-			"start_time":      startTime.Format(time.RFC3339),
-			"is_active":       true,
-			"selfie":          selfiePath,
-		}
-		json.NewEncoder(w).Encode(activeShift)
+		var breakStartedAt interface{}
+		if open := shift.OpenBreak(); open != nil {
+			breakStartedAt = open.StartedAt.Format(time.RFC3339)
+		}
+
+		response.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"id":               shift.ID,
+			"user_id":          userID,
+			"username":         shift.Username,
+			"slot_time_range":  shift.SlotTimeRange,
+			"position":         shift.Position,
+			"zone":             shift.Zone,
+			"start_time":       shift.StartTime.Format(time.RFC3339),
+			"is_active":        true,
+			"on_break":         breakStartedAt != nil,
+			"break_started_at": breakStartedAt,
+			"breaks_taken":     len(shift.Breaks),
+			"break_time":       response.FormatDuration(shift.BreakSeconds(shifts.Now())),
+			"selfie":           signer.Sign(shift.SelfiePath),
+		})
 	}
 }
 
-func GetShiftsHandler(db *sql.DB) http.HandlerFunc {
+func GetShiftsHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
 		if !ok {
@@ -376,47 +384,28 @@ func GetShiftsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		rows, err := db.Query(`
-			SELECT 
-				start_time, end_time, slot_time_range, position, zone, worked_duration
-			FROM slots 
-			WHERE user_id = $1 AND end_time IS NOT NULL
-			ORDER BY start_time DESC
-		`, userID)
+		params, err := listing.Parse(r, repositories.ShiftHistorySpec)
+		if err != nil {
+			response.RespondWithBadRequest(w, err)
+			return
+		}
+
+		history, page, err := shifts.History(r.Context(), userID, params)
 		if err != nil {
 			log.Printf("DB error fetching shifts for user %d: %v", userID, err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to query shifts")
 			return
 		}
-		defer rows.Close()
 
-		var shifts []map[string]interface{}
-		for rows.Next() {
-			var startTime, endTime time.Time
-			var slotTimeRange, position, zone sql.NullString
-			var workedDuration sql.NullInt64
-			if err := rows.Scan(&startTime, &endTime, &slotTimeRange, &position, &zone, &workedDuration); err != nil {
-				log.Printf("Error scanning shift history row: %v", err)
-				continue
-			}
-			shift := map[string]interface{}{
-				"date":             startTime.Format("2006-01-02"),
-				"selected_slot":    slotTimeRange.String,
-				"worked_time":      response.FormatDuration(int(workedDuration.Int64)),
-				"work_period":      fmt.Sprintf("%s–%s", startTime.Format("15:04"), endTime.Format("15:04")),
-				"transport_status": "Транспорт не указан",
-				"new_tasks":        0,
-			}
-			shifts = append(shifts, shift)
+		result := []map[string]interface{}{}
+		for _, shift := range history {
+			result = append(result, shiftHistoryItem(shift))
 		}
-		if shifts == nil {
-			shifts = []map[string]interface{}{}
-		}
-		response.RespondWithJSON(w, http.StatusOK, shifts)
+		response.RespondWithPage(w, page, result)
 	}
 }
 
-func GetUserShiftsByIDHandler(db *sql.DB) http.HandlerFunc {
+func GetUserShiftsByIDHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		targetUserIDStr := chi.URLParam(r, "userID")
 		targetUserID, err := strconv.Atoi(targetUserIDStr)
@@ -431,62 +420,39 @@ func GetUserShiftsByIDHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var currentUserRole string
-		err = db.QueryRow("SELECT role FROM users WHERE id = $1", currentUserID).Scan(&currentUserRole)
+		allowed, err := shifts.CanViewHistory(r.Context(), currentUserID, targetUserID)
 		if err != nil {
 			log.Printf("DB error fetching current user role: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load user role")
 			return
 		}
-
-		if currentUserRole != "admin" && currentUserRole != "superadmin" && currentUserID != targetUserID {
+		if !allowed {
 			response.RespondWithError(w, http.StatusForbidden, "Access denied")
 			return
 		}
 
-		rows, err := db.Query(`
-			SELECT 
-				start_time, end_time, slot_time_range, position, zone, worked_duration
-			FROM slots 
-			WHERE user_id = $1 AND end_time IS NOT NULL
-			ORDER BY start_time DESC
-		`, targetUserID)
+		params, err := listing.Parse(r, repositories.ShiftHistorySpec)
 		if err != nil {
-			log.Printf("DB error fetching shifts for user %d: %v", targetUserID, err)
-			response.RespondWithError(w, http.StatusInternalServerError, "Failed to query shifts")
+			response.RespondWithBadRequest(w, err)
 			return
 		}
-		defer rows.Close()
 
-		var shifts []map[string]interface{}
-		for rows.Next() {
-			var startTime, endTime time.Time
-			var slotTimeRange, position, zone sql.NullString
-			var workedDuration sql.NullInt64
-			if err := rows.Scan(&startTime, &endTime, &slotTimeRange, &position, &zone, &workedDuration); err != nil {
-				log.Printf("Error scanning target user shift row: %v", err)
-				continue
-			}
-			shift := map[string]interface{}{
-				"date":             startTime.Format("2006-01-02"),
-				"selected_slot":    slotTimeRange.String,
-				"worked_time":      response.FormatDuration(int(workedDuration.Int64)),
-				"work_period":      fmt.Sprintf("%s–%s", startTime.Format("15:04"), endTime.Format("15:04")),
-				"transport_status": "Транспорт не указан",
-				"new_tasks":        0,
-			}
-			shifts = append(shifts, shift)
+		history, page, err := shifts.History(r.Context(), targetUserID, params)
+		if err != nil {
+			log.Printf("DB error fetching shifts for user %d: %v", targetUserID, err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to query shifts")
+			return
 		}
 
-		if shifts == nil {
-			shifts = []map[string]interface{}{}
+		result := []map[string]interface{}{}
+		for _, shift := range history {
+			result = append(result, shiftHistoryItem(shift))
 		}
-
-		response.RespondWithJSON(w, http.StatusOK, shifts)
+		response.RespondWithPage(w, page, result)
 	}
 }
 
-func GetAvailablePositionsHandler(db *sql.DB) http.HandlerFunc {
+func GetAvailablePositionsHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := r.Context().Value(middleware.UserIDContextKey).(int)
 		if !ok {
@@ -494,79 +460,36 @@ func GetAvailablePositionsHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		var role string
-		err := db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&role)
+		position, err := shifts.Position(r.Context(), userID)
 		if err != nil {
 			log.Printf("DB error fetching role for user %d: %v", userID, err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load user role")
 			return
 		}
-
-		positionMap := map[string]string{
-			"superadmin":  "Суперадмин",
-			"admin":       "Администратор",
-			"coordinator": "Координатор",
-			"scout":       "Скаут",
-			"user":        "Пользователь",
-		}
-
-		position := "Сотрудник"
-		if readablePosition, exists := positionMap[role]; exists {
-			position = readablePosition
-		}
 		response.RespondWithJSON(w, http.StatusOK, []string{position})
 	}
 }
 
-func GetAvailableTimeSlotsHandler(db *sql.DB) http.HandlerFunc {
+func GetAvailableTimeSlotsHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var timeSlots []string
-		rows, err := db.Query("SELECT slot_time_range FROM available_time_slots")
+		timeSlots, err := shifts.TimeSlots(r.Context())
 		if err != nil {
 			log.Printf("DB error fetching time slots: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load time slots")
 			return
 		}
-		defer rows.Close()
-		for rows.Next() {
-			var timeSlot string
-			if err := rows.Scan(&timeSlot); err != nil {
-				log.Printf("Error scanning time slot: %v", err)
-				continue
-			}
-			timeSlots = append(timeSlots, (timeSlot))
-		}
 		response.RespondWithJSON(w, http.StatusOK, timeSlots)
 	}
 }
 
-func GetAvailableTimeSlotsForStartHandler(db *sql.DB) http.HandlerFunc {
+func GetAvailableTimeSlotsForStartHandler(shifts *shiftService.ShiftService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query("SELECT slot_time_range FROM available_time_slots")
+		availableNow, err := shifts.TimeSlotsAvailableNow(r.Context())
 		if err != nil {
 			log.Printf("DB error: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to load time slots")
 			return
 		}
-		defer rows.Close()
-
-		var allSlots []string
-		for rows.Next() {
-			var slot string
-			if err := rows.Scan(&slot); err != nil {
-				continue
-			}
-			allSlots = append(allSlots, slot)
-		}
-
-		var availableNow []string
-		for _, slot := range allSlots {
-			if canStartShift(slot) {
-				availableNow = append(availableNow, slot)
-			}
-		}
-
-		// Правильный вызов — в том же пакете
 		response.RespondWithJSON(w, http.StatusOK, availableNow)
 	}
 }
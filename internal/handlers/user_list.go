@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/evn/eom_backendl/internal/middleware"
 	"github.com/evn/eom_backendl/internal/pkg/response"
 )
 
@@ -15,9 +16,18 @@ type User struct {
 	Role      string `json:"role"`
 }
 
+// PublicUser — минимальный набор полей для пользователей без прав персонала.
+type PublicUser struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+}
+
 func ListUsersHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query("SELECT id, username, first_name, role FROM users")
+		role, _ := middleware.GetUserRoleFromContext(r.Context())
+		isStaff := middleware.IsStaff(role)
+
+		rows, err := db.Query("SELECT id, username, first_name, role FROM users WHERE deleted_at IS NULL")
 		if err != nil {
 			log.Printf("Error querying users: %v", err)
 			response.RespondWithError(w, http.StatusInternalServerError, "Failed to query users")
@@ -50,6 +60,15 @@ func ListUsersHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if !isStaff {
+			publicUsers := make([]PublicUser, 0, len(users))
+			for _, u := range users {
+				publicUsers = append(publicUsers, PublicUser{ID: u.ID, FirstName: u.FirstName})
+			}
+			response.RespondWithJSON(w, http.StatusOK, publicUsers)
+			return
+		}
+
 		response.RespondWithJSON(w, http.StatusOK, users)
 	}
 }
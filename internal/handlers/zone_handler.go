@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/evn/eom_backendl/internal/pkg/response"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -39,7 +40,7 @@ func GetAvailableZonesHandler(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-func CreateZoneHandler(db *sql.DB) http.HandlerFunc {
+func CreateZoneHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var zone Zone
 		if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
@@ -48,11 +49,12 @@ func CreateZoneHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		if zone.Name == "" {
-			response.RespondWithError(w, http.StatusBadRequest, "Zone name is required")
+			response.RespondWithValidation(w, response.FieldError{Field: "name", Code: response.FieldRequired})
 			return
 		}
 
 		var newID int
+		created := true
 		err := db.QueryRow(`
 			INSERT INTO zones (name) 
 			VALUES ($1) 
@@ -63,6 +65,7 @@ func CreateZoneHandler(db *sql.DB) http.HandlerFunc {
 		if err != nil {
 			if err == sql.ErrNoRows {
 				// Запись уже существует — получаем её ID
+				created = false
 				err = db.QueryRow("SELECT id FROM zones WHERE name = $1", zone.Name).Scan(&newID)
 				if err != nil {
 					log.Printf("Failed to fetch existing zone ID: %v", err)
@@ -77,11 +80,14 @@ func CreateZoneHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		zone.ID = newID
+		if created {
+			auditLog.Record(r, "zone.create", "zone", strconv.Itoa(newID), nil, zone)
+		}
 		response.RespondWithJSON(w, http.StatusCreated, zone)
 	}
 }
 
-func UpdateZoneHandler(db *sql.DB) http.HandlerFunc {
+func UpdateZoneHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.Atoi(idStr)
@@ -96,6 +102,17 @@ func UpdateZoneHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		var before Zone
+		err = db.QueryRow("SELECT id, name FROM zones WHERE id = $1", id).Scan(&before.ID, &before.Name)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "Zone not found")
+			return
+		} else if err != nil {
+			log.Printf("Database error: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to update zone")
+			return
+		}
+
 		result, err := db.Exec("UPDATE zones SET name = $1 WHERE id = $2", zone.Name, id)
 		if err != nil {
 			log.Printf("Database update error: %v", err)
@@ -110,11 +127,12 @@ func UpdateZoneHandler(db *sql.DB) http.HandlerFunc {
 		}
 
 		zone.ID = id
+		auditLog.Record(r, "zone.update", "zone", strconv.Itoa(id), before, zone)
 		response.RespondWithJSON(w, http.StatusOK, zone)
 	}
 }
 
-func DeleteZoneHandler(db *sql.DB) http.HandlerFunc {
+func DeleteZoneHandler(db *sql.DB, auditLog *auditService.AuditLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.Atoi(idStr)
@@ -123,6 +141,17 @@ func DeleteZoneHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		var before Zone
+		err = db.QueryRow("SELECT id, name FROM zones WHERE id = $1", id).Scan(&before.ID, &before.Name)
+		if err == sql.ErrNoRows {
+			response.RespondWithError(w, http.StatusNotFound, "Zone not found")
+			return
+		} else if err != nil {
+			log.Printf("Database error: %v", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete zone")
+			return
+		}
+
 		result, err := db.Exec("DELETE FROM zones WHERE id = $1", id)
 		if err != nil {
 			log.Printf("Database delete error: %v", err)
@@ -136,6 +165,7 @@ func DeleteZoneHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		auditLog.Record(r, "zone.delete", "zone", strconv.Itoa(id), before, nil)
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 	}
 }
@@ -0,0 +1,135 @@
+// internal/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyTTL        = 24 * time.Hour
+	idempotencyLockTTL    = time.Minute // сколько держим «в процессе», если сервер упал посреди запроса
+	idempotencyMaxKeySize = 128
+	idempotencyPending    = "pending"
+)
+
+type idempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyRecorder пишет ответ клиенту и копию — для сохранения.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Idempotency делает повтор запроса с тем же заголовком Idempotency-Key безопасным:
+// повтор получает сохранённый ответ первого запроса, а не выполняется заново.
+// Ключ действует для пользователя и маршрута; запросы без заголовка проходят как есть.
+// Ответы 5xx не сохраняются — такой запрос можно повторить с тем же ключом.
+func Idempotency(redisClient *redis.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > idempotencyMaxKeySize {
+				response.RespondWithValidation(w, response.FieldError{Field: IdempotencyKeyHeader, Code: response.FieldTooLong, Params: map[string]interface{}{"max": idempotencyMaxKeySize}})
+				return
+			}
+
+			userID, _ := GetUserIDFromContext(r.Context())
+			storeKey := "idempotency:" + strconv.Itoa(userID) + ":" + r.Method + ":" + r.URL.Path + ":" + key
+
+			ok, err := redisClient.SetNX(r.Context(), storeKey, idempotencyPending, idempotencyLockTTL).Result()
+			if err != nil {
+				log.Printf("Redis error on idempotency key: %v", err)
+				response.RespondWithError(w, http.StatusServiceUnavailable, "Idempotency check failed")
+				return
+			}
+			if !ok {
+				replayIdempotent(w, r, redisClient, storeKey)
+				return
+			}
+
+			rec := &idempotencyRecorder{ResponseWriter: w}
+			defer func() {
+				// Клиент мог оборвать соединение — ответ всё равно сохраняем для его повтора
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+					redisClient.Del(ctx, storeKey)
+					return
+				}
+				data, _ := json.Marshal(idempotentResponse{
+					Status:      rec.status,
+					ContentType: rec.Header().Get("Content-Type"),
+					Body:        rec.body.Bytes(),
+				})
+				if err := redisClient.Set(ctx, storeKey, data, idempotencyTTL).Err(); err != nil {
+					log.Printf("Failed to store idempotent response: %v", err)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+func replayIdempotent(w http.ResponseWriter, r *http.Request, redisClient *redis.Client, storeKey string) {
+	stored, err := redisClient.Get(r.Context(), storeKey).Result()
+	if err == redis.Nil {
+		// Первый запрос завершился ошибкой и освободил ключ — пусть клиент повторит
+		response.RespondWithError(w, http.StatusConflict, "Previous request with this Idempotency-Key failed, try again")
+		return
+	} else if err != nil {
+		log.Printf("Redis error reading idempotent response: %v", err)
+		response.RespondWithError(w, http.StatusServiceUnavailable, "Idempotency check failed")
+		return
+	}
+	if stored == idempotencyPending {
+		response.RespondWithError(w, http.StatusConflict, "Request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	var saved idempotentResponse
+	if err := json.Unmarshal([]byte(stored), &saved); err != nil {
+		log.Printf("Corrupted idempotent response %s: %v", storeKey, err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Idempotency check failed")
+		return
+	}
+	if saved.ContentType != "" {
+		w.Header().Set("Content-Type", saved.ContentType)
+	}
+	w.Header().Set(IdempotencyReplayedHeader, "true")
+	w.WriteHeader(saved.Status)
+	w.Write(saved.Body)
+}
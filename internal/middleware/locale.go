@@ -0,0 +1,20 @@
+// internal/middleware/locale.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+// Locale выбирает язык ответа (ru, kk, en) по Accept-Language и ставит его в
+// Content-Language: по нему response переводит сообщения об ошибках.
+func Locale() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Language", response.Language(r.Header.Get("Accept-Language")))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// internal/middleware/password.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// RequirePasswordChanged не пускает с токеном, выданным по временному паролю,
+// никуда, кроме перечисленных путей (смена пароля, выход).
+func RequirePasswordChanged(allowedPaths ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(allowedPaths))
+	for _, p := range allowedPaths {
+		allowed[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, claims, _ := jwtauth.FromContext(r.Context())
+			if mustChange, _ := claims[authService.MustChangePasswordClaim].(bool); mustChange && !allowed[r.URL.Path] {
+				response.RespondWithError(w, http.StatusForbidden, "Password change required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
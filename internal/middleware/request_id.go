@@ -0,0 +1,53 @@
+// internal/middleware/request_id.go
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const maxRequestIDLength = 64
+
+// RequestID берёт id запроса из X-Request-Id клиента или выдаёт новый и
+// возвращает его в заголовке ответа: по нему ошибку из приложения можно
+// найти в логе. Ставится до chiMiddleware.Logger — тот пишет id в строку лога.
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(response.RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(response.RequestIDHeader, id)
+			ctx := context.WithValue(r.Context(), chiMiddleware.RequestIDKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID — id клиента попадает в лог и заголовки, поэтому
+// принимаются только короткие строки из букв, цифр, '-', '_' и '.'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
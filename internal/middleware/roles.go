@@ -0,0 +1,55 @@
+// internal/middleware/roles.go
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// StaffRoles — роли, которым доступны данные других сотрудников.
+var StaffRoles = []string{"supervisor", "coordinator", "admin", "superadmin"}
+
+// GetUserRoleFromContext возвращает роль из claims JWT.
+func GetUserRoleFromContext(ctx context.Context) (string, bool) {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil || claims == nil {
+		return "", false
+	}
+	role, ok := claims["role"].(string)
+	return role, ok && role != ""
+}
+
+// IsStaff проверяет, относится ли роль к персоналу.
+func IsStaff(role string) bool {
+	return hasRole(role, StaffRoles)
+}
+
+// RequireRoles пропускает запрос только если роль пользователя входит в список.
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetUserRoleFromContext(r.Context())
+			if !ok {
+				response.RespondWithError(w, http.StatusForbidden, "Role not found")
+				return
+			}
+			if !hasRole(role, roles) {
+				response.RespondWithError(w, http.StatusForbidden, "Access denied")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasRole(role string, roles []string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
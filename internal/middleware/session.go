@@ -0,0 +1,49 @@
+// internal/middleware/session.go
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+// GetSessionIDFromContext возвращает sid из claims access-токена.
+func GetSessionIDFromContext(ctx context.Context) (string, bool) {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil || claims == nil {
+		return "", false
+	}
+	sid, ok := claims["sid"].(string)
+	return sid, ok && sid != ""
+}
+
+// SessionGuard отклоняет access-токены отозванных сессий.
+// Токены, выпущенные до появления sid, пропускаются до истечения срока.
+func SessionGuard(jwtService *authService.JWTService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sid, ok := GetSessionIDFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			revoked, err := jwtService.IsSessionRevoked(r.Context(), sid)
+			if err != nil {
+				log.Printf("Redis error checking session %s: %v", sid, err)
+				response.RespondWithError(w, http.StatusServiceUnavailable, "Session check failed")
+				return
+			}
+			if revoked {
+				response.RespondWithError(w, http.StatusUnauthorized, "Session has been revoked")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
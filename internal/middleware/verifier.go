@@ -0,0 +1,56 @@
+// internal/middleware/verifier.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Verifier проверяет JWT ключами JWTService (с учётом kid и ротации) и кладёт
+// токен в контекст в формате jwtauth, чтобы jwtauth.FromContext работал как прежде.
+func Verifier(jwtService *authService.JWTService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.TokenFromHeader(r)
+			if tokenString == "" {
+				tokenString = jwtauth.TokenFromCookie(r)
+			}
+			if tokenString == "" {
+				next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), nil, jwtauth.ErrNoTokenFound)))
+				return
+			}
+
+			if _, err := jwtService.ValidateToken(tokenString); err != nil {
+				next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), nil, jwtauth.ErrUnauthorized)))
+				return
+			}
+
+			// Подпись уже проверена выше, здесь только разбор claims
+			token, err := jwt.ParseInsecure([]byte(tokenString))
+			next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, err)))
+		})
+	}
+}
+
+// Authenticated — в запросе действительный токен (после Verifier).
+func Authenticated(r *http.Request) bool {
+	token, _, err := jwtauth.FromContext(r.Context())
+	return err == nil && token != nil
+}
+
+// Authenticator пропускает только запросы с действительным токеном.
+func Authenticator() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Authenticated(r) {
+				response.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// models/assignment.go
+package models
+
+// ShiftAssignment — плановый выход сотрудника (строка таблицы shift_assignments).
+type ShiftAssignment struct {
+	ID            int    `json:"id"`
+	UserID        int    `json:"user_id"`
+	Username      string `json:"username"`
+	ShiftDate     string `json:"shift_date"` // 2006-01-02
+	SlotTimeRange string `json:"slot_time_range"`
+	Zone          string `json:"zone"`
+	ShiftID       *int   `json:"slot_id"` // смена, которой выход отработан
+}
+
+// PunctualityRow — пунктуальность сотрудника за период.
+type PunctualityRow struct {
+	UserID            int     `json:"user_id"`
+	Username          string  `json:"username"`
+	Shifts            int     `json:"shifts"`
+	LateShifts        int     `json:"late_shifts"`
+	LateMinutes       int     `json:"late_minutes"`
+	EarlyLeaveShifts  int     `json:"early_leave_shifts"`
+	EarlyLeaveMinutes int     `json:"early_leave_minutes"`
+	AutoClosedShifts  int     `json:"auto_closed_shifts"`
+	Planned           int     `json:"planned"`
+	NoShows           int     `json:"no_shows"`
+	OnTimeRate        float64 `json:"on_time_rate"` // доля смен без опоздания, раннего ухода и автозакрытия
+}
@@ -0,0 +1,129 @@
+// models/shift.go
+package models
+
+import "time"
+
+// Shift — смена сотрудника (строка таблицы slots).
+type Shift struct {
+	ID             int
+	UserID         int
+	Username       string // заполняется только в выборках с JOIN users
+	StartTime      time.Time
+	EndTime        *time.Time
+	SlotTimeRange  string
+	Position       string
+	Zone           string
+	SelfiePath     string
+	SelfieThumb    string // пусто у смен, открытых до появления миниатюр
+	Selfie         SelfieMeta
+	WorkedDuration int // в секундах, без перерывов
+	BreakDuration  int // в секундах, заполняется при закрытии смены
+	Breaks         []ShiftBreak
+	// Пунктуальность: минуты опоздания и раннего ухода сверх допуска
+	LateMinutes       int
+	EarlyLeaveMinutes int
+	EndReason         string // ShiftEnded*, пусто у открытых и старых смен
+	// Правки администратора (см. ShiftCorrection)
+	CorrectedAt *time.Time
+	VoidedAt    *time.Time // аннулирована: не учитывается в табеле и отчётах
+}
+
+// Кто закрыл смену (slots.end_reason).
+const (
+	ShiftEndedByUser  = "user"
+	ShiftEndedByAdmin = "admin"
+	ShiftEndedAuto    = "auto" // по окончании слота: сотрудник забыл закрыть смену
+)
+
+// ShiftFlags — отметки пунктуальности смены для списков и отчётов.
+type ShiftFlags struct {
+	Late              bool `json:"late"`
+	LateMinutes       int  `json:"late_minutes"`
+	LeftEarly         bool `json:"left_early"`
+	EarlyLeaveMinutes int  `json:"early_leave_minutes"`
+	AutoClosed        bool `json:"auto_closed"`
+	Corrected         bool `json:"corrected"`
+	Voided            bool `json:"voided"`
+}
+
+func (s Shift) Flags() ShiftFlags {
+	return ShiftFlags{
+		Late:              s.LateMinutes > 0,
+		LateMinutes:       s.LateMinutes,
+		LeftEarly:         s.EarlyLeaveMinutes > 0,
+		EarlyLeaveMinutes: s.EarlyLeaveMinutes,
+		AutoClosed:        s.EndReason == ShiftEndedAuto,
+		Corrected:         s.CorrectedAt != nil,
+		Voided:            s.VoidedAt != nil,
+	}
+}
+
+// SelfieMeta — данные проверки селфи, сохранённые при открытии смены.
+type SelfieMeta struct {
+	TakenAt      *time.Time // время съёмки из EXIF
+	Latitude     *float64
+	Longitude    *float64
+	PHash        *int64                 // перцептивный хэш (uint64, записанный как BIGINT)
+	Verification map[string]interface{} // результаты проверок, например сверки лица
+}
+
+// SelfieHash — хэш селфи смены для поиска повторно отправленных фото.
+type SelfieHash struct {
+	ShiftID int
+	UserID  int
+	Hash    int64
+}
+
+// OpenBreak — текущий перерыв, если смена на паузе (нужны загруженные Breaks).
+func (s Shift) OpenBreak() *ShiftBreak {
+	for i := range s.Breaks {
+		if s.Breaks[i].EndedAt == nil {
+			return &s.Breaks[i]
+		}
+	}
+	return nil
+}
+
+// BreakSeconds — время перерывов на момент now: у закрытой смены сохранённое,
+// у открытой считается по Breaks.
+func (s Shift) BreakSeconds(now time.Time) int {
+	if s.EndTime != nil {
+		return s.BreakDuration
+	}
+	total := 0
+	for _, b := range s.Breaks {
+		total += b.Seconds(now)
+	}
+	return total
+}
+
+// ShiftBreak — перерыв внутри смены (строка таблицы slot_breaks).
+type ShiftBreak struct {
+	ID         int        `json:"id"`
+	ShiftID    int        `json:"slot_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	AutoClosed bool       `json:"auto_closed"` // закрыт по лимиту длительности
+}
+
+// Seconds — длительность перерыва; незавершённый считается до now.
+func (b ShiftBreak) Seconds(now time.Time) int {
+	end := now
+	if b.EndedAt != nil {
+		end = *b.EndedAt
+	}
+	if seconds := int(end.Sub(b.StartedAt).Seconds()); seconds > 0 {
+		return seconds
+	}
+	return 0
+}
+
+// BreakPolicy — лимиты перерывов для типа смены (available_time_slots).
+type BreakPolicy struct {
+	MaxBreaks       int
+	MaxBreakMinutes int
+}
+
+func (p BreakPolicy) MaxLength() time.Duration {
+	return time.Duration(p.MaxBreakMinutes) * time.Minute
+}
@@ -0,0 +1,71 @@
+// models/shift_correction.go
+package models
+
+import "time"
+
+// Что администратор сделал со сменой (shift_corrections.action).
+const (
+	ShiftCorrectionForceEnd = "force_end"
+	ShiftCorrectionEdit     = "edit"
+	ShiftCorrectionVoid     = "void"
+)
+
+// Коды причин правки смены.
+const (
+	CorrectionForgotToEnd      = "forgot_to_end"      // сотрудник забыл закрыть смену
+	CorrectionStartedByMistake = "started_by_mistake" // смену открыли по ошибке
+	CorrectionWrongZone        = "wrong_zone"
+	CorrectionWrongSlot        = "wrong_slot"
+	CorrectionTimeDispute      = "time_dispute" // спор о часах
+	CorrectionOther            = "other"        // нужен комментарий
+)
+
+// CorrectionReasons — допустимые коды причин.
+var CorrectionReasons = []string{
+	CorrectionForgotToEnd,
+	CorrectionStartedByMistake,
+	CorrectionWrongZone,
+	CorrectionWrongSlot,
+	CorrectionTimeDispute,
+	CorrectionOther,
+}
+
+// ShiftValues — значения смены, которые правит администратор, и пересчитанные по ним.
+type ShiftValues struct {
+	StartTime         time.Time  `json:"start_time"`
+	EndTime           *time.Time `json:"end_time"`
+	Zone              string     `json:"zone"`
+	SlotTimeRange     string     `json:"slot_time_range"`
+	WorkedDuration    int        `json:"worked_duration"` // в секундах
+	BreakDuration     int        `json:"break_duration"`
+	LateMinutes       int        `json:"late_minutes"`
+	EarlyLeaveMinutes int        `json:"early_leave_minutes"`
+	Voided            bool       `json:"voided"`
+}
+
+func (s Shift) Values() ShiftValues {
+	return ShiftValues{
+		StartTime:         s.StartTime,
+		EndTime:           s.EndTime,
+		Zone:              s.Zone,
+		SlotTimeRange:     s.SlotTimeRange,
+		WorkedDuration:    s.WorkedDuration,
+		BreakDuration:     s.BreakDuration,
+		LateMinutes:       s.LateMinutes,
+		EarlyLeaveMinutes: s.EarlyLeaveMinutes,
+		Voided:            s.VoidedAt != nil,
+	}
+}
+
+// ShiftCorrection — правка смены администратором (строка таблицы shift_corrections).
+type ShiftCorrection struct {
+	ID        int         `json:"id"`
+	ShiftID   int         `json:"slot_id"`
+	Action    string      `json:"action"` // ShiftCorrection*
+	Reason    string      `json:"reason"` // Correction*
+	Comment   string      `json:"comment"`
+	Before    ShiftValues `json:"before"`
+	After     ShiftValues `json:"after"`
+	CreatedBy *int        `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+// models/shift_report.go
+package models
+
+import "time"
+
+// ShiftReport — отчёт сотрудника при закрытии смены (строка shift_reports).
+type ShiftReport struct {
+	ShiftID   int            `json:"slot_id"`
+	Scooters  map[string]int `json:"scooters"` // сервис → собрано самокатов
+	Issues    []string       `json:"issues"`
+	Notes     string         `json:"notes"`
+	Photos    []string       `json:"photos"` // пути в хранилище, наружу отдаются подписанными
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Empty — в отчёте нет ни одного заполненного поля.
+func (r ShiftReport) Empty() bool {
+	return len(r.Scooters) == 0 && len(r.Issues) == 0 && r.Notes == "" && len(r.Photos) == 0
+}
+
+// ShiftWithReport — закрытая смена и её отчёт, если он был.
+type ShiftWithReport struct {
+	Shift  Shift
+	Report *ShiftReport
+}
@@ -0,0 +1,87 @@
+// models/timesheet.go
+package models
+
+import "time"
+
+// Статусы табеля за месяц. Открытый табель считается по сменам при каждом
+// запросе, утверждённый и закрытый отдаются из сохранённого снимка.
+const (
+	TimesheetOpen     = "open"
+	TimesheetApproved = "approved"
+	TimesheetLocked   = "locked" // передан в расчёт зарплаты, изменить нельзя
+)
+
+// TimesheetEntry — закрытая смена, попадающая в табель.
+type TimesheetEntry struct {
+	ShiftID       int
+	UserID        int
+	Username      string
+	FirstName     string
+	StartTime     time.Time
+	SlotTimeRange string
+	Zone          string
+	WorkedSeconds int
+}
+
+// TimesheetRules — правила округления и переработки, по которым посчитан табель.
+type TimesheetRules struct {
+	RoundingMinutes     int    `json:"rounding_minutes"` // шаг округления каждой смены
+	RoundingMode        string `json:"rounding_mode"`    // nearest, up или down
+	DailyOvertimeHours  int    `json:"daily_overtime_hours"`
+	WeeklyOvertimeHours int    `json:"weekly_overtime_hours"` // 0 — недельная переработка не считается
+}
+
+// TimesheetTotals — часы за день, неделю или месяц, в минутах.
+type TimesheetTotals struct {
+	Shifts          int            `json:"shifts"`
+	WorkedMinutes   int            `json:"worked_minutes"`
+	RegularMinutes  int            `json:"regular_minutes"`
+	OvertimeMinutes int            `json:"overtime_minutes"`
+	BySlot          map[string]int `json:"by_slot"` // отработанные минуты по типу смены
+	ByZone          map[string]int `json:"by_zone"`
+}
+
+type TimesheetDay struct {
+	Date string `json:"date"` // 2006-01-02
+	TimesheetTotals
+}
+
+// TimesheetWeek — итоги ISO-недели; From и To — её дни внутри месяца.
+type TimesheetWeek struct {
+	Week string `json:"week"` // 2006-W01
+	From string `json:"from"`
+	To   string `json:"to"`
+	TimesheetTotals
+}
+
+type TimesheetEmployee struct {
+	UserID    int             `json:"user_id"`
+	Username  string          `json:"username"`
+	FirstName string          `json:"first_name"`
+	Month     TimesheetTotals `json:"month"`
+	Weeks     []TimesheetWeek `json:"weeks"`
+	Days      []TimesheetDay  `json:"days"`
+}
+
+// Timesheet — табель за календарный месяц.
+type Timesheet struct {
+	Period     string              `json:"period"` // 2006-01
+	Status     string              `json:"status"`
+	Rules      TimesheetRules      `json:"rules"`
+	Employees  []TimesheetEmployee `json:"employees"`
+	ComputedAt time.Time           `json:"computed_at"`
+	ApprovedBy *int                `json:"approved_by,omitempty"`
+	ApprovedAt *time.Time          `json:"approved_at,omitempty"`
+	LockedBy   *int                `json:"locked_by,omitempty"`
+	LockedAt   *time.Time          `json:"locked_at,omitempty"`
+}
+
+// TimesheetPeriod — утверждение табеля за месяц (строка timesheet_periods).
+type TimesheetPeriod struct {
+	Status     string
+	Snapshot   []byte // Timesheet в JSON на момент утверждения
+	ApprovedBy *int
+	ApprovedAt time.Time
+	LockedBy   *int
+	LockedAt   *time.Time
+}
@@ -0,0 +1,17 @@
+// Package csvsafe защищает выгрузки CSV, которые открывают в Excel: ячейка,
+// начинающаяся с =, +, -, @, табуляции или возврата каретки, считается
+// формулой (CSV injection).
+package csvsafe
+
+// Cell экранирует текст из пользовательских данных апострофом, если Excel
+// принял бы его за формулу. Числа, посчитанные сервером, экранировать не нужно.
+func Cell(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
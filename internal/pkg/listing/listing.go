@@ -0,0 +1,314 @@
+// Package listing — общий слой для списочных эндпоинтов: разбор параметров
+// страницы, сортировки и фильтров из query string и сборка SQL по ним.
+//
+// Страница задаётся либо limit/offset, либо cursor из next_cursor предыдущего
+// ответа (курсор устойчив к вставкам и не сканирует пропущенные строки).
+// sort=field или sort=-field (по убыванию). Фильтры: from, to (YYYY-MM-DD,
+// to включительно), zone, user_id, role, status — каждый список принимает
+// только свои.
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Фильтры, которые может поддерживать список (Spec.Filters).
+const (
+	FilterDate   = "date" // from и to
+	FilterZone   = "zone"
+	FilterUser   = "user_id"
+	FilterRole   = "role"
+	FilterStatus = "status"
+)
+
+// Spec — что умеет конкретный список.
+type Spec struct {
+	// Sorts — поле сортировки в API → SQL-выражение. Выражение не должно
+	// давать NULL (иначе курсор теряет строки): nullable-колонки оборачиваются в COALESCE.
+	Sorts       map[string]string
+	DefaultSort string // например "-end_time"
+	IDColumn    string // уникальная колонка: добивает сортировку и входит в курсор
+	Filters     []string
+	Columns     Columns
+}
+
+// Columns — SQL-колонки, к которым применяются фильтры.
+type Columns struct {
+	Date   string
+	Zone   string
+	UserID string
+	Role   string
+	Status string
+}
+
+// Filter — значения фильтров из запроса; пустые не применяются.
+type Filter struct {
+	From   *time.Time
+	To     *time.Time // исключительная граница: начало дня после to
+	Zone   string
+	UserID *int
+	Role   string
+	Status string
+}
+
+// Params — разобранные параметры списка.
+type Params struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor // если задан, Offset равен нулю
+	Sort   string
+	Desc   bool
+	Filter Filter
+}
+
+// Cursor — позиция последней строки страницы: значение поля сортировки и id.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s Spec) allows(filter string) bool {
+	for _, f := range s.Filters {
+		if f == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse читает параметры списка из запроса. Ошибка — *response.ValidationError
+// с ошибками по параметрам (см. response.RespondWithBadRequest).
+func Parse(r *http.Request, spec Spec) (Params, error) {
+	q := r.URL.Query()
+	p := Params{Limit: DefaultLimit}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			return p, invalid("limit", response.FieldOutOfRange, map[string]interface{}{"min": 1, "max": MaxLimit})
+		}
+		p.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, invalid("offset", response.FieldInvalid, nil)
+		}
+		p.Offset = n
+	}
+
+	order := q.Get("sort")
+	if order == "" {
+		order = spec.DefaultSort
+	}
+	p.Desc = strings.HasPrefix(order, "-")
+	p.Sort = strings.TrimPrefix(order, "-")
+	if _, ok := spec.Sorts[p.Sort]; !ok {
+		return p, invalid("sort", response.FieldOneOf, map[string]interface{}{"allowed": strings.Join(sortNames(spec), ", ")})
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if p.Offset > 0 {
+			return p, invalid("cursor", response.FieldExclusive, map[string]interface{}{"with": "offset"})
+		}
+		c, err := decodeCursor(v)
+		if err != nil || c.Sort != order {
+			return p, invalid("cursor", response.FieldInvalid, nil)
+		}
+		p.Cursor = c
+	}
+
+	for _, name := range []string{"from", "to", FilterZone, FilterUser, FilterRole, FilterStatus} {
+		filter := name
+		if name == "from" || name == "to" {
+			filter = FilterDate
+		}
+		if q.Get(name) != "" && !spec.allows(filter) {
+			return p, invalid(name, response.FieldUnsupported, nil)
+		}
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return p, invalid("from", response.FieldInvalidFormat, dateFormat)
+		}
+		p.Filter.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return p, invalid("to", response.FieldInvalidFormat, dateFormat)
+		}
+		t = t.AddDate(0, 0, 1)
+		p.Filter.To = &t
+	}
+	if v := q.Get(FilterUser); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return p, invalid(FilterUser, response.FieldInvalid, nil)
+		}
+		p.Filter.UserID = &id
+	}
+	p.Filter.Zone = q.Get(FilterZone)
+	p.Filter.Role = q.Get(FilterRole)
+	p.Filter.Status = q.Get(FilterStatus)
+	return p, nil
+}
+
+var dateFormat = map[string]interface{}{"format": "YYYY-MM-DD"}
+
+func invalid(field, code string, params map[string]interface{}) error {
+	return response.Invalid(nil, response.FieldError{Field: field, Code: code, Params: params})
+}
+
+func sortNames(spec Spec) []string {
+	names := make([]string, 0, len(spec.Sorts))
+	for name := range spec.Sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Query собирает WHERE, ORDER BY и LIMIT списка. Условия добавляются через
+// Where с плейсхолдерами $%d — номера параметров расставляются сами.
+type Query struct {
+	spec   Spec
+	params Params
+	conds  []string
+	args   []interface{}
+}
+
+// NewQuery начинает запрос и сразу применяет фильтры из p.
+func NewQuery(spec Spec, p Params) *Query {
+	q := &Query{spec: spec, params: p}
+	f, cols := p.Filter, spec.Columns
+	if f.From != nil {
+		q.Where(cols.Date+" >= $%d", *f.From)
+	}
+	if f.To != nil {
+		q.Where(cols.Date+" < $%d", *f.To)
+	}
+	if f.Zone != "" {
+		q.Where(cols.Zone+" = $%d", f.Zone)
+	}
+	if f.UserID != nil {
+		q.Where(cols.UserID+" = $%d", *f.UserID)
+	}
+	if f.Role != "" {
+		q.Where(cols.Role+" = $%d", f.Role)
+	}
+	if f.Status != "" {
+		q.Where(cols.Status+" = $%d", f.Status)
+	}
+	return q
+}
+
+// Where добавляет условие; на каждое значение в expr — свой $%d.
+func (q *Query) Where(expr string, values ...interface{}) {
+	nums := make([]interface{}, len(values))
+	for i, v := range values {
+		q.args = append(q.args, v)
+		nums[i] = len(q.args)
+	}
+	q.conds = append(q.conds, fmt.Sprintf(expr, nums...))
+}
+
+func where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// Count — WHERE и параметры для подсчёта total (без курсора и страницы).
+func (q *Query) Count() (string, []interface{}) {
+	return where(q.conds), q.args
+}
+
+// SortValue — выражение для колонки, по которой строится курсор; её нужно
+// выбрать последней и отдать в Result.
+func (q *Query) SortValue() string {
+	return "(" + q.spec.Sorts[q.params.Sort] + ")::text"
+}
+
+// Page — WHERE с курсором, ORDER BY и LIMIT/OFFSET. Строк запрашивается на
+// одну больше Limit: по лишней Result понимает, что есть следующая страница.
+func (q *Query) Page() (string, []interface{}) {
+	conds := append([]string{}, q.conds...)
+	args := append([]interface{}{}, q.args...)
+	sortExpr := q.spec.Sorts[q.params.Sort]
+	dir, cmp := "ASC", ">"
+	if q.params.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if c := q.params.Cursor; c != nil {
+		args = append(args, c.Value, c.ID)
+		conds = append(conds, fmt.Sprintf("(%s, %s) %s ($%d, $%d)", sortExpr, q.spec.IDColumn, cmp, len(args)-1, len(args)))
+	}
+	sql := where(conds) + fmt.Sprintf(" ORDER BY %s %s, %s %s", sortExpr, dir, q.spec.IDColumn, dir)
+	args = append(args, q.params.Limit+1)
+	sql += fmt.Sprintf(" LIMIT $%d", len(args))
+	if q.params.Offset > 0 {
+		args = append(args, q.params.Offset)
+		sql += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return sql, args
+}
+
+// Key — значение SortValue и id строки, нужны Result для курсора.
+type Key struct {
+	Value string
+	ID    int
+}
+
+// Result обрезает лишнюю строку и собирает конверт ответа. keys — по строкам
+// выборки в том же порядке; возвращается, сколько строк оставить.
+func (q *Query) Result(keys []Key, total int) (int, response.Page) {
+	page := response.Page{Total: total, Limit: q.params.Limit, Offset: q.params.Offset}
+	n := len(keys)
+	if n > q.params.Limit {
+		n = q.params.Limit
+		page.HasMore = true
+		order := q.params.Sort
+		if q.params.Desc {
+			order = "-" + order
+		}
+		last := keys[n-1]
+		page.NextCursor = Cursor{Sort: order, Value: last.Value, ID: last.ID}.Encode()
+	}
+	return n, page
+}
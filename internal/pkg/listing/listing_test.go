@@ -0,0 +1,126 @@
+package listing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+var testSpec = Spec{
+	Sorts:       map[string]string{"end_time": "s.end_time", "zone": "s.zone"},
+	DefaultSort: "-end_time",
+	IDColumn:    "s.id",
+	Filters:     []string{FilterDate, FilterZone},
+	Columns:     Columns{Date: "s.start_time", Zone: "s.zone"},
+}
+
+func parse(t *testing.T, query string) (Params, error) {
+	t.Helper()
+	return Parse(httptest.NewRequest(http.MethodGet, "/api/shifts?"+query, nil), testSpec)
+}
+
+func TestParseErrors(t *testing.T) {
+	ascCursor := Cursor{Sort: "end_time", Value: "2026-03-01", ID: 5}.Encode()
+	tests := []struct {
+		query string
+		field string
+		code  string
+	}{
+		{"limit=0", "limit", response.FieldOutOfRange},
+		{"limit=501", "limit", response.FieldOutOfRange},
+		{"offset=-1", "offset", response.FieldInvalid},
+		{"sort=username", "sort", response.FieldOneOf},
+		{"cursor=%21%21", "cursor", response.FieldInvalid},
+		{"cursor=" + ascCursor, "cursor", response.FieldInvalid}, // курсор от другой сортировки
+		{"offset=10&sort=end_time&cursor=" + ascCursor, "cursor", response.FieldExclusive},
+		{"role=admin", "role", response.FieldUnsupported},
+		{"from=01.03.2026", "from", response.FieldInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parse(t, tt.query)
+			var verr *response.ValidationError
+			if !errors.As(err, &verr) || len(verr.Fields) != 1 {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+			if f := verr.Fields[0]; f.Field != tt.field || f.Code != tt.code {
+				t.Errorf("got %s/%s, want %s/%s", f.Field, f.Code, tt.field, tt.code)
+			}
+		})
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	p, err := parse(t, "zone=center&from=2026-03-01&to=2026-03-31&limit=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewQuery(testSpec, p)
+	q.Where("s.voided_at IS NULL")
+
+	sql, args := q.Page()
+	want := " WHERE s.start_time >= $1 AND s.start_time < $2 AND s.zone = $3 AND s.voided_at IS NULL" +
+		" ORDER BY s.end_time DESC, s.id DESC LIMIT $4"
+	if sql != want {
+		t.Errorf("page sql:\n%s\nwant:\n%s", sql, want)
+	}
+	if len(args) != 4 || args[2] != "center" || args[3] != 3 {
+		t.Errorf("page args: %v", args)
+	}
+	// to включительно: граница — начало следующего дня
+	if to := p.Filter.To.Format("2006-01-02"); to != "2026-04-01" {
+		t.Errorf("to bound %s, want 2026-04-01", to)
+	}
+
+	countSQL, countArgs := q.Count()
+	if countSQL != " WHERE s.start_time >= $1 AND s.start_time < $2 AND s.zone = $3 AND s.voided_at IS NULL" || len(countArgs) != 3 {
+		t.Errorf("count: %s %v", countSQL, countArgs)
+	}
+}
+
+// Курсор из next_cursor первой страницы продолжает выборку после её последней строки.
+func TestCursorRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		sort    string
+		wantCmp string
+		wantDir string
+	}{
+		{"-end_time", "<", "DESC"},
+		{"zone", ">", "ASC"},
+	} {
+		t.Run(tt.sort, func(t *testing.T) {
+			p, err := parse(t, "limit=2&sort="+tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, page := NewQuery(testSpec, p).Result([]Key{{"c", 3}, {"b", 2}, {"a", 1}}, 10)
+			if n != 2 || !page.HasMore || page.NextCursor == "" {
+				t.Fatalf("first page: n=%d %+v", n, page)
+			}
+
+			next, err := parse(t, "limit=2&sort="+tt.sort+"&cursor="+page.NextCursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := (&Cursor{Sort: tt.sort, Value: "b", ID: 2}); !reflect.DeepEqual(next.Cursor, want) {
+				t.Errorf("cursor %+v, want %+v", next.Cursor, want)
+			}
+
+			sql, args := NewQuery(testSpec, next).Page()
+			col := testSpec.Sorts[next.Sort]
+			want := " WHERE (" + col + ", s.id) " + tt.wantCmp + " ($1, $2) ORDER BY " + col + " " + tt.wantDir + ", s.id " + tt.wantDir + " LIMIT $3"
+			if sql != want || !reflect.DeepEqual(args, []interface{}{"b", 2, 3}) {
+				t.Errorf("next page: %s %v\nwant: %s", sql, args, want)
+			}
+
+			n, page = NewQuery(testSpec, next).Result([]Key{{"a", 1}}, 10)
+			if n != 1 || page.HasMore || page.NextCursor != "" {
+				t.Errorf("last page: n=%d %+v", n, page)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+// SpecHandler отдаёт документ OpenAPI в JSON.
+func (s *Spec) SpecHandler() http.HandlerFunc {
+	data, err := json.Marshal(s.Document())
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// DocsHandler отдаёт справочник по API, собранный из спецификации на сервере.
+// Страница не грузит скриптов и стилей со сторонних CDN (CSP это запрещает):
+// для Swagger UI или Postman документ берётся по адресу specURL.
+func (s *Spec) DocsHandler(specURL string) http.HandlerFunc {
+	var page bytes.Buffer
+	if err := docsTemplate.Execute(&page, s.docsPage(specURL)); err != nil {
+		panic("openapi: " + err.Error())
+	}
+	data := page.Bytes()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		w.Write(data)
+	}
+}
+
+type docsPage struct {
+	Spec    *Spec
+	SpecURL string
+	Tags    []docsTag
+	Schemas []docsSchema
+}
+
+type docsTag struct {
+	Name       string
+	Operations []docsOperation
+}
+
+type docsOperation struct {
+	*Operation
+	Status   int
+	Body     string
+	Response string
+}
+
+type docsSchema struct {
+	Name   string
+	Schema string
+}
+
+func (s *Spec) docsPage(specURL string) docsPage {
+	page := docsPage{Spec: s, SpecURL: specURL}
+	byTag := map[string][]docsOperation{}
+	for _, op := range s.Operations {
+		doc := docsOperation{Operation: op, Status: op.Status, Body: schemaJSON(op.Body), Response: schemaJSON(op.Response)}
+		if doc.Status == 0 {
+			doc.Status = http.StatusOK
+		}
+		if op.Body == nil {
+			doc.Body = schemaJSON(op.Multipart)
+		}
+		byTag[op.Tag] = append(byTag[op.Tag], doc)
+	}
+	for tag, ops := range byTag {
+		sort.SliceStable(ops, func(i, j int) bool {
+			if ops[i].Path != ops[j].Path {
+				return ops[i].Path < ops[j].Path
+			}
+			return ops[i].Method < ops[j].Method
+		})
+		page.Tags = append(page.Tags, docsTag{Name: tag, Operations: ops})
+	}
+	sort.Slice(page.Tags, func(i, j int) bool { return page.Tags[i].Name < page.Tags[j].Name })
+	for name, schema := range s.Schemas {
+		page.Schemas = append(page.Schemas, docsSchema{Name: name, Schema: schemaJSON(schema)})
+	}
+	sort.Slice(page.Schemas, func(i, j int) bool { return page.Schemas[i].Name < page.Schemas[j].Name })
+	return page
+}
+
+func schemaJSON(schema *Schema) string {
+	if schema == nil {
+		return ""
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+	return string(data)
+}
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>{{.Spec.Title}}</title>
+	<style>
+		body { font-family: sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+		h3 { font-family: monospace; }
+		pre { background: #f5f5f5; padding: .5em; overflow-x: auto; }
+		.method { color: #fff; background: #555; padding: 0 .4em; border-radius: 3px; }
+	</style>
+</head>
+<body>
+	<h1>{{.Spec.Title}} {{.Spec.Version}}</h1>
+	<p>{{.Spec.Description}}</p>
+	<p>Документ OpenAPI: <a href="{{.SpecURL}}">{{.SpecURL}}</a>. Ошибки всех операций — схема ErrorBody.</p>
+	{{range .Tags}}
+	<h2>{{.Name}}</h2>
+	{{range .Operations}}
+	<h3><span class="method">{{.Method}}</span> {{.Path}}</h3>
+	<p>{{.Summary}}{{if .Public}} (без токена){{end}}</p>
+	{{if .Params}}<ul>{{range .Params}}
+		<li><code>{{.Name}}</code> ({{.In}}{{if .Required}}, обязательный{{end}}){{if .Description}} — {{.Description}}{{end}}</li>{{end}}
+	</ul>{{end}}
+	{{if .Body}}<p>Тело запроса{{if .Multipart}} (multipart/form-data){{end}}:</p><pre>{{.Body}}</pre>{{end}}
+	<p>Ответ {{.Status}}{{if .ContentType}}: {{.ContentType}}{{end}}</p>
+	{{if .Response}}<pre>{{.Response}}</pre>{{end}}
+	{{end}}
+	{{end}}
+	<h2>Схемы</h2>
+	{{range .Schemas}}
+	<h3>{{.Name}}</h3>
+	<pre>{{.Schema}}</pre>
+	{{end}}
+</body>
+</html>
+`))
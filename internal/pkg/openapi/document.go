@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Document собирает документ OpenAPI 3.0. Ошибки всех операций описаны одной
+// схемой ErrorBody (см. response.ErrorBody).
+func (s *Spec) Document() map[string]interface{} {
+	paths := map[string]map[string]interface{}{}
+	for _, op := range s.Operations {
+		path := strings.ReplaceAll(op.Path, "*", "{path}")
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(op.Method)] = op.document()
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":       s.Title,
+			"version":     s.Version,
+			"description": s.Description,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": s.Schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []map[string][]string{{"bearerAuth": {}}},
+	}
+}
+
+func (op *Operation) document() map[string]interface{} {
+	doc := map[string]interface{}{
+		"summary":     op.Summary,
+		"operationId": operationID(op),
+	}
+	if op.Tag != "" {
+		doc["tags"] = []string{op.Tag}
+	}
+	if op.Public {
+		doc["security"] = []interface{}{}
+	}
+
+	var params []map[string]interface{}
+	for _, p := range op.Params {
+		name := p.Name
+		if name == "*" {
+			name = "path"
+		}
+		param := map[string]interface{}{"name": name, "in": p.In, "schema": p.Schema}
+		if p.Required {
+			param["required"] = true
+		}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	if params != nil {
+		doc["parameters"] = params
+	}
+
+	content := map[string]interface{}{}
+	if op.Body != nil {
+		content["application/json"] = map[string]interface{}{"schema": op.Body}
+	}
+	if op.Multipart != nil {
+		content["multipart/form-data"] = map[string]interface{}{"schema": op.Multipart}
+	}
+	if len(content) > 0 {
+		doc["requestBody"] = map[string]interface{}{
+			"required": op.BodyRequired || (op.Body == nil && op.Multipart != nil),
+			"content":  content,
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	switch {
+	case op.Response != nil:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": op.Response}}
+	case op.ContentType != "":
+		success["content"] = map[string]interface{}{op.ContentType: map[string]interface{}{}}
+	}
+	doc["responses"] = map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default": map[string]interface{}{
+			"description": "Ошибка",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": Ref("ErrorBody")}},
+		},
+	}
+	return doc
+}
+
+// operationID — например get_api_users_userID_shifts.
+func operationID(op *Operation) string {
+	replacer := strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_", "*", "path", ".", "_")
+	return strings.ToLower(op.Method) + replacer.Replace(op.Path)
+}
+
+// errorBodySchema — response.ErrorBody, схема ErrorBody каждой спецификации.
+func errorBodySchema() *Schema {
+	return Object(Fields{
+		"error": String().Desc("Текст ошибки на языке из Accept-Language"),
+		"code":  String().Desc("Стабильный код ошибки"),
+		"details": Array(Object(Fields{
+			"field":   String(),
+			"code":    String(),
+			"message": String(),
+			"params":  Map(Any()).Optional(),
+		})).Optional(),
+		"request_id": String().Optional(),
+	})
+}
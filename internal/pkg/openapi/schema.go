@@ -0,0 +1,116 @@
+// Package openapi — описание API в коде. Из одного описания строится документ
+// OpenAPI 3 для /api/docs, по нему же Validator проверяет запросы, а
+// контрактные тесты — ответы обработчиков.
+package openapi
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Schema — подмножество JSON Schema из OpenAPI 3.0, которого хватает API.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	IsNullable           bool               `json:"nullable,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+
+	// optional — поля объекта может не быть (omitempty); остальные обязательны.
+	optional bool
+}
+
+// Fields — поля объекта.
+type Fields map[string]*Schema
+
+func String() *Schema  { return &Schema{Type: "string"} }
+func Integer() *Schema { return &Schema{Type: "integer"} }
+func Number() *Schema  { return &Schema{Type: "number"} }
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+// DateTime — время в RFC 3339.
+func DateTime() *Schema { return &Schema{Type: "string", Format: "date-time"} }
+
+// Date — дата YYYY-MM-DD.
+func Date() *Schema { return &Schema{Type: "string", Format: "date"} }
+
+// Binary — файл в multipart-форме.
+func Binary() *Schema { return &Schema{Type: "string", Format: "binary"} }
+
+// Any — значение любого типа.
+func Any() *Schema { return &Schema{} }
+
+func Array(items *Schema) *Schema { return &Schema{Type: "array", Items: items} }
+
+// Map — объект с произвольными ключами и значениями values.
+func Map(values *Schema) *Schema { return &Schema{Type: "object", AdditionalProperties: values} }
+
+// Object — объект с полями fields; обязательны все, кроме помеченных Optional.
+func Object(fields Fields) *Schema {
+	s := &Schema{Type: "object", Properties: fields}
+	for name, field := range fields {
+		if !field.optional {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// Input — тело запроса: обязательны только поля required, остальные клиент
+// может не передавать.
+func Input(fields Fields, required ...string) *Schema {
+	for _, field := range fields {
+		field.optional = true
+	}
+	for _, name := range required {
+		fields[name].optional = false
+	}
+	return Object(fields)
+}
+
+// Ref — ссылка на схему из Spec.Schemas.
+func Ref(name string) *Schema { return &Schema{Ref: "#/components/schemas/" + name} }
+
+func (s *Schema) Nullable() *Schema { s.IsNullable = true; return s }
+
+func (s *Schema) Optional() *Schema { s.optional = true; return s }
+
+func (s *Schema) Desc(description string) *Schema { s.Description = description; return s }
+
+// OneOf ограничивает строку значениями values.
+func (s *Schema) OneOf(values ...string) *Schema { s.Enum = values; return s }
+
+func (s *Schema) Range(min, max float64) *Schema {
+	s.Minimum, s.Maximum = &min, &max
+	return s
+}
+
+func (s *Schema) Min(min float64) *Schema { s.Minimum = &min; return s }
+
+func (s *Schema) MaxLen(n int) *Schema { s.MaxLength = &n; return s }
+
+func (s *Schema) MaxCount(n int) *Schema { s.MaxItems = &n; return s }
+
+// MarshalJSON: в OpenAPI 3.0 рядом с $ref нельзя ставить nullable, поэтому
+// nullable-ссылка записывается через allOf.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if s.Ref != "" && (s.IsNullable || s.Description != "") {
+		return json.Marshal(struct {
+			AllOf       []map[string]string `json:"allOf"`
+			Nullable    bool                `json:"nullable,omitempty"`
+			Description string              `json:"description,omitempty"`
+		}{[]map[string]string{{"$ref": s.Ref}}, s.IsNullable, s.Description})
+	}
+	return json.Marshal((*plain)(s))
+}
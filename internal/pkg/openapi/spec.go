@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Spec — всё API: операции и общие схемы (components/schemas).
+type Spec struct {
+	Title       string
+	Version     string
+	Description string
+	Schemas     map[string]*Schema
+	Operations  []*Operation
+}
+
+// New — пустая спецификация; схема ошибок ErrorBody в ней уже есть.
+func New(title, version, description string) *Spec {
+	return &Spec{
+		Title:       title,
+		Version:     version,
+		Description: description,
+		Schemas:     map[string]*Schema{"ErrorBody": errorBodySchema()},
+	}
+}
+
+// Operation — один маршрут. Path записывается как в chi: /api/users/{userID},
+// /uploads/* (хвост пути).
+type Operation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Public  bool // без токена
+	Params  []Param
+
+	Body         *Schema // JSON-тело запроса
+	BodyRequired bool
+	Multipart    *Schema // multipart/form-data; Validator его не читает
+
+	Status      int     // код успешного ответа, по умолчанию 200
+	Response    *Schema // JSON-ответ; nil — ответа без тела или ContentType
+	ContentType string  // не-JSON ответ: text/csv, text/html, image/*...
+}
+
+// Param — параметр пути или query.
+type Param struct {
+	Name        string
+	In          string // path или query
+	Required    bool
+	Description string
+	Schema      *Schema
+}
+
+func PathParam(name string, schema *Schema) Param {
+	return Param{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+func Query(name string, schema *Schema, description string) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func (p Param) Require() Param {
+	p.Required = true
+	return p
+}
+
+func (s *Spec) Add(ops ...*Operation) {
+	s.Operations = append(s.Operations, ops...)
+}
+
+// Operation — операция по методу и шаблону пути chi.
+func (s *Spec) Operation(method, path string) *Operation {
+	for _, op := range s.Operations {
+		if op.Method == method && op.Path == path {
+			return op
+		}
+	}
+	return nil
+}
+
+// Find ищет операцию для запроса и значения параметров пути. Статические
+// сегменты важнее параметров: /api/shifts/active, а не /api/shifts/{id}.
+func (s *Spec) Find(method, path string) (*Operation, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var best *Operation
+	var bestParams map[string]string
+	bestScore := -1
+	for _, op := range s.Operations {
+		if op.Method != method {
+			continue
+		}
+		params, score, ok := match(strings.Split(strings.Trim(op.Path, "/"), "/"), segments)
+		if ok && score > bestScore {
+			best, bestParams, bestScore = op, params, score
+		}
+	}
+	return best, bestParams
+}
+
+func match(pattern, segments []string) (map[string]string, int, bool) {
+	params := map[string]string{}
+	score := 0
+	for i, part := range pattern {
+		if part == "*" {
+			params["*"] = strings.Join(segments[i:], "/")
+			return params, score, true
+		}
+		if i >= len(segments) {
+			return nil, 0, false
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params[part[1:len(part)-1]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, 0, false
+		}
+		score++
+	}
+	return params, score, len(pattern) == len(segments)
+}
+
+// CheckRoutes сверяет маршруты роутера со спецификацией: каждый маршрут должен
+// быть описан, и каждая операция — зарегистрирована.
+func (s *Spec) CheckRoutes(routes chi.Routes) error {
+	registered := map[string]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	documented := map[string]bool{}
+	for _, op := range s.Operations {
+		key := op.Method + " " + op.Path
+		if documented[key] {
+			problems = append(problems, "described twice: "+key)
+		}
+		documented[key] = true
+		if !registered[key] {
+			problems = append(problems, "not registered: "+key)
+		}
+	}
+	for key := range registered {
+		if !documented[key] {
+			problems = append(problems, "not described: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi spec is out of sync with routes:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+// checker проверяет значение из json.Unmarshal по схеме. strict — проверка
+// ответа: поля, которых нет в схеме, тоже ошибка (так ловится переименование).
+type checker struct {
+	schemas map[string]*Schema
+	strict  bool
+	errs    []response.FieldError
+}
+
+// ValidateRequest проверяет тело запроса; лишние поля допускаются.
+func (s *Spec) ValidateRequest(schema *Schema, value interface{}) []response.FieldError {
+	c := &checker{schemas: s.Schemas}
+	c.check("body", schema, value)
+	return c.errs
+}
+
+// ValidateResponse проверяет ответ обработчика: все поля должны быть описаны.
+func (s *Spec) ValidateResponse(schema *Schema, value interface{}) []response.FieldError {
+	c := &checker{schemas: s.Schemas, strict: true}
+	c.check("response", schema, value)
+	return c.errs
+}
+
+func (c *checker) fail(path, code string, params map[string]interface{}) {
+	c.errs = append(c.errs, response.FieldError{Field: path, Code: code, Params: params})
+}
+
+func (c *checker) resolve(schema *Schema) *Schema {
+	for schema.Ref != "" {
+		resolved, ok := c.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			panic("openapi: unknown schema " + schema.Ref)
+		}
+		if schema.IsNullable {
+			copied := *resolved
+			copied.IsNullable = true
+			resolved = &copied
+		}
+		schema = resolved
+	}
+	return schema
+}
+
+func (c *checker) check(path string, schema *Schema, value interface{}) {
+	schema = c.resolve(schema)
+	if value == nil {
+		if !schema.IsNullable && schema.Type != "" {
+			c.typeMismatch(path, schema)
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "":
+		return
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			c.typeMismatch(path, schema)
+			return
+		}
+		c.checkString(path, schema, str)
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && n != math.Trunc(n)) {
+			c.typeMismatch(path, schema)
+			return
+		}
+		c.checkNumber(path, schema, n)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			c.typeMismatch(path, schema)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			c.typeMismatch(path, schema)
+			return
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			c.fail(path, response.FieldTooMany, map[string]interface{}{"max": *schema.MaxItems})
+		}
+		for i, item := range items {
+			c.check(fmt.Sprintf("%s[%d]", path, i), schema.Items, item)
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			c.typeMismatch(path, schema)
+			return
+		}
+		c.checkObject(path, schema, obj)
+	}
+}
+
+func (c *checker) typeMismatch(path string, schema *Schema) {
+	c.fail(path, response.FieldInvalid, map[string]interface{}{"expected": schema.Type})
+}
+
+func (c *checker) checkString(path string, schema *Schema, str string) {
+	if len(schema.Enum) > 0 && !contains(schema.Enum, str) {
+		c.fail(path, response.FieldOneOf, map[string]interface{}{"allowed": strings.Join(schema.Enum, ", ")})
+		return
+	}
+	if schema.MaxLength != nil && len([]rune(str)) > *schema.MaxLength {
+		c.fail(path, response.FieldTooLong, map[string]interface{}{"max": *schema.MaxLength})
+		return
+	}
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			c.fail(path, response.FieldInvalidFormat, map[string]interface{}{"format": "RFC3339"})
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			c.fail(path, response.FieldInvalidFormat, map[string]interface{}{"format": "YYYY-MM-DD"})
+		}
+	}
+}
+
+func (c *checker) checkNumber(path string, schema *Schema, n float64) {
+	if (schema.Minimum != nil && n < *schema.Minimum) || (schema.Maximum != nil && n > *schema.Maximum) {
+		c.fail(path, response.FieldOutOfRange, map[string]interface{}{"min": bound(schema.Minimum), "max": bound(schema.Maximum)})
+	}
+}
+
+func bound(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func (c *checker) checkObject(path string, schema *Schema, obj map[string]interface{}) {
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			c.fail(path+"."+name, response.FieldRequired, nil)
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if field, ok := schema.Properties[key]; ok {
+			c.check(path+"."+key, field, obj[key])
+		} else if schema.AdditionalProperties != nil {
+			c.check(path+"."+key, schema.AdditionalProperties, obj[key])
+		} else if c.strict {
+			c.fail(path+"."+key, response.FieldUnsupported, nil)
+		}
+	}
+}
+
+// parseParam приводит строковое значение параметра пути или query к типу схемы.
+func parseParam(schema *Schema, raw string) (interface{}, bool) {
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		return float64(n), err == nil
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		return n, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	}
+	return raw, true
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
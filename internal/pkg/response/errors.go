@@ -0,0 +1,188 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader — id запроса: приходит от клиента или выдаётся сервером
+// (middleware.RequestID) и возвращается в заголовке и в теле ошибки.
+const RequestIDHeader = "X-Request-Id"
+
+// Общие коды ошибок; остальные — в каталоге errorTexts.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodeRequestTooLarge    = "request_too_large"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeUnprocessable      = "unprocessable"
+	CodeTooManyRequests    = "too_many_requests"
+	CodeInternal           = "internal_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// Коды ошибок в полях запроса (FieldError.Code).
+const (
+	FieldRequired      = "required"
+	FieldInvalid       = "invalid"
+	FieldInvalidFormat = "invalid_format" // params: format
+	FieldOneOf         = "one_of"         // params: allowed
+	FieldTooLong       = "too_long"       // params: max
+	FieldTooMany       = "too_many"       // params: max
+	FieldTooLarge      = "too_large"      // params: max
+	FieldOutOfRange    = "out_of_range"   // params: min, max
+	FieldUnsupported   = "unsupported"
+	FieldExclusive     = "exclusive" // params: with
+	FieldNotAfterStart = "not_after_start"
+	FieldInFuture      = "in_future"
+	FieldMaxDuration   = "max_duration" // params: hours
+	FieldOverlaps      = "overlaps"     // params: shift_id
+	FieldInvalidImage  = "invalid_image"
+	FieldEvenCount     = "even_count" // params: brand, date
+)
+
+// ErrorBody — тело ответа с ошибкой. Error — текст на языке клиента (поле
+// осталось от прежнего формата), Code — стабильный код, по которому клиент
+// решает, что показать.
+type ErrorBody struct {
+	Error     string       `json:"error"`
+	Code      string       `json:"code"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError — ошибка в конкретном поле запроса. Message заполняется при
+// ответе на языке клиента, Params — значения для клиента и для текста.
+type FieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// ValidationError — запрос не прошёл проверку; Fields — что не так по полям.
+// Err — признак для errors.Is (например, ErrInvalidReport сервиса), может быть nil.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// Invalid собирает ValidationError.
+func Invalid(err error, fields ...FieldError) *ValidationError {
+	return &ValidationError{Err: err, Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Code)
+	}
+	msg := "invalid request"
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", msg, strings.Join(parts, ", "))
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// RespondWithError отвечает ошибкой. Если message есть в каталоге, клиент
+// получает её код и текст на своём языке; иначе код берётся по статусу,
+// а текст отдаётся как есть. Текст ошибок 5xx наружу не уходит — подробности
+// остаются в логе.
+func RespondWithError(w http.ResponseWriter, status int, message string) {
+	if status >= http.StatusInternalServerError {
+		RespondWithCode(w, status, statusCode(status))
+		return
+	}
+	if code, ok := legacyCodes[message]; ok {
+		RespondWithCode(w, status, code)
+		return
+	}
+	writeError(w, status, ErrorBody{Error: message, Code: statusCode(status)})
+}
+
+// RespondWithCode отвечает ошибкой с кодом из каталога.
+func RespondWithCode(w http.ResponseWriter, status int, code string) {
+	writeError(w, status, NewErrorBody(w, code))
+}
+
+// NewErrorBody — тело ошибки с кодом из каталога для ответов с
+// дополнительными полями.
+func NewErrorBody(w http.ResponseWriter, code string) ErrorBody {
+	return ErrorBody{
+		Error:     Message(language(w), code),
+		Code:      code,
+		RequestID: w.Header().Get(RequestIDHeader),
+	}
+}
+
+// RespondWithValidation отвечает 400 с ошибками по полям.
+func RespondWithValidation(w http.ResponseWriter, fields ...FieldError) {
+	lang := language(w)
+	details := make([]FieldError, len(fields))
+	for i, f := range fields {
+		f.Message = fieldMessage(lang, f)
+		details[i] = f
+	}
+	body := NewErrorBody(w, CodeValidationFailed)
+	body.Details = details
+	writeError(w, http.StatusBadRequest, body)
+}
+
+// RespondWithBadRequest отвечает 400 на ошибку разбора или проверки запроса:
+// для ValidationError — с ошибками по полям, для текста из каталога — его
+// кодом, иначе общим bad_request: текст err клиенту не уходит.
+func RespondWithBadRequest(w http.ResponseWriter, err error) {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		RespondWithValidation(w, invalid.Fields...)
+		return
+	}
+	code, ok := legacyCodes[err.Error()]
+	if !ok {
+		code = CodeBadRequest
+	}
+	RespondWithCode(w, http.StatusBadRequest, code)
+}
+
+func writeError(w http.ResponseWriter, status int, body ErrorBody) {
+	body.RequestID = w.Header().Get(RequestIDHeader)
+	RespondWithJSON(w, status, body)
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,104 @@
+package response
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// serverErrorStatuses — статусы 5xx: их текст клиенту не уходит (RespondWithError
+// отвечает кодом по статусу), поэтому каталог для них не нужен.
+var serverErrorStatuses = map[string]bool{
+	"StatusInternalServerError":           true,
+	"StatusNotImplemented":                true,
+	"StatusBadGateway":                    true,
+	"StatusServiceUnavailable":            true,
+	"StatusGatewayTimeout":                true,
+	"StatusInsufficientStorage":           true,
+	"StatusNetworkAuthenticationRequired": true,
+}
+
+// TestRespondWithErrorMessagesAreCataloged проходит по исходникам и проверяет,
+// что каждый текст ошибки 4xx, переданный в RespondWithError, есть в каталоге:
+// иначе клиент получит общий код по статусу и непереведённый текст.
+// Текст не из литерала (err.Error()) проверить нельзя, и наружу он уходить не должен.
+func TestRespondWithErrorMessagesAreCataloged(t *testing.T) {
+	root := filepath.Join("..", "..", "..")
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); name != "." && name != ".." && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || calledName(call) != "RespondWithError" || len(call.Args) != 3 {
+				return true
+			}
+			if isServerError(call.Args[1]) {
+				return true
+			}
+			lit, ok := call.Args[2].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				t.Errorf("%s: 4xx message is not a literal, use RespondWithCode or RespondWithBadRequest", fset.Position(call.Pos()))
+				return true
+			}
+			message, _ := strconv.Unquote(lit.Value)
+			if _, ok := legacyCodes[message]; !ok {
+				t.Errorf("%s: message %q has no code in the catalog, use RespondWithCode", fset.Position(call.Pos()), message)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLegacyCodesHaveTexts — каждый код, на который ссылается legacyCodes, есть в каталоге.
+func TestLegacyCodesHaveTexts(t *testing.T) {
+	for message, code := range legacyCodes {
+		if _, ok := errorTexts[code]; !ok {
+			t.Errorf("message %q maps to code %q missing from errorTexts", message, code)
+		}
+	}
+}
+
+func calledName(call *ast.CallExpr) string {
+	switch fn := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		return fn.Sel.Name
+	case *ast.Ident:
+		return fn.Name
+	}
+	return ""
+}
+
+func isServerError(status ast.Expr) bool {
+	switch s := status.(type) {
+	case *ast.SelectorExpr:
+		return serverErrorStatuses[s.Sel.Name]
+	case *ast.BasicLit:
+		code, err := strconv.Atoi(s.Value)
+		return err == nil && code >= 500
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Языки сообщений об ошибках. Язык выбирается по Accept-Language
+// (middleware.Locale) и передаётся ответу в заголовке Content-Language.
+const (
+	LangRU = "ru"
+	LangKK = "kk"
+	LangEN = "en"
+
+	DefaultLanguage = LangRU
+)
+
+// Language выбирает язык ответа по заголовку Accept-Language с учётом q.
+// Казахский иногда приходит как kz.
+func Language(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		lang, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+		if lang == "kz" {
+			lang = LangKK
+		}
+		if (lang == LangRU || lang == LangKK || lang == LangEN) && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+func language(w http.ResponseWriter) string {
+	if lang := w.Header().Get("Content-Language"); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// text — сообщение на поддерживаемых языках.
+type text struct {
+	ru, kk, en string
+}
+
+func (t text) in(lang string) string {
+	switch lang {
+	case LangKK:
+		return t.kk
+	case LangEN:
+		return t.en
+	}
+	return t.ru
+}
+
+// Message — текст ошибки с кодом code на языке lang.
+func Message(lang, code string) string {
+	if t, ok := errorTexts[code]; ok {
+		return t.in(lang)
+	}
+	return errorTexts[CodeBadRequest].in(lang)
+}
+
+// fieldMessage — текст ошибки поля с подставленными Params; для неизвестного
+// кода остаётся Message, заданный при создании.
+func fieldMessage(lang string, f FieldError) string {
+	t, ok := fieldTexts[f.Code]
+	if !ok {
+		return f.Message
+	}
+	msg := t.in(lang)
+	for key, value := range f.Params {
+		msg = strings.ReplaceAll(msg, "{"+key+"}", fmt.Sprint(value))
+	}
+	return msg
+}
+
+// errorTexts — каталог кодов ошибок. Коды — часть API: клиенты на них
+// опираются, поэтому код не переименовывается, только добавляется новый.
+var errorTexts = map[string]text{
+	CodeBadRequest:         {"Некорректный запрос", "Сұрау қате", "Bad request"},
+	CodeValidationFailed:   {"Проверьте правильность заполнения полей", "Өрістердің дұрыс толтырылғанын тексеріңіз", "Some fields are invalid"},
+	CodeUnauthorized:       {"Требуется авторизация", "Авторизация қажет", "Authentication required"},
+	CodeForbidden:          {"Доступ запрещён", "Қолжетімділік жоқ", "Access denied"},
+	CodeNotFound:           {"Не найдено", "Табылмады", "Not found"},
+	CodeMethodNotAllowed:   {"Метод не поддерживается", "Әдіске қолдау көрсетілмейді", "Method not allowed"},
+	CodeConflict:           {"Действие недоступно в текущем состоянии", "Ағымдағы күйде әрекет қолжетімсіз", "Action is not available in the current state"},
+	CodeRequestTooLarge:    {"Запрос слишком большой", "Сұрау тым үлкен", "Request is too large"},
+	CodeUnsupportedMedia:   {"Неподдерживаемый формат запроса", "Сұрау пішіміне қолдау көрсетілмейді", "Unsupported request format"},
+	CodeUnprocessable:      {"Запрос не может быть выполнен", "Сұрауды орындау мүмкін емес", "Request can't be processed"},
+	CodeTooManyRequests:    {"Слишком много запросов, попробуйте позже", "Сұраулар тым көп, кейінірек қайталап көріңіз", "Too many requests, try again later"},
+	CodeInternal:           {"Внутренняя ошибка сервера, попробуйте позже", "Сервердің ішкі қатесі, кейінірек қайталап көріңіз", "Internal server error, try again later"},
+	CodeServiceUnavailable: {"Сервис временно недоступен, попробуйте позже", "Қызмет уақытша қолжетімсіз, кейінірек қайталап көріңіз", "Service temporarily unavailable, try again later"},
+
+	// Запрос
+	"invalid_json":   {"Неверный формат данных запроса", "Сұрау деректерінің пішімі қате", "Invalid request body"},
+	"missing_fields": {"Заполните обязательные поля", "Міндетті өрістерді толтырыңыз", "Required fields are missing"},
+	"invalid_id":     {"Неверный идентификатор", "Идентификатор қате", "Invalid identifier"},
+	"invalid_date":   {"Неверный формат даты", "Күн пішімі қате", "Invalid date format"},
+	"invalid_period": {"Неверный период", "Кезең қате", "Invalid period"},
+	"file_too_large": {"Файл слишком большой или повреждён", "Файл тым үлкен немесе бүлінген", "File is too large or malformed"},
+	"invalid_image":  {"Неверное изображение: поддерживаются JPEG и PNG", "Сурет қате: JPEG және PNG қолдау көрсетіледі", "Invalid image: JPEG and PNG are supported"},
+
+	// Авторизация
+	"invalid_token":              {"Сессия недействительна, войдите снова", "Сессия жарамсыз, қайта кіріңіз", "Invalid token, sign in again"},
+	"invalid_credentials":        {"Неверный логин или пароль", "Логин немесе құпиясөз қате", "Invalid username or password"},
+	"refresh_token_invalid":      {"Сессия истекла, войдите снова", "Сессия мерзімі өтті, қайта кіріңіз", "Session expired, sign in again"},
+	"session_revoked":            {"Сессия завершена, войдите снова", "Сессия аяқталды, қайта кіріңіз", "Session has been revoked, sign in again"},
+	"telegram_auth_failed":       {"Не удалось войти через Telegram", "Telegram арқылы кіру мүмкін болмады", "Telegram sign-in failed"},
+	"current_password_incorrect": {"Текущий пароль неверен", "Ағымдағы құпиясөз қате", "Current password is incorrect"},
+	"password_same":              {"Новый пароль должен отличаться от текущего", "Жаңа құпиясөз ағымдағыдан өзгеше болуы керек", "New password must differ from the current one"},
+	"password_too_short":         {"Пароль должен быть не короче 8 символов", "Құпиясөз кемінде 8 таңбадан тұруы керек", "Password must be at least 8 characters long"},
+	"password_too_long":          {"Пароль не должен быть длиннее 72 байт", "Құпиясөз 72 байттан аспауы керек", "Password must be at most 72 bytes long"},
+	"password_too_weak":          {"Пароль должен содержать буквы и цифры", "Құпиясөзде әріптер мен сандар болуы керек", "Password must contain both letters and digits"},
+	"password_change_required":   {"Сначала смените временный пароль", "Алдымен уақытша құпиясөзді ауыстырыңыз", "Change your temporary password first"},
+	"too_many_login_attempts":    {"Слишком много попыток входа, попробуйте позже", "Кіру әрекеттері тым көп, кейінірек қайталап көріңіз", "Too many login attempts, try again later"},
+	"code_requested_too_often":   {"Подождите, прежде чем запросить код снова", "Кодты қайта сұрамас бұрын күте тұрыңыз", "Please wait before requesting another code"},
+	"invalid_code":               {"Неверный или просроченный код", "Код қате немесе мерзімі өткен", "Invalid or expired code"},
+	"invalid_link":               {"Ссылка недействительна или устарела", "Сілтеме жарамсыз немесе ескірген", "Invalid or expired link"},
+	"account_blocked":            {"Аккаунт заблокирован", "Аккаунт бұғатталған", "Account is blocked"},
+	"account_deleted":            {"Аккаунт удалён", "Аккаунт жойылған", "Account has been deleted"},
+	"registration_rejected":      {"Регистрация отклонена", "Тіркеу қабылданбады", "Registration was rejected"},
+	"username_taken":             {"Такое имя пользователя уже занято", "Бұл пайдаланушы аты бос емес", "Username already exists"},
+
+	// Привязка Telegram
+	"telegram_linked_to_other":    {"Этот Telegram привязан к другому пользователю", "Бұл Telegram басқа пайдаланушыға байланған", "This Telegram account is linked to another user"},
+	"telegram_already_linked":     {"Уже привязан другой Telegram, сначала отвяжите его", "Басқа Telegram байланған, алдымен оны ажыратыңыз", "Another Telegram account is already linked, unlink it first"},
+	"telegram_not_linked":         {"Telegram не привязан", "Telegram байланбаған", "Telegram is not linked"},
+	"password_required_to_unlink": {"Задайте пароль, прежде чем отвязать Telegram", "Telegram-ды ажыратпас бұрын құпиясөз орнатыңыз", "Set a password before unlinking Telegram"},
+
+	// Пользователи
+	"user_not_pending":        {"Пользователь не ожидает подтверждения", "Пайдаланушы растауды күтпейді", "User is not awaiting approval"},
+	"user_already_deleted":    {"Пользователь уже удалён", "Пайдаланушы жойылып қойған", "User is already deleted"},
+	"user_not_deleted":        {"Пользователь не удалён", "Пайдаланушы жойылмаған", "User is not deleted"},
+	"user_anonymized":         {"Обезличенного пользователя нельзя восстановить", "Иесіздендірілген пайдаланушыны қалпына келтіруге болмайды", "Anonymized user can't be restored"},
+	"user_already_anonymized": {"Пользователь уже обезличен", "Пайдаланушы иесіздендіріліп қойған", "User is already anonymized"},
+	"user_merged":             {"Пользователь объединён с другим аккаунтом", "Пайдаланушы басқа аккаунтпен біріктірілген", "User was merged into another account"},
+	"merge_same_user":         {"Нельзя объединить пользователя с самим собой", "Пайдаланушыны өзімен біріктіруге болмайды", "Can't merge a user into itself"},
+	"merge_deleted_user":      {"Удалённых пользователей нельзя объединять", "Жойылған пайдаланушыларды біріктіруге болмайды", "Deleted users can't be merged"},
+	"merge_both_telegram":     {"У обоих пользователей привязан Telegram, сначала отвяжите один", "Екі пайдаланушыда да Telegram байланған, алдымен біреуін ажыратыңыз", "Both users have Telegram linked, unlink one first"},
+	"merge_active_shift":      {"У объединяемого пользователя есть активная смена", "Біріктірілетін пайдаланушының белсенді ауысымы бар", "Source user has an active shift"},
+	"role_protected":          {"Эту роль нельзя удалить", "Бұл рөлді жоюға болмайды", "This role can't be deleted"},
+
+	// Справочники
+	"user_not_found":    {"Пользователь не найден", "Пайдаланушы табылмады", "User not found"},
+	"zone_not_found":    {"Зона не найдена", "Аймақ табылмады", "Zone not found"},
+	"map_not_found":     {"Карта не найдена", "Карта табылмады", "Map not found"},
+	"task_not_found":    {"Задача не найдена", "Тапсырма табылмады", "Task not found"},
+	"version_not_found": {"Версия не найдена", "Нұсқа табылмады", "Version not found"},
+	"session_not_found": {"Сеанс не найден", "Сеанс табылмады", "Session not found"},
+	"file_not_found":    {"Файл не найден", "Файл табылмады", "File not found"},
+
+	// Смены
+	"shift_not_found":       {"Смена не найдена", "Ауысым табылмады", "Shift not found"},
+	"no_active_shift":       {"Нет активной смены", "Белсенді ауысым жоқ", "No active shift"},
+	"shift_already_active":  {"Смена уже начата", "Ауысым басталып қойған", "Shift has already started"},
+	"shift_start_window":    {"Смену можно начать только за 20 минут до её начала или в течение смены", "Ауысымды басталуына 20 минут қалғанда немесе ауысым кезінде ғана бастауға болады", "A shift can be started no earlier than 20 minutes before it begins or during the shift"},
+	"shift_still_active":    {"Смена ещё не закрыта, сначала завершите её", "Ауысым әлі жабылмаған, алдымен оны аяқтаңыз", "Shift is still active, end it first"},
+	"shift_voided":          {"Смена аннулирована", "Ауысымның күші жойылған", "Shift is voided"},
+	"zone_required":         {"Укажите зону: активной смены нет", "Аймақты көрсетіңіз: белсенді ауысым жоқ", "Zone is required when there is no active shift"},
+	"invalid_zone":          {"Неизвестная зона", "Белгісіз аймақ", "Unknown zone"},
+	"invalid_time_slot":     {"Неизвестный слот смены", "Белгісіз ауысым слоты", "Unknown time slot"},
+	"reason_required":       {"Укажите причину", "Себебін көрсетіңіз", "Reason is required"},
+	"break_already_started": {"Перерыв уже начат", "Үзіліс басталып қойған", "Break has already started"},
+	"no_active_break":       {"Нет активного перерыва", "Белсенді үзіліс жоқ", "No active break"},
+	"break_limit_reached":   {"Лимит перерывов для этой смены исчерпан", "Бұл ауысымдағы үзілістер лимиті таусылды", "Break limit for this shift is reached"},
+	"selfie_required":       {"Нужно селфи", "Селфи қажет", "Selfie is required"},
+	"selfie_face_mismatch":  {"Лицо на селфи не совпадает с профилем сотрудника", "Селфидегі бет қызметкер профиліне сәйкес келмейді", "The face on the selfie doesn't match the employee profile"},
+	"selfie_not_fresh":      {"Селфи нужно сделать непосредственно перед началом смены", "Селфиді ауысым басталар алдында ғана түсіру керек", "The selfie must be taken right before the shift starts"},
+	"selfie_no_timestamp":   {"Не удалось определить время съёмки селфи, сделайте фото камерой приложения", "Селфидің түсірілген уақытын анықтау мүмкін болмады, суретті қолданбаның камерасымен түсіріңіз", "Couldn't determine when the selfie was taken, use the app camera"},
+	"selfie_reused":         {"Это фото уже использовалось для другой смены", "Бұл сурет басқа ауысым үшін қолданылған", "This photo was already used for another shift"},
+	"not_enough_scouts":     {"Недостаточно доступных скаутов", "Қолжетімді скауттар жеткіліксіз", "Not enough available scouts"},
+	"nothing_to_change":     {"Нечего менять", "Өзгертетін ештеңе жоқ", "Nothing to change"},
+
+	// Табель
+	"timesheet_approved":     {"Табель за этот месяц утверждён: снимите утверждение, чтобы править смены", "Осы айдың табелі бекітілген: ауысымдарды түзету үшін бекітуді алып тастаңыз", "The timesheet for this month is approved: unapprove it to edit shifts"},
+	"timesheet_locked":       {"Табель закрыт после расчёта зарплаты", "Табель жалақы есептелгеннен кейін жабылды", "The timesheet is locked after payroll"},
+	"timesheet_not_approved": {"Табель не утверждён", "Табель бекітілмеген", "The timesheet is not approved"},
+	"month_not_finished":     {"Месяц ещё не закончился", "Ай әлі аяқталған жоқ", "The month is not over yet"},
+	"open_shifts_in_month":   {"В этом месяце есть незакрытые смены", "Бұл айда жабылмаған ауысымдар бар", "There are unfinished shifts this month"},
+
+	// Промокоды
+	"invalid_brand":     {"Недопустимый бренд", "Жарамсыз бренд", "Invalid brand"},
+	"promo_unavailable": {"Промокоды этого бренда временно недоступны", "Бұл брендтің промокодтары уақытша қолжетімсіз", "Promo codes of this brand are temporarily unavailable"},
+	"promo_sold_out":    {"Промокоды закончились", "Промокодтар таусылды", "No promo codes left"},
+	"invalid_excel":     {"Неверный формат Excel", "Excel пішімі қате", "Invalid Excel file"},
+	"promo_file_empty":  {"Файл должен содержать заголовок и хотя бы одну строку", "Файлда тақырып пен кемінде бір жол болуы керек", "The file must contain a header and at least one row"},
+
+	// Загрузки
+	"retention_disabled": {"Срок хранения загрузок не задан", "Жүктемелерді сақтау мерзімі белгіленбеген", "Upload retention is disabled"},
+
+	// Idempotency-Key
+	"idempotency_in_progress": {"Запрос ещё выполняется, дождитесь ответа", "Сұрау әлі орындалуда, жауапты күтіңіз", "Request with this Idempotency-Key is still in progress"},
+	"idempotency_retry":       {"Предыдущий запрос не выполнен, повторите его", "Алдыңғы сұрау орындалмады, қайталаңыз", "Previous request with this Idempotency-Key failed, try again"},
+}
+
+// legacyCodes — коды для прежних текстов RespondWithError, чтобы старые
+// вызовы отдавали код и перевод без переписывания.
+var legacyCodes = map[string]string{
+	"Invalid JSON":                             "invalid_json",
+	"Invalid JSON in request body":             "invalid_json",
+	"Invalid request":                          "invalid_json",
+	"Invalid request body":                     "invalid_json",
+	"Invalid request body JSON":                "invalid_json",
+	"Invalid request data":                     "invalid_json",
+	"Invalid report JSON":                      "invalid_json",
+	"Failed to parse form data":                "invalid_json",
+	"Неверный JSON":                            "invalid_json",
+	"Missing required fields":                  "missing_fields",
+	"Invalid user ID":                          "invalid_id",
+	"Invalid User ID":                          "invalid_id",
+	"Invalid user ID type":                     "invalid_id",
+	"Invalid zone ID":                          "invalid_id",
+	"Invalid map ID":                           "invalid_id",
+	"Invalid slot ID":                          "invalid_id",
+	"Invalid version ID":                       "invalid_id",
+	"Invalid task ID format":                   "invalid_id",
+	"Invalid date format, expected YYYY-MM-DD": "invalid_date",
+	"Invalid 'from' date, expected YYYY-MM-DD": "invalid_date",
+	"Invalid 'to' date, expected YYYY-MM-DD":   "invalid_date",
+	"Invalid 'from' timestamp (use RFC3339)":   "invalid_date",
+	"Invalid 'to' timestamp (use RFC3339)":     "invalid_date",
+	"Invalid period, use YYYY-MM":              "invalid_period",
+	"Invalid period: 'to' must be after 'from' and within 93 days": "invalid_period",
+	"'from' must be before 'to'":                                   "invalid_period",
+	"File too large or malformed":                                  "file_too_large",
+	"Request too large or invalid":                                 "file_too_large",
+	"Invalid image":                                                "invalid_image",
+	"Only JPEG and PNG images allowed":                             "invalid_image",
+
+	"User not authenticated":       CodeUnauthorized,
+	"User ID not found in context": CodeUnauthorized,
+	"User ID not found in token":   CodeUnauthorized,
+	"Unauthorized":                 CodeUnauthorized,
+	"Не авторизован":               CodeUnauthorized,
+	"Access denied":                CodeForbidden,
+	"Role not found":               CodeForbidden,
+	"Требуются права администратора": CodeForbidden,
+	"Method not allowed":                            CodeMethodNotAllowed,
+	"Invalid token":                                 "invalid_token",
+	"Invalid claims":                                "invalid_token",
+	"Invalid credentials":                           "invalid_credentials",
+	"Invalid or expired refresh token":              "refresh_token_invalid",
+	"Refresh token required":                        "refresh_token_invalid",
+	"Session has been revoked":                      "session_revoked",
+	"Telegram auth failed":                          "telegram_auth_failed",
+	"Telegram auth validation returned nil data":    "telegram_auth_failed",
+	"Current password is incorrect":                 "current_password_incorrect",
+	"New password must differ from the current one": "password_same",
+	"password must be at least 8 characters long":   "password_too_short",
+	"password must be at most 72 bytes long":        "password_too_long",
+	"password must contain both letters and digits": "password_too_weak",
+	"Password change required":                      "password_change_required",
+	"Too many login attempts, try again later":      "too_many_login_attempts",
+	"Please wait before requesting another code":    "code_requested_too_often",
+	"Invalid or expired code":                       "invalid_code",
+	"Invalid or expired link":                       "invalid_link",
+	"Account is blocked":                            "account_blocked",
+	"Account has been deleted":                      "account_deleted",
+	"Username already exists":                       "username_taken",
+
+	"User not found":    "user_not_found",
+	"Zone not found":    "zone_not_found",
+	"Map not found":     "map_not_found",
+	"Task not found":    "task_not_found",
+	"Version not found": "version_not_found",
+	"Session not found": "session_not_found",
+	"File not found":    "file_not_found",
+	"Файл не найден":    "file_not_found",
+
+	"Slot not found":                                "shift_not_found",
+	"No active slot found":                          "no_active_shift",
+	"No active slot found for the user":             "no_active_shift",
+	"Slot already active":                           "shift_already_active",
+	"Slot is still active, end it first":            "shift_still_active",
+	"Slot is voided":                                "shift_voided",
+	"Zone is required when there is no active slot": "zone_required",
+	"Invalid zone":                                  "invalid_zone",
+	"Invalid time slot":                             "invalid_time_slot",
+	"Invalid slot time range":                       "invalid_time_slot",
+	"Reason is required":                            "reason_required",
+	"Selfie image is required":                      "selfie_required",
+	"Смену можно начать только за 20 минут до её начала или в течение смены": "shift_start_window",
+	"Перерыв уже начат":                                                          "break_already_started",
+	"Нет активного перерыва":                                                     "no_active_break",
+	"Лимит перерывов для этой смены исчерпан":                                    "break_limit_reached",
+	"Лицо на селфи не совпадает с профилем сотрудника":                           "selfie_face_mismatch",
+	"Селфи нужно сделать непосредственно перед началом смены":                    "selfie_not_fresh",
+	"Не удалось определить время съёмки селфи, сделайте фото камерой приложения": "selfie_no_timestamp",
+	"Это фото уже использовалось для другой смены":                               "selfie_reused",
+	"Недостаточно доступных скаутов":                                             "not_enough_scouts",
+
+	"Табель за этот месяц утверждён: снимите утверждение, чтобы править смены": "timesheet_approved",
+	"Табель закрыт после расчёта зарплаты":                                     "timesheet_locked",
+	"Табель не утверждён":                 "timesheet_not_approved",
+	"Месяц ещё не закончился":             "month_not_finished",
+	"В этом месяце есть незакрытые смены": "open_shifts_in_month",
+
+	"Недопустимый бренд":                         "invalid_brand",
+	"Промокоды этого бренда временно недоступны": "promo_unavailable",
+
+	"Request with this Idempotency-Key is still in progress":       "idempotency_in_progress",
+	"Previous request with this Idempotency-Key failed, try again": "idempotency_retry",
+}
+
+// fieldTexts — тексты ошибок полей; {имя} заменяется значением из Params.
+var fieldTexts = map[string]text{
+	FieldRequired:      {"Обязательное поле", "Міндетті өріс", "This field is required"},
+	FieldInvalid:       {"Недопустимое значение", "Жарамсыз мән", "Invalid value"},
+	FieldInvalidFormat: {"Неверный формат, ожидается {format}", "Пішімі қате, күтілетіні: {format}", "Invalid format, expected {format}"},
+	FieldOneOf:         {"Допустимые значения: {allowed}", "Рұқсат етілген мәндер: {allowed}", "Must be one of: {allowed}"},
+	FieldTooLong:       {"Не длиннее {max} символов", "Ең көбі {max} таңба", "Must be at most {max} characters"},
+	FieldTooMany:       {"Не больше {max}", "Ең көбі {max}", "At most {max} allowed"},
+	FieldTooLarge:      {"Файл больше {max}", "Файл {max} көлемінен үлкен", "File is larger than {max}"},
+	FieldOutOfRange:    {"Значение должно быть от {min} до {max}", "Мән {min} мен {max} аралығында болуы керек", "Must be between {min} and {max}"},
+	FieldUnsupported:   {"Здесь не поддерживается", "Мұнда қолдау көрсетілмейді", "Not supported here"},
+	FieldExclusive:     {"Нельзя указывать вместе с {with}", "{with} параметрімен бірге көрсетуге болмайды", "Can't be used together with {with}"},
+	FieldNotAfterStart: {"Должно быть позже начала смены", "Ауысым басталғаннан кейін болуы керек", "Must be after the shift start"},
+	FieldInFuture:      {"Не может быть в будущем", "Болашақта болуы мүмкін емес", "Can't be in the future"},
+	FieldMaxDuration:   {"Смена не может быть длиннее {hours} ч", "Ауысым {hours} сағаттан ұзақ бола алмайды", "Shift can't be longer than {hours} hours"},
+	FieldOverlaps:      {"Пересекается со сменой {shift_id}", "{shift_id} ауысымымен уақыты қиылысады", "Overlaps shift {shift_id}"},
+	FieldInvalidImage:  {"Нужно изображение JPEG или PNG", "JPEG немесе PNG суреті қажет", "Must be a JPEG or PNG image"},
+	FieldEvenCount:     {"У {brand} на {date} должно быть чётное количество промокодов", "{brand} үшін {date} күніне промокодтар саны жұп болуы керек", "{brand} needs an even number of promo codes for {date}"},
+}
@@ -0,0 +1,20 @@
+package response
+
+import "net/http"
+
+// Page — единый конверт постраничного списка. NextCursor передаётся в
+// ?cursor= для следующей страницы; пуст, если страница последняя.
+type Page struct {
+	Items      interface{} `json:"items"`
+	Total      int         `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// RespondWithPage отдаёт страницу списка с элементами items.
+func RespondWithPage(w http.ResponseWriter, page Page, items interface{}) {
+	page.Items = items
+	RespondWithJSON(w, http.StatusOK, page)
+}
@@ -1,10 +1,13 @@
 package repositories
 
 import (
+    "context"
     "database/sql"
     "fmt"
     // "time"
     "github.com/evn/eom_backendl/internal/models"
+    "github.com/evn/eom_backendl/internal/pkg/listing"
+    "github.com/evn/eom_backendl/internal/pkg/response"
 )
 
 type AppVersionRepository struct {
@@ -50,6 +53,36 @@ func (r *AppVersionRepository) GetLatestVersion(platform string) (*models.AppVer
     
     return &version, nil
 }
+// GetVersionByID получает версию по ID
+func (r *AppVersionRepository) GetVersionByID(id int) (*models.AppVersion, error) {
+    query := `
+        SELECT id, platform, version, build_number, release_notes, download_url, 
+               min_sdk_version, is_mandatory, is_active, created_at, updated_at
+        FROM app_versions 
+        WHERE id = $1
+    `
+    
+    var version models.AppVersion
+    err := r.DB.QueryRow(query, id).Scan(
+        &version.ID,
+        &version.Platform,
+        &version.Version,
+        &version.BuildNumber,
+        &version.ReleaseNotes,
+        &version.DownloadURL,
+        &version.MinSDKVersion,
+        &version.IsMandatory,
+        &version.IsActive,
+        &version.CreatedAt,
+        &version.UpdatedAt,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to get version %d: %w", id, err)
+    }
+    
+    return &version, nil
+}
+
 // CheckVersion проверяет, доступна ли новая версия
 func (r *AppVersionRepository) CheckVersion(platform, currentVersion string, buildNumber int) (*models.VersionCheckResponse, error) {
     latestVersion, err := r.GetLatestVersion(platform)
@@ -82,25 +115,52 @@ func (r *AppVersionRepository) CheckVersion(platform, currentVersion string, bui
     
     return response, nil
 }
-// GetAllVersions получает все версии для платформы
-func (r *AppVersionRepository) GetAllVersions(platform string) ([]models.AppVersion, error) {
-    query := `
-        SELECT id, platform, version, build_number, release_notes, download_url, 
-               min_sdk_version, is_mandatory, is_active, created_at, updated_at
-        FROM app_versions 
-        WHERE platform = $1 
-        ORDER BY build_number DESC
-    `
-    
-    rows, err := r.DB.Query(query, platform)
+// AppVersionsSpec — фильтры и сортировки списка версий; from/to — по дате
+// создания, status — active или inactive.
+var AppVersionsSpec = listing.Spec{
+    Sorts: map[string]string{
+        "created_at":   "COALESCE(created_at, to_timestamp(0))",
+        "build_number": "build_number",
+        "platform":     "platform",
+    },
+    DefaultSort: "-build_number",
+    IDColumn:    "id",
+    Filters:     []string{listing.FilterDate, listing.FilterStatus},
+    Columns: listing.Columns{
+        Date:   "created_at",
+        Status: "CASE WHEN COALESCE(is_active, FALSE) THEN 'active' ELSE 'inactive' END",
+    },
+}
+
+// ListVersions — страница версий; platform "" — все платформы.
+func (r *AppVersionRepository) ListVersions(ctx context.Context, platform string, p listing.Params) ([]models.AppVersion, response.Page, error) {
+    q := listing.NewQuery(AppVersionsSpec, p)
+    if platform != "" {
+        q.Where("platform = $%d", platform)
+    }
+
+    var total int
+    where, args := q.Count()
+    if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM app_versions"+where, args...).Scan(&total); err != nil {
+        return nil, response.Page{}, fmt.Errorf("failed to count versions: %w", err)
+    }
+
+    page, args := q.Page()
+    rows, err := r.DB.QueryContext(ctx, `
+        SELECT id, platform, version, build_number, COALESCE(release_notes, ''), download_url,
+               COALESCE(min_sdk_version, 0), COALESCE(is_mandatory, FALSE), COALESCE(is_active, FALSE),
+               created_at, updated_at, `+q.SortValue()+`
+        FROM app_versions`+page, args...)
     if err != nil {
-        return nil, fmt.Errorf("failed to query versions: %w", err)
+        return nil, response.Page{}, fmt.Errorf("failed to query versions: %w", err)
     }
     defer rows.Close()
-    
-    var versions []models.AppVersion
+
+    versions := []models.AppVersion{}
+    var keys []listing.Key
     for rows.Next() {
         var version models.AppVersion
+        var key listing.Key
         err := rows.Scan(
             &version.ID,
             &version.Platform,
@@ -113,14 +173,21 @@ func (r *AppVersionRepository) GetAllVersions(platform string) ([]models.AppVers
             &version.IsActive,
             &version.CreatedAt,
             &version.UpdatedAt,
+            &key.Value,
         )
         if err != nil {
-            return nil, fmt.Errorf("failed to scan version: %w", err)
+            return nil, response.Page{}, fmt.Errorf("failed to scan version: %w", err)
         }
+        key.ID = version.ID
+        keys = append(keys, key)
         versions = append(versions, version)
     }
-    
-    return versions, nil
+    if err := rows.Err(); err != nil {
+        return nil, response.Page{}, fmt.Errorf("failed to read versions: %w", err)
+    }
+
+    n, result := q.Result(keys, total)
+    return versions[:n], result, nil
 }
 
 // CreateVersion создает новую версию
@@ -0,0 +1,89 @@
+// repositories/assignment_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+// AssignmentRepository — плановые выходы (таблица shift_assignments).
+// Даты передаются строками 2006-01-02 и сравниваются как DATE.
+type AssignmentRepository interface {
+	// Create возвращает ErrConflict, если у сотрудника уже есть выход в этот слот в этот день.
+	Create(ctx context.Context, a *models.ShiftAssignment, createdBy int) error
+	// AttachShift отмечает выход отработанным. Выхода в плане нет — не ошибка.
+	AttachShift(ctx context.Context, userID int, date, slotTimeRange string, shiftID int) error
+	// DetachShift снова делает выход неотработанным (смену аннулировали).
+	DetachShift(ctx context.Context, shiftID int) error
+	ListBetween(ctx context.Context, from, to string) ([]models.ShiftAssignment, error)
+}
+
+type assignmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepository(db *sql.DB) AssignmentRepository {
+	return &assignmentRepository{db: db}
+}
+
+func (r *assignmentRepository) Create(ctx context.Context, a *models.ShiftAssignment, createdBy int) error {
+	// ON CONFLICT вместо ошибки: ошибка оборвала бы транзакцию, а дубли в
+	// пакетном планировании просто пропускаются
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO shift_assignments (user_id, shift_date, slot_time_range, zone, created_by)
+		VALUES ($1, $2::date, $3, $4, $5)
+		ON CONFLICT (user_id, shift_date, slot_time_range) DO NOTHING
+		RETURNING id`,
+		a.UserID, a.ShiftDate, a.SlotTimeRange, a.Zone, createdBy,
+	).Scan(&a.ID)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *assignmentRepository) AttachShift(ctx context.Context, userID int, date, slotTimeRange string, shiftID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE shift_assignments SET slot_id = $1
+		WHERE user_id = $2 AND shift_date = $3::date AND slot_time_range = $4 AND slot_id IS NULL`,
+		shiftID, userID, date, slotTimeRange,
+	)
+	return err
+}
+
+func (r *assignmentRepository) DetachShift(ctx context.Context, shiftID int) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE shift_assignments SET slot_id = NULL WHERE slot_id = $1", shiftID)
+	return err
+}
+
+// ListBetween — выходы с from по to включительно.
+func (r *assignmentRepository) ListBetween(ctx context.Context, from, to string) ([]models.ShiftAssignment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT a.id, a.user_id, u.username, to_char(a.shift_date, 'YYYY-MM-DD'), a.slot_time_range, a.zone, a.slot_id
+		FROM shift_assignments a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.shift_date BETWEEN $1::date AND $2::date
+		ORDER BY a.shift_date, a.slot_time_range, u.username`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []models.ShiftAssignment
+	for rows.Next() {
+		var a models.ShiftAssignment
+		var shiftID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.ShiftDate, &a.SlotTimeRange, &a.Zone, &shiftID); err != nil {
+			return nil, err
+		}
+		if shiftID.Valid {
+			id := int(shiftID.Int64)
+			a.ShiftID = &id
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
@@ -0,0 +1,119 @@
+// repositories/break_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/lib/pq"
+)
+
+// BreakRepository — перерывы внутри смен (таблица slot_breaks).
+// Изменения делаются под блокировкой смены (ShiftRepository.LockActive*).
+type BreakRepository interface {
+	// Create возвращает ErrConflict, если у смены уже есть незавершённый перерыв.
+	Create(ctx context.Context, b *models.ShiftBreak) error
+	GetOpen(ctx context.Context, shiftID int) (*models.ShiftBreak, error)
+	Finish(ctx context.Context, breakID int, endedAt time.Time, autoClosed bool) error
+	ListByShift(ctx context.Context, shiftID int) ([]models.ShiftBreak, error)
+	ListByShifts(ctx context.Context, shiftIDs []int) (map[int][]models.ShiftBreak, error)
+}
+
+type breakRepository struct {
+	db *sql.DB
+}
+
+func NewBreakRepository(db *sql.DB) BreakRepository {
+	return &breakRepository{db: db}
+}
+
+const breakColumns = `id, slot_id, started_at, ended_at, auto_closed`
+
+func scanBreak(row interface{ Scan(...interface{}) error }) (*models.ShiftBreak, error) {
+	var b models.ShiftBreak
+	var endedAt sql.NullTime
+	if err := row.Scan(&b.ID, &b.ShiftID, &b.StartedAt, &endedAt, &b.AutoClosed); err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		b.EndedAt = &endedAt.Time
+	}
+	return &b, nil
+}
+
+func (r *breakRepository) Create(ctx context.Context, b *models.ShiftBreak) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO slot_breaks (slot_id, started_at)
+		VALUES ($1, $2)
+		RETURNING id`,
+		b.ShiftID, b.StartedAt,
+	).Scan(&b.ID)
+	if isUniqueViolation(err, "idx_slot_breaks_one_open") {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *breakRepository) GetOpen(ctx context.Context, shiftID int) (*models.ShiftBreak, error) {
+	b, err := scanBreak(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+breakColumns+`
+		FROM slot_breaks
+		WHERE slot_id = $1 AND ended_at IS NULL`, shiftID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return b, err
+}
+
+// Finish закрывает перерыв, если он ещё открыт.
+func (r *breakRepository) Finish(ctx context.Context, breakID int, endedAt time.Time, autoClosed bool) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE slot_breaks SET ended_at = $1, auto_closed = $2
+		WHERE id = $3 AND ended_at IS NULL`,
+		endedAt, autoClosed, breakID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *breakRepository) ListByShift(ctx context.Context, shiftID int) ([]models.ShiftBreak, error) {
+	byShift, err := r.ListByShifts(ctx, []int{shiftID})
+	if err != nil {
+		return nil, err
+	}
+	return byShift[shiftID], nil
+}
+
+func (r *breakRepository) ListByShifts(ctx context.Context, shiftIDs []int) (map[int][]models.ShiftBreak, error) {
+	byShift := make(map[int][]models.ShiftBreak)
+	if len(shiftIDs) == 0 {
+		return byShift, nil
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+breakColumns+`
+		FROM slot_breaks
+		WHERE slot_id = ANY($1)
+		ORDER BY started_at`, pq.Array(shiftIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b, err := scanBreak(rows)
+		if err != nil {
+			return nil, err
+		}
+		byShift[b.ShiftID] = append(byShift[b.ShiftID], *b)
+	}
+	return byShift, rows.Err()
+}
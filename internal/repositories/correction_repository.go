@@ -0,0 +1,77 @@
+// repositories/correction_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+// CorrectionRepository — история правок смен администраторами (таблица shift_corrections).
+type CorrectionRepository interface {
+	Create(ctx context.Context, c *models.ShiftCorrection) error
+	// ListByShift — правки смены от первой к последней.
+	ListByShift(ctx context.Context, shiftID int) ([]models.ShiftCorrection, error)
+}
+
+type correctionRepository struct {
+	db *sql.DB
+}
+
+func NewCorrectionRepository(db *sql.DB) CorrectionRepository {
+	return &correctionRepository{db: db}
+}
+
+func (r *correctionRepository) Create(ctx context.Context, c *models.ShiftCorrection) error {
+	before, err := json.Marshal(c.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(c.After)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO shift_corrections (slot_id, action, reason, comment, before, after, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		c.ShiftID, c.Action, c.Reason, c.Comment, string(before), string(after), c.CreatedBy,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *correctionRepository) ListByShift(ctx context.Context, shiftID int) ([]models.ShiftCorrection, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, slot_id, action, reason, comment, before, after, created_by, created_at
+		FROM shift_corrections
+		WHERE slot_id = $1
+		ORDER BY id`, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var corrections []models.ShiftCorrection
+	for rows.Next() {
+		var c models.ShiftCorrection
+		var before, after []byte
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.ShiftID, &c.Action, &c.Reason, &c.Comment, &before, &after, &createdBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(before, &c.Before); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(after, &c.After); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			id := int(createdBy.Int64)
+			c.CreatedBy = &id
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections, rows.Err()
+}
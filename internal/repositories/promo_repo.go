@@ -6,6 +6,9 @@ import (
 	"fmt"
 )
 
+// ErrNoPromoCodes — свободных промокодов бренда не осталось.
+var ErrNoPromoCodes = errors.New("no promo codes available")
+
 type PromoRepository struct {
 	db *sql.DB
 }
@@ -36,7 +39,7 @@ func (r *PromoRepository) ClaimSinglePromoForUser(brand string, userID int) ([]s
 	err := r.db.QueryRow(query, userID, brand).Scan(&code)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("нет доступных промокодов для %s", brand)
+			return nil, fmt.Errorf("%w: %s", ErrNoPromoCodes, brand)
 		}
 		return nil, fmt.Errorf("ошибка выдачи промокода: %w", err)
 	}
@@ -68,7 +71,7 @@ func (r *PromoRepository) ClaimYandexPairForUser(userID int) ([]string, error) {
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("нет доступных пар промокодов YANDEX")
+			return nil, fmt.Errorf("%w: YANDEX pair", ErrNoPromoCodes)
 		}
 		return nil, fmt.Errorf("ошибка поиска даты для YANDEX: %w", err)
 	}
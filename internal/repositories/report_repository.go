@@ -0,0 +1,145 @@
+// repositories/report_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+// ReportRepository — отчёты о закрытых сменах (таблица shift_reports).
+type ReportRepository interface {
+	Create(ctx context.Context, report *models.ShiftReport) error
+	// LatestInZone — последняя смена в зоне с отчётом, закрытая не раньше since.
+	// ErrNotFound, если такой нет.
+	LatestInZone(ctx context.Context, zone string, since time.Time) (*models.ShiftWithReport, error)
+	// ListEnded — закрытые смены, начатые в [from, to), с отчётами; zone "" — все зоны.
+	// Аннулированные смены не попадают ни сюда, ни в LatestInZone.
+	ListEnded(ctx context.Context, zone string, from, to time.Time) ([]models.ShiftWithReport, error)
+	// ClearPhotosBefore убирает фото из отчётов, созданных раньше before.
+	ClearPhotosBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+const reportColumns = `r.slot_id, r.scooters, r.issues, r.notes, r.photos, r.created_at`
+
+// withExtra дочитывает колонки после shiftColumns, не меняя scanShift.
+type withExtra struct {
+	row   interface{ Scan(...interface{}) error }
+	extra []interface{}
+}
+
+func (w withExtra) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.extra...)...)
+}
+
+// scanShiftWithReport читает shiftColumns и reportColumns (LEFT JOIN: отчёта может не быть).
+func scanShiftWithReport(row interface{ Scan(...interface{}) error }) (*models.ShiftWithReport, error) {
+	var reportShiftID sql.NullInt64
+	var scooters, issues, photos []byte
+	var notes sql.NullString
+	var createdAt sql.NullTime
+	shift, err := scanShift(withExtra{row, []interface{}{&reportShiftID, &scooters, &issues, &notes, &photos, &createdAt}})
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.ShiftWithReport{Shift: *shift}
+	if !reportShiftID.Valid {
+		return item, nil
+	}
+	report := &models.ShiftReport{ShiftID: int(reportShiftID.Int64), Notes: notes.String, CreatedAt: createdAt.Time}
+	if err := json.Unmarshal(scooters, &report.Scooters); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(issues, &report.Issues); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(photos, &report.Photos); err != nil {
+		return nil, err
+	}
+	item.Report = report
+	return item, nil
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *models.ShiftReport) error {
+	scooters, err := json.Marshal(report.Scooters)
+	if err != nil {
+		return err
+	}
+	issues, err := json.Marshal(report.Issues)
+	if err != nil {
+		return err
+	}
+	photos, err := json.Marshal(report.Photos)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO shift_reports (slot_id, scooters, issues, notes, photos)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`,
+		report.ShiftID, string(scooters), string(issues), report.Notes, string(photos),
+	).Scan(&report.CreatedAt)
+}
+
+func (r *reportRepository) LatestInZone(ctx context.Context, zone string, since time.Time) (*models.ShiftWithReport, error) {
+	item, err := scanShiftWithReport(conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT `+shiftColumns+`, `+reportColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		JOIN shift_reports r ON r.slot_id = s.id
+		WHERE s.zone = $1 AND s.end_time >= $2 AND s.voided_at IS NULL
+		ORDER BY s.end_time DESC
+		LIMIT 1`, zone, since))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return item, err
+}
+
+func (r *reportRepository) ListEnded(ctx context.Context, zone string, from, to time.Time) ([]models.ShiftWithReport, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+shiftColumns+`, `+reportColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		LEFT JOIN shift_reports r ON r.slot_id = s.id
+		WHERE s.end_time IS NOT NULL AND s.voided_at IS NULL AND s.start_time >= $1 AND s.start_time < $2
+		  AND ($3 = '' OR s.zone = $3)
+		ORDER BY s.zone, s.start_time`, from, to, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.ShiftWithReport
+	for rows.Next() {
+		item, err := scanShiftWithReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (r *reportRepository) ClearPhotosBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE shift_reports SET photos = '[]'
+		WHERE created_at < $1 AND photos <> '[]'::jsonb`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
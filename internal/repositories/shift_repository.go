@@ -0,0 +1,387 @@
+// repositories/shift_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+)
+
+// ShiftRepository — смены (таблица slots) и справочник временных слотов.
+// Методы Lock* берут блокировку строк и имеют смысл только внутри Transactor.InTx.
+type ShiftRepository interface {
+	Create(ctx context.Context, shift *models.Shift) error
+	GetByID(ctx context.Context, id int) (*models.Shift, error)
+	GetActiveByUser(ctx context.Context, userID int) (*models.Shift, error)
+	LockActiveByUser(ctx context.Context, userID int) (*models.Shift, error)
+	LockActive(ctx context.Context) ([]models.Shift, error)
+	LockByID(ctx context.Context, id int) (*models.Shift, error)
+	// Finish сохраняет конец смены, отработанное время, ранний уход и EndReason.
+	Finish(ctx context.Context, shift *models.Shift) error
+	// Correct сохраняет правку администратора: время, зону, слот, пересчитанные
+	// длительности и VoidedAt; отмечает смену исправленной.
+	Correct(ctx context.Context, shift *models.Shift) error
+	// OverlappingShift — id другой неаннулированной смены пользователя, время
+	// которой пересекается с [start, end); 0, если такой нет. Открытая смена
+	// считается длящейся до сих пор.
+	OverlappingShift(ctx context.Context, userID, excludeID int, start, end time.Time) (int, error)
+	ListActive(ctx context.Context) ([]models.Shift, error)
+	// ListEndedByUser и ListEndedBetween не возвращают аннулированные смены.
+	// ListEndedByUser — страница истории, параметры по ShiftHistorySpec.
+	ListEndedByUser(ctx context.Context, userID int, p listing.Params) ([]models.Shift, response.Page, error)
+	ListEndedBetween(ctx context.Context, from, to time.Time) ([]models.Shift, error)
+	ListTimeSlots(ctx context.Context) ([]string, error)
+	TimeSlotExists(ctx context.Context, slotTimeRange string) (bool, error)
+	GetBreakPolicy(ctx context.Context, slotTimeRange string) (*models.BreakPolicy, error)
+	ListBreakPolicies(ctx context.Context) (map[string]models.BreakPolicy, error)
+	ListRecentSelfieHashes(ctx context.Context, since time.Time) ([]models.SelfieHash, error)
+	ClearSelfiesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type shiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
+
+const shiftColumns = `s.id, s.user_id, u.username, s.start_time, s.end_time, s.slot_time_range,
+	s.position, s.zone, s.selfie_path, s.selfie_thumb_path, s.worked_duration, s.break_duration,
+	s.late_minutes, s.early_leave_minutes, COALESCE(s.end_reason, ''), s.corrected_at, s.voided_at`
+
+func scanShift(row interface{ Scan(...interface{}) error }) (*models.Shift, error) {
+	var shift models.Shift
+	var endTime sql.NullTime
+	var selfiePath, selfieThumb sql.NullString
+	var workedDuration, breakDuration sql.NullInt64
+	var correctedAt, voidedAt sql.NullTime
+	err := row.Scan(&shift.ID, &shift.UserID, &shift.Username, &shift.StartTime, &endTime,
+		&shift.SlotTimeRange, &shift.Position, &shift.Zone, &selfiePath, &selfieThumb, &workedDuration, &breakDuration,
+		&shift.LateMinutes, &shift.EarlyLeaveMinutes, &shift.EndReason, &correctedAt, &voidedAt)
+	if err != nil {
+		return nil, err
+	}
+	if endTime.Valid {
+		shift.EndTime = &endTime.Time
+	}
+	if correctedAt.Valid {
+		shift.CorrectedAt = &correctedAt.Time
+	}
+	if voidedAt.Valid {
+		shift.VoidedAt = &voidedAt.Time
+	}
+	shift.SelfiePath = selfiePath.String
+	shift.SelfieThumb = selfieThumb.String
+	shift.WorkedDuration = int(workedDuration.Int64)
+	shift.BreakDuration = int(breakDuration.Int64)
+	return &shift, nil
+}
+
+func (r *shiftRepository) queryShifts(ctx context.Context, query string, args ...interface{}) ([]models.Shift, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shifts []models.Shift
+	for rows.Next() {
+		shift, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *shift)
+	}
+	return shifts, rows.Err()
+}
+
+func (r *shiftRepository) queryShift(ctx context.Context, query string, args ...interface{}) (*models.Shift, error) {
+	shift, err := scanShift(conn(ctx, r.db).QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return shift, err
+}
+
+// Create добавляет смену. Если у пользователя уже есть открытая смена,
+// возвращает ErrConflict (уникальный индекс idx_slots_one_open_per_user).
+func (r *shiftRepository) Create(ctx context.Context, shift *models.Shift) error {
+	verification, err := json.Marshal(shift.Selfie.Verification)
+	if err != nil {
+		return err
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO slots (user_id, start_time, slot_time_range, position, zone, selfie_path, selfie_thumb_path,
+			selfie_taken_at, selfie_lat, selfie_lon, selfie_phash, selfie_verification, late_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, NULLIF($12, 'null')::jsonb, $13)
+		RETURNING id`,
+		shift.UserID, shift.StartTime, shift.SlotTimeRange, shift.Position, shift.Zone, shift.SelfiePath, shift.SelfieThumb,
+		shift.Selfie.TakenAt, shift.Selfie.Latitude, shift.Selfie.Longitude, shift.Selfie.PHash, string(verification),
+		shift.LateMinutes,
+	).Scan(&shift.ID)
+	if isUniqueViolation(err, "idx_slots_one_open_per_user") {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *shiftRepository) GetByID(ctx context.Context, id int) (*models.Shift, error) {
+	return r.queryShift(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = $1`, id)
+}
+
+func (r *shiftRepository) GetActiveByUser(ctx context.Context, userID int) (*models.Shift, error) {
+	return r.queryShift(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.user_id = $1 AND s.end_time IS NULL`, userID)
+}
+
+func (r *shiftRepository) LockActiveByUser(ctx context.Context, userID int) (*models.Shift, error) {
+	return r.queryShift(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.user_id = $1 AND s.end_time IS NULL
+		FOR UPDATE OF s`, userID)
+}
+
+// LockActive блокирует все открытые смены. Смены, уже заблокированные
+// другим запросом (например, их прямо сейчас закрывает сотрудник), пропускаются.
+func (r *shiftRepository) LockActive(ctx context.Context) ([]models.Shift, error) {
+	return r.queryShifts(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.end_time IS NULL
+		FOR UPDATE OF s SKIP LOCKED`)
+}
+
+func (r *shiftRepository) LockByID(ctx context.Context, id int) (*models.Shift, error) {
+	return r.queryShift(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = $1
+		FOR UPDATE OF s`, id)
+}
+
+func (r *shiftRepository) OverlappingShift(ctx context.Context, userID, excludeID int, start, end time.Time) (int, error) {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id FROM slots
+		WHERE user_id = $1 AND id <> $2 AND voided_at IS NULL
+		  AND start_time < $4 AND COALESCE(end_time, 'infinity') > $3
+		ORDER BY start_time
+		LIMIT 1`,
+		userID, excludeID, start, end,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Finish закрывает смену, если она ещё открыта.
+func (r *shiftRepository) Finish(ctx context.Context, shift *models.Shift) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE slots SET end_time = $1, worked_duration = $2, break_duration = $3,
+			early_leave_minutes = $4, end_reason = $5
+		WHERE id = $6 AND end_time IS NULL`,
+		shift.EndTime, shift.WorkedDuration, shift.BreakDuration, shift.EarlyLeaveMinutes, shift.EndReason, shift.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *shiftRepository) Correct(ctx context.Context, shift *models.Shift) error {
+	return conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE slots SET start_time = $1, end_time = $2, zone = $3, slot_time_range = $4,
+			worked_duration = $5, break_duration = $6, late_minutes = $7, early_leave_minutes = $8,
+			voided_at = $9, corrected_at = NOW()
+		WHERE id = $10
+		RETURNING corrected_at`,
+		shift.StartTime, shift.EndTime, shift.Zone, shift.SlotTimeRange,
+		shift.WorkedDuration, shift.BreakDuration, shift.LateMinutes, shift.EarlyLeaveMinutes,
+		shift.VoidedAt, shift.ID,
+	).Scan(&shift.CorrectedAt)
+}
+
+func (r *shiftRepository) ListActive(ctx context.Context) ([]models.Shift, error) {
+	return r.queryShifts(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.end_time IS NULL`)
+}
+
+// ShiftHistorySpec — фильтры и сортировки истории смен сотрудника; from/to — по началу смены.
+var ShiftHistorySpec = listing.Spec{
+	Sorts: map[string]string{
+		"start_time": "s.start_time",
+		"end_time":   "s.end_time",
+		"worked":     "COALESCE(s.worked_duration, 0)",
+	},
+	DefaultSort: "-start_time",
+	IDColumn:    "s.id",
+	Filters:     []string{listing.FilterDate, listing.FilterZone},
+	Columns:     listing.Columns{Date: "s.start_time", Zone: "s.zone"},
+}
+
+func (r *shiftRepository) ListEndedByUser(ctx context.Context, userID int, p listing.Params) ([]models.Shift, response.Page, error) {
+	q := listing.NewQuery(ShiftHistorySpec, p)
+	q.Where("s.user_id = $%d", userID)
+	q.Where("s.end_time IS NOT NULL AND s.voided_at IS NULL")
+
+	var total int
+	where, args := q.Count()
+	if err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM slots s"+where, args...).Scan(&total); err != nil {
+		return nil, response.Page{}, err
+	}
+
+	page, args := q.Page()
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+shiftColumns+`, `+q.SortValue()+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id`+page, args...)
+	if err != nil {
+		return nil, response.Page{}, err
+	}
+	defer rows.Close()
+
+	shifts := []models.Shift{}
+	var keys []listing.Key
+	for rows.Next() {
+		var key listing.Key
+		shift, err := scanShift(withExtra{rows, []interface{}{&key.Value}})
+		if err != nil {
+			return nil, response.Page{}, err
+		}
+		key.ID = shift.ID
+		keys = append(keys, key)
+		shifts = append(shifts, *shift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, response.Page{}, err
+	}
+	n, result := q.Result(keys, total)
+	return shifts[:n], result, nil
+}
+
+// ListEndedBetween — закрытые смены, начатые в [from, to).
+func (r *shiftRepository) ListEndedBetween(ctx context.Context, from, to time.Time) ([]models.Shift, error) {
+	return r.queryShifts(ctx, `
+		SELECT `+shiftColumns+`
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.end_time IS NOT NULL AND s.voided_at IS NULL AND s.start_time >= $1 AND s.start_time < $2
+		ORDER BY s.start_time`, from, to)
+}
+
+func (r *shiftRepository) ListTimeSlots(ctx context.Context) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT slot_time_range FROM available_time_slots")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []string
+	for rows.Next() {
+		var slot string
+		if err := rows.Scan(&slot); err != nil {
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+	return slots, rows.Err()
+}
+
+func (r *shiftRepository) TimeSlotExists(ctx context.Context, slotTimeRange string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM available_time_slots WHERE slot_time_range = $1)", slotTimeRange,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetBreakPolicy возвращает ErrNotFound, если такого временного слота нет.
+func (r *shiftRepository) GetBreakPolicy(ctx context.Context, slotTimeRange string) (*models.BreakPolicy, error) {
+	var policy models.BreakPolicy
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT max_breaks, max_break_minutes FROM available_time_slots WHERE slot_time_range = $1", slotTimeRange,
+	).Scan(&policy.MaxBreaks, &policy.MaxBreakMinutes)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return &policy, err
+}
+
+func (r *shiftRepository) ListBreakPolicies(ctx context.Context) (map[string]models.BreakPolicy, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		"SELECT slot_time_range, max_breaks, max_break_minutes FROM available_time_slots")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make(map[string]models.BreakPolicy)
+	for rows.Next() {
+		var slot string
+		var policy models.BreakPolicy
+		if err := rows.Scan(&slot, &policy.MaxBreaks, &policy.MaxBreakMinutes); err != nil {
+			return nil, err
+		}
+		policies[slot] = policy
+	}
+	return policies, rows.Err()
+}
+
+func (r *shiftRepository) ListRecentSelfieHashes(ctx context.Context, since time.Time) ([]models.SelfieHash, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, selfie_phash
+		FROM slots
+		WHERE selfie_phash IS NOT NULL AND start_time >= $1`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []models.SelfieHash
+	for rows.Next() {
+		var h models.SelfieHash
+		if err := rows.Scan(&h.ShiftID, &h.UserID, &h.Hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
+
+// ClearSelfiesBefore убирает пути к селфи из закрытых смен, начатых раньше before.
+// Хэш и метаданные остаются: по ним по-прежнему ищутся повторы.
+func (r *shiftRepository) ClearSelfiesBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE slots SET selfie_path = '', selfie_thumb_path = NULL
+		WHERE start_time < $1 AND end_time IS NOT NULL
+		  AND (selfie_path <> '' OR selfie_thumb_path IS NOT NULL)`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,148 @@
+// repositories/timesheet_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+// TimesheetRepository — данные для табеля и утверждения табелей по месяцам
+// (таблица timesheet_periods). Месяц задаётся первым числом.
+type TimesheetRepository interface {
+	ListEntries(ctx context.Context, from, to time.Time) ([]models.TimesheetEntry, error)
+	CountOpenShifts(ctx context.Context, from, to time.Time) (int, error)
+	// GetPeriod возвращает ErrNotFound, если табель за месяц не утверждён.
+	GetPeriod(ctx context.Context, month time.Time) (*models.TimesheetPeriod, error)
+	// Approve сохраняет снимок табеля. ErrConflict — табель уже закрыт.
+	Approve(ctx context.Context, month time.Time, snapshot []byte, approvedBy int) error
+	// Reopen и Lock возвращают ErrNotFound, если табель не в статусе approved.
+	Reopen(ctx context.Context, month time.Time) error
+	Lock(ctx context.Context, month time.Time, lockedBy int) error
+}
+
+type timesheetRepository struct {
+	db *sql.DB
+}
+
+func NewTimesheetRepository(db *sql.DB) TimesheetRepository {
+	return &timesheetRepository{db: db}
+}
+
+// monthKey — значение для колонки period_start (DATE) без сдвига по часовому поясу.
+func monthKey(month time.Time) string {
+	return month.Format("2006-01-02")
+}
+
+// ListEntries — закрытые неаннулированные смены, начатые в [from, to).
+func (r *timesheetRepository) ListEntries(ctx context.Context, from, to time.Time) ([]models.TimesheetEntry, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT s.id, s.user_id, u.username, COALESCE(u.first_name, ''), s.start_time,
+			s.slot_time_range, s.zone, COALESCE(s.worked_duration, 0)
+		FROM slots s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.end_time IS NOT NULL AND s.voided_at IS NULL AND s.start_time >= $1 AND s.start_time < $2
+		ORDER BY s.user_id, s.start_time`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TimesheetEntry
+	for rows.Next() {
+		var e models.TimesheetEntry
+		if err := rows.Scan(&e.ShiftID, &e.UserID, &e.Username, &e.FirstName, &e.StartTime,
+			&e.SlotTimeRange, &e.Zone, &e.WorkedSeconds); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *timesheetRepository) CountOpenShifts(ctx context.Context, from, to time.Time) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM slots
+		WHERE end_time IS NULL AND start_time >= $1 AND start_time < $2`, from, to,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *timesheetRepository) GetPeriod(ctx context.Context, month time.Time) (*models.TimesheetPeriod, error) {
+	var p models.TimesheetPeriod
+	var approvedBy, lockedBy sql.NullInt64
+	var lockedAt sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT status, snapshot, approved_by, approved_at, locked_by, locked_at
+		FROM timesheet_periods
+		WHERE period_start = $1::date`, monthKey(month),
+	).Scan(&p.Status, &p.Snapshot, &approvedBy, &p.ApprovedAt, &lockedBy, &lockedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if approvedBy.Valid {
+		id := int(approvedBy.Int64)
+		p.ApprovedBy = &id
+	}
+	if lockedBy.Valid {
+		id := int(lockedBy.Int64)
+		p.LockedBy = &id
+	}
+	if lockedAt.Valid {
+		p.LockedAt = &lockedAt.Time
+	}
+	return &p, nil
+}
+
+// Approve утверждает табель или заменяет снимок ещё не закрытого.
+func (r *timesheetRepository) Approve(ctx context.Context, month time.Time, snapshot []byte, approvedBy int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO timesheet_periods (period_start, status, snapshot, approved_by, approved_at)
+		VALUES ($1::date, 'approved', $2, $3, NOW())
+		ON CONFLICT (period_start) DO UPDATE
+		SET snapshot = EXCLUDED.snapshot, approved_by = EXCLUDED.approved_by, approved_at = EXCLUDED.approved_at
+		WHERE timesheet_periods.status = 'approved'`,
+		monthKey(month), string(snapshot), approvedBy,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *timesheetRepository) Reopen(ctx context.Context, month time.Time) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM timesheet_periods
+		WHERE period_start = $1::date AND status = 'approved'`, monthKey(month))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *timesheetRepository) Lock(ctx context.Context, month time.Time, lockedBy int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE timesheet_periods SET status = 'locked', locked_by = $1, locked_at = NOW()
+		WHERE period_start = $2::date AND status = 'approved'`,
+		lockedBy, monthKey(month),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
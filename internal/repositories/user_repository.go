@@ -0,0 +1,46 @@
+// repositories/user_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UserRepository — то, что доменным сервисам нужно знать о пользователях.
+type UserRepository interface {
+	GetRole(ctx context.Context, userID int) (string, error)
+	// LockForUpdate блокирует строку пользователя до конца транзакции,
+	// сериализуя операции над его сменами.
+	LockForUpdate(ctx context.Context, userID int) error
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) GetRole(ctx context.Context, userID int) (string, error) {
+	var role string
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT role FROM users WHERE id = $1", userID,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return role, err
+}
+
+func (r *userRepository) LockForUpdate(ctx context.Context, userID int) error {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
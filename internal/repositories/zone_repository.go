@@ -0,0 +1,28 @@
+// repositories/zone_repository.go
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ZoneRepository interface {
+	Exists(ctx context.Context, name string) (bool, error)
+}
+
+type zoneRepository struct {
+	db *sql.DB
+}
+
+func NewZoneRepository(db *sql.DB) ZoneRepository {
+	return &zoneRepository{db: db}
+}
+
+func (r *zoneRepository) Exists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM zones WHERE name = $1)", name,
+	).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// contractDB — database/sql поверх заготовленных ответов для обработчиков,
+// которые ходят в *sql.DB напрямую. Запрос получает строки первого правила,
+// чей фрагмент в нём есть (пробелы схлопываются). Неизвестный запрос — ошибка:
+// обработчик ответит 500, и тест это покажет. Exec без правила проходит.
+type contractDB struct {
+	mu    sync.Mutex
+	rules []contractRule
+}
+
+type contractRule struct {
+	match string
+	rows  [][]driver.Value
+}
+
+func newContractDB() (*sql.DB, *contractDB) {
+	scripted := &contractDB{}
+	return sql.OpenDB(scripted), scripted
+}
+
+// On задаёт строки для запросов с фрагментом match; позднее правило важнее.
+func (d *contractDB) On(match string, rows ...[]driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules = append([]contractRule{{match: normalizeQuery(match), rows: rows}}, d.rules...)
+}
+
+func (d *contractDB) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules = nil
+}
+
+func (d *contractDB) find(query string) (contractRule, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	query = normalizeQuery(query)
+	for _, rule := range d.rules {
+		if strings.Contains(query, rule.match) {
+			return rule, true
+		}
+	}
+	return contractRule{}, false
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func (d *contractDB) Connect(context.Context) (driver.Conn, error) { return contractConn{d}, nil }
+func (d *contractDB) Driver() driver.Driver                        { return contractDriver{d} }
+
+type contractDriver struct{ db *contractDB }
+
+func (d contractDriver) Open(string) (driver.Conn, error) { return contractConn{d.db}, nil }
+
+type contractConn struct{ db *contractDB }
+
+func (c contractConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("contractDB: prepared statements are not supported")
+}
+func (c contractConn) Close() error              { return nil }
+func (c contractConn) Begin() (driver.Tx, error) { return contractConn{c.db}, nil }
+func (c contractConn) Commit() error             { return nil }
+func (c contractConn) Rollback() error           { return nil }
+
+// CheckNamedValue принимает аргументы любых типов (pq.Array и т.п.).
+func (c contractConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c contractConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rule, ok := c.db.find(query)
+	if !ok {
+		return nil, fmt.Errorf("contractDB: unexpected query %q", normalizeQuery(query))
+	}
+	return &contractRows{rows: rule.rows}, nil
+}
+
+func (c contractConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type contractRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *contractRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *contractRows) Close() error { return nil }
+
+func (r *contractRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
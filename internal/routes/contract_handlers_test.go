@@ -0,0 +1,291 @@
+package routes
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/handlers"
+	adminHandlers "github.com/evn/eom_backendl/internal/handlers/admin"
+	authHandlers "github.com/evn/eom_backendl/internal/handlers/auth"
+	mapHandlers "github.com/evn/eom_backendl/internal/handlers/map"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/repositories"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	authService "github.com/evn/eom_backendl/internal/services/auth"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	timesheetService "github.com/evn/eom_backendl/internal/services/timesheet"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestAdminHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+	auditLog := auditService.NewAuditLogger(repositories.NewAuditRepository(db))
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Get("/api/admin/users", adminHandlers.ListAdminUsersHandler(db))
+	router.Get("/api/admin/approvals", adminHandlers.ListPendingApprovalsHandler(db))
+	router.Post("/api/admin/approvals/{userID}/approve", adminHandlers.ApproveUserHandler(db, auditLog, nil))
+
+	reset := func() {
+		scripted.Reset()
+		scripted.On("INSERT INTO audit_log", []driver.Value{int64(1), testNow})
+		scripted.On("SELECT COUNT(*) FROM users", []driver.Value{int64(2)})
+		scripted.On("SELECT id, username, first_name, role, status, is_active, created_at, promo_codes, deleted_at",
+			[]driver.Value{int64(7), "scout", "Айдар", "scout", "active", true, testNow, []byte(`{"JET": ["A1"]}`), nil, "scout"},
+			[]driver.Value{int64(8), "old", nil, "scout", "deleted", false, testNow, nil, testNow, "old"})
+		scripted.On("WHERE status = 'pending'",
+			[]driver.Value{int64(9), "newbie", "Асель", nil, "+77010000000", int64(555), nil, testNow, testNow})
+		scripted.On("RETURNING telegram_id", []driver.Value{nil})
+	}
+
+	runContractCases(t, spec, router, reset, []contractCase{
+		{name: "users", method: http.MethodGet, target: "/api/admin/users?include_deleted=true"},
+		{name: "approvals", method: http.MethodGet, target: "/api/admin/approvals"},
+		{name: "approve", method: http.MethodPost, target: "/api/admin/approvals/9/approve"},
+		{name: "approve not pending", method: http.MethodPost, target: "/api/admin/approvals/9/approve", status: http.StatusConflict,
+			before: func() { scripted.On("RETURNING telegram_id") }},
+	})
+}
+
+func TestAuthHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	defer redisClient.Close()
+	keySet, err := authService.LoadKeySet(authService.KeySetConfig{HMACSecret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtService := authService.NewJWTService(keySet, redisClient)
+	authHandler := authHandlers.NewAuthHandler(db, jwtService, nil, nil, nil)
+	profileHandler := authHandlers.NewProfileHandler(db)
+
+	router := chi.NewRouter()
+	router.Post("/api/auth/register", authHandler.RegisterHandler)
+	router.Get("/.well-known/jwks.json", authHandler.JWKSHandler)
+	router.With(withToken(testUserID)).Get("/api/profile", profileHandler.GetProfile)
+
+	reset := func() {
+		scripted.Reset()
+		scripted.On("SELECT COUNT(*) FROM users WHERE username", []driver.Value{int64(0)})
+		scripted.On("SELECT id, username, first_name, telegram_id, role, avatar_url, zone, status, is_active",
+			[]driver.Value{int64(testUserID), "scout", "Айдар", int64(555), "scout", nil, "Center", "active", true})
+	}
+
+	runContractCases(t, spec, router, reset, []contractCase{
+		{name: "register", method: http.MethodPost, target: "/api/auth/register",
+			body: `{"username": "newbie", "first_name": "Асель", "password": "Str0ng-Passw0rd!"}`},
+		{name: "register taken username", method: http.MethodPost, target: "/api/auth/register", status: http.StatusBadRequest,
+			body:   `{"username": "scout", "password": "Str0ng-Passw0rd!"}`,
+			before: func() { scripted.On("SELECT COUNT(*) FROM users WHERE username", []driver.Value{int64(1)}) }},
+		{name: "jwks", method: http.MethodGet, target: "/.well-known/jwks.json"},
+		{name: "profile", method: http.MethodGet, target: "/api/profile"},
+		{name: "profile of deleted user", method: http.MethodGet, target: "/api/profile", status: http.StatusNotFound,
+			before: func() { scripted.On("SELECT id, username, first_name, telegram_id") }},
+	})
+}
+
+func TestTimesheetHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+	auditLog := auditService.NewAuditLogger(repositories.NewAuditRepository(db))
+	scripted.On("INSERT INTO audit_log", []driver.Value{int64(1), testNow})
+	repo := &contractTimesheets{}
+	timesheets := timesheetService.NewTimesheetService(contractTx{}, repo, models.TimesheetRules{
+		RoundingMinutes: 15, RoundingMode: "nearest", DailyOvertimeHours: 8, WeeklyOvertimeHours: 40,
+	})
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Get("/api/admin/timesheets/{period}", adminHandlers.GetTimesheetHandler(timesheets))
+	router.Get("/api/admin/timesheets/{period}/export", adminHandlers.ExportTimesheetHandler(timesheets))
+	router.Post("/api/admin/timesheets/{period}/approve", adminHandlers.ApproveTimesheetHandler(timesheets, auditLog))
+	router.Post("/api/admin/timesheets/{period}/reopen", adminHandlers.ReopenTimesheetHandler(timesheets, auditLog))
+	router.Post("/api/admin/timesheets/{period}/lock", adminHandlers.LockTimesheetHandler(timesheets, auditLog))
+
+	approve := func() {
+		if _, err := timesheets.Approve(context.Background(), time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local), testUserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runContractCases(t, spec, router, repo.reset, []contractCase{
+		{name: "draft", method: http.MethodGet, target: "/api/admin/timesheets/2026-02"},
+		{name: "export", method: http.MethodGet, target: "/api/admin/timesheets/2026-02/export"},
+		{name: "approve", method: http.MethodPost, target: "/api/admin/timesheets/2026-02/approve"},
+		{name: "approved", method: http.MethodGet, target: "/api/admin/timesheets/2026-02", before: approve},
+		{name: "reopen", method: http.MethodPost, target: "/api/admin/timesheets/2026-02/reopen", before: approve},
+		{name: "lock", method: http.MethodPost, target: "/api/admin/timesheets/2026-02/lock", before: approve},
+		{name: "lock draft", method: http.MethodPost, target: "/api/admin/timesheets/2026-02/lock", status: http.StatusConflict},
+		{name: "invalid period", method: http.MethodGet, target: "/api/admin/timesheets/2026-13", status: http.StatusBadRequest},
+	})
+}
+
+func TestMapHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+	auditLog := auditService.NewAuditLogger(repositories.NewAuditRepository(db))
+	mapHandler := mapHandlers.NewMapHandler(db, auditLog, storageService.NewLocalStorage(t.TempDir()))
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Get("/api/admin/maps", mapHandler.GetMapsHandler)
+	router.Get("/api/admin/maps/{mapID}", mapHandler.GetMapByIDHandler)
+	router.Delete("/api/admin/maps/{mapID}", mapHandler.DeleteMapHandler)
+
+	mapRow := []driver.Value{int64(4), "Алматы", "Центр", "almaty.geojson", int64(2048), testNow}
+	reset := func() {
+		scripted.Reset()
+		scripted.On("INSERT INTO audit_log", []driver.Value{int64(1), testNow})
+		scripted.On("SELECT COUNT(*) FROM maps", []driver.Value{int64(1)})
+		scripted.On("SELECT id, city, COALESCE(description, ''), file_name, file_size, upload_date,",
+			append(append([]driver.Value(nil), mapRow...), testNow.Format(time.RFC3339Nano)))
+		scripted.On("SELECT id, city, description, file_name, file_size, upload_date FROM maps WHERE", mapRow)
+	}
+
+	runContractCases(t, spec, router, reset, []contractCase{
+		{name: "maps", method: http.MethodGet, target: "/api/admin/maps"},
+		{name: "map", method: http.MethodGet, target: "/api/admin/maps/4"},
+		{name: "delete", method: http.MethodDelete, target: "/api/admin/maps/4"},
+		{name: "missing map", method: http.MethodGet, target: "/api/admin/maps/5", status: http.StatusNotFound,
+			before: func() { scripted.On("SELECT id, city, description, file_name, file_size, upload_date FROM maps WHERE") }},
+	})
+}
+
+func TestAppVersionHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+	auditLog := auditService.NewAuditLogger(repositories.NewAuditRepository(db))
+	appVersionHandler := handlers.NewAppVersionHandler(db, auditLog)
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Post("/api/app/version/check", appVersionHandler.CheckVersionHandler)
+	router.Get("/api/app/version/latest", appVersionHandler.GetLatestVersionHandler)
+	router.Get("/api/admin/app/versions", appVersionHandler.ListVersionsHandler)
+
+	versionRow := []driver.Value{int64(3), "android", "2.4.0", int64(240), "Исправления", "https://example.com/app.apk",
+		int64(26), false, true, testNow, testNow}
+	reset := func() {
+		scripted.Reset()
+		scripted.On("FROM app_versions WHERE platform = $1 AND is_active = TRUE", versionRow)
+		scripted.On("SELECT COUNT(*) FROM app_versions", []driver.Value{int64(1)})
+		scripted.On("SELECT id, platform, version, build_number, COALESCE(release_notes, '')",
+			append(append([]driver.Value(nil), versionRow...), "240"))
+	}
+
+	runContractCases(t, spec, router, reset, []contractCase{
+		{name: "update available", method: http.MethodPost, target: "/api/app/version/check",
+			body: `{"platform": "android", "current_version": "2.3.0", "build_number": 230}`},
+		{name: "up to date", method: http.MethodPost, target: "/api/app/version/check",
+			body: `{"platform": "android", "current_version": "2.4.0", "build_number": 240}`},
+		{name: "no versions", method: http.MethodPost, target: "/api/app/version/check",
+			body:   `{"platform": "ios", "current_version": "1.0.0", "build_number": 1}`,
+			before: func() { scripted.On("FROM app_versions WHERE platform = $1 AND is_active = TRUE") }},
+		{name: "latest", method: http.MethodGet, target: "/api/app/version/latest?platform=android"},
+		{name: "list", method: http.MethodGet, target: "/api/admin/app/versions?platform=android"},
+		{name: "latest missing", method: http.MethodGet, target: "/api/app/version/latest?platform=ios", status: http.StatusNotFound,
+			before: func() { scripted.On("FROM app_versions WHERE platform = $1 AND is_active = TRUE") }},
+	})
+}
+
+func TestAuditLogHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	db, scripted := newContractDB()
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Get("/api/admin/audit-log", adminHandlers.ListAuditLogHandler(db))
+	router.Get("/api/admin/audit-log/export", adminHandlers.ExportAuditLogHandler(db))
+
+	reset := func() {
+		scripted.Reset()
+		scripted.On("FROM audit_log",
+			[]driver.Value{int64(12), int64(testUserID), "superadmin", "user.role_change", "user", "8",
+				[]byte(`{"role": "scout"}`), []byte(`{"role": "supervisor"}`), "10.0.0.1", testNow},
+			[]driver.Value{int64(11), nil, "system", "shift.auto_end", "slot", "5", nil, nil, "", testNow})
+	}
+
+	runContractCases(t, spec, router, reset, []contractCase{
+		{name: "entries", method: http.MethodGet, target: "/api/admin/audit-log?action=user.role_change&limit=10"},
+		{name: "empty", method: http.MethodGet, target: "/api/admin/audit-log", before: func() { scripted.On("FROM audit_log") }},
+		{name: "export", method: http.MethodGet, target: "/api/admin/audit-log/export?from=2026-03-01T00:00:00Z"},
+		{name: "invalid filter", method: http.MethodGet, target: "/api/admin/audit-log?actor_id=abc", status: http.StatusBadRequest},
+	})
+}
+
+// withToken кладёт в контекст токен с user_id, как Verifier.
+func withToken(userID int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := jwt.New()
+			token.Set("user_id", float64(userID))
+			next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, nil)))
+		})
+	}
+}
+
+// contractTimesheets — табель за февраль 2026 в памяти: две смены одного
+// сотрудника и статус периода.
+type contractTimesheets struct {
+	period *models.TimesheetPeriod
+}
+
+func (r *contractTimesheets) reset() { r.period = nil }
+
+func (r *contractTimesheets) ListEntries(ctx context.Context, from, to time.Time) ([]models.TimesheetEntry, error) {
+	day := time.Date(2026, 2, 9, 7, 0, 0, 0, time.Local)
+	return []models.TimesheetEntry{
+		{ShiftID: 1, UserID: testUserID, Username: "scout", FirstName: "Айдар", StartTime: day,
+			SlotTimeRange: "07:00-15:00", Zone: "Center", WorkedSeconds: 8*3600 + 7*60},
+		{ShiftID: 2, UserID: testUserID, Username: "scout", FirstName: "Айдар", StartTime: day.AddDate(0, 0, 1),
+			SlotTimeRange: "15:00-23:00", Zone: "North", WorkedSeconds: 9 * 3600},
+	}, nil
+}
+
+func (r *contractTimesheets) CountOpenShifts(ctx context.Context, from, to time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *contractTimesheets) GetPeriod(ctx context.Context, month time.Time) (*models.TimesheetPeriod, error) {
+	if r.period == nil {
+		return nil, repositories.ErrNotFound
+	}
+	period := *r.period
+	return &period, nil
+}
+
+func (r *contractTimesheets) Approve(ctx context.Context, month time.Time, snapshot []byte, approvedBy int) error {
+	if r.period != nil && r.period.Status == "locked" {
+		return repositories.ErrConflict
+	}
+	r.period = &models.TimesheetPeriod{Status: "approved", Snapshot: snapshot, ApprovedBy: &approvedBy, ApprovedAt: testNow}
+	return nil
+}
+
+func (r *contractTimesheets) Reopen(ctx context.Context, month time.Time) error {
+	if r.period == nil || r.period.Status != "approved" {
+		return repositories.ErrNotFound
+	}
+	r.period = nil
+	return nil
+}
+
+func (r *contractTimesheets) Lock(ctx context.Context, month time.Time, lockedBy int) error {
+	if r.period == nil || r.period.Status != "approved" {
+		return repositories.ErrNotFound
+	}
+	r.period.Status = "locked"
+	r.period.LockedBy = &lockedBy
+	lockedAt := testNow
+	r.period.LockedAt = &lockedAt
+	return nil
+}
@@ -0,0 +1,404 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evn/eom_backendl/config"
+	shiftHandlers "github.com/evn/eom_backendl/internal/handlers/shift"
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/openapi"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	"github.com/go-chi/chi/v5"
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Контрактные тесты: ответы обработчиков сверяются со спецификацией строго —
+// переименованное, пропавшее или неописанное поле роняет тест.
+
+const testUserID = 7
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+
+func TestSpecCoversAllRoutes(t *testing.T) {
+	// Подключения ленивые: Setup только собирает роутер
+	db, err := sql.Open("postgres", "postgres://test@127.0.0.1:1/test?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	defer redisClient.Close()
+
+	cfg := &config.Config{
+		JwtSecret:         strings.Repeat("s", 32),
+		UploadsSigningKey: strings.Repeat("k", 32),
+	}
+	router := Setup(cfg, db, redisClient, storageService.NewLocalStorage(t.TempDir()))
+	if err := APISpec().CheckRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpecDocument(t *testing.T) {
+	spec := APISpec()
+	data, err := json.Marshal(spec.Document())
+	if err != nil {
+		t.Fatalf("document does not marshal: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	paths := doc["paths"].(map[string]interface{})
+	for _, path := range []string{"/api/shifts", "/api/users/{userID}/shifts", "/uploads/{path}"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("document has no path %s", path)
+		}
+	}
+
+	if op, params := spec.Find(http.MethodGet, "/api/shifts/active"); op == nil || op.Path != "/api/shifts/active" {
+		t.Errorf("static segment must win over a parameter, got %+v", op)
+	} else if len(params) != 0 {
+		t.Errorf("unexpected params %v", params)
+	}
+	if op, params := spec.Find(http.MethodGet, "/api/users/15/shifts"); op == nil || params["userID"] != "15" {
+		t.Errorf("path parameter not matched: %+v %v", op, params)
+	}
+}
+
+func TestContractDetectsDrift(t *testing.T) {
+	spec := APISpec()
+	op := spec.Operation(http.MethodPost, "/api/slot/resume")
+	body := map[string]interface{}{
+		"message":    "Break ended",
+		"break_id":   1.0,
+		"started_at": "2026-03-10T11:00:00Z",
+		"ended_at":   "2026-03-10T11:10:00Z",
+		"break_time": "00:10:00",
+		"autoClosed": false, // переименованное поле
+	}
+	errs := spec.ValidateResponse(op.Response, body)
+	if !hasError(errs, "response.auto_closed", response.FieldRequired) || !hasError(errs, "response.autoClosed", response.FieldUnsupported) {
+		t.Errorf("drift not detected: %+v", errs)
+	}
+}
+
+func TestValidatorRejectsInvalidRequest(t *testing.T) {
+	spec := APISpec()
+	handler := spec.Validator(func(*http.Request) bool { return true })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		method, target, body string
+		status               int
+		field                string
+	}{
+		{http.MethodPost, "/api/geo", `{"lat": 91, "lon": 10}`, http.StatusBadRequest, "body.lat"},
+		{http.MethodPost, "/api/geo", `{"lon": 10}`, http.StatusBadRequest, "body.lat"},
+		{http.MethodPost, "/api/geo", `{"lat": 43.2, "lon": 76.9, "extra": true}`, http.StatusNoContent, ""},
+		{http.MethodGet, "/api/users/abc/shifts", "", http.StatusBadRequest, "userID"},
+		{http.MethodGet, "/api/shifts?limit=1000", "", http.StatusBadRequest, "limit"},
+		{http.MethodGet, "/api/shifts?sort=-start_time&from=2026-03-01", "", http.StatusNoContent, ""},
+		{http.MethodPatch, "/api/admin/shifts/3", `{"reason": "because"}`, http.StatusBadRequest, "body.reason"},
+		{http.MethodGet, "/not-described", "", http.StatusNoContent, ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("%s %s: status %d, want %d (%s)", c.method, c.target, rec.Code, c.status, rec.Body)
+			continue
+		}
+		if c.field == "" {
+			continue
+		}
+		var body response.ErrorBody
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Details) == 0 || body.Details[0].Field != c.field {
+			t.Errorf("%s %s: details %+v, want field %s", c.method, c.target, body.Details, c.field)
+		}
+	}
+}
+
+// TestValidatorLeavesAnonymousRequestsToAuthenticator — закрытая операция без
+// токена доходит до Authenticator и получает 401, а не ошибки по полям.
+func TestValidatorLeavesAnonymousRequestsToAuthenticator(t *testing.T) {
+	spec := APISpec()
+	router := chi.NewRouter()
+	router.Use(spec.Validator(middleware.Authenticated))
+	router.Post("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticator())
+		r.Post("/api/geo", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	cases := []struct {
+		target, body string
+		status       int
+	}{
+		{"/api/geo", `{"lat": 91}`, http.StatusUnauthorized},
+		{"/api/auth/login", `{"username": 1}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, c.target, strings.NewReader(c.body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("POST %s: status %d, want %d (%s)", c.target, rec.Code, c.status, rec.Body)
+		}
+	}
+}
+
+func TestShiftHandlersMatchSpec(t *testing.T) {
+	spec := APISpec()
+	shifts, repo := newContractShiftService()
+	signer := mediaService.NewURLSigner(strings.Repeat("k", 32), time.Hour, nil)
+	images := mediaService.NewImageProcessor(1600, 320)
+	store := storageService.NewLocalStorage(t.TempDir())
+
+	router := chi.NewRouter()
+	router.Use(asUser(testUserID))
+	router.Get("/api/shifts", shiftHandlers.GetShiftsHandler(shifts))
+	router.Get("/api/users/{userID}/shifts", shiftHandlers.GetUserShiftsByIDHandler(shifts))
+	router.Get("/api/shifts/active", shiftHandlers.GetUserActiveShiftHandler(shifts, signer))
+	router.Get("/api/active-slots", shiftHandlers.GetActiveShiftsHandler(shifts, signer))
+	router.Get("/api/shifts/handover", shiftHandlers.GetHandoverHandler(shifts, signer))
+	router.Get("/api/admin/shift-reports", shiftHandlers.GetShiftReportsHandler(shifts, signer))
+	router.Get("/api/slots/positions", shiftHandlers.GetAvailablePositionsHandler(shifts))
+	router.Get("/api/slots/times", shiftHandlers.GetAvailableTimeSlotsHandler(shifts))
+	router.Post("/api/slot/end", shiftHandlers.EndSlotHandler(shifts, images, store, signer, []string{"jet", "whoosh"}))
+
+	runContractCases(t, spec, router, repo.reset, []contractCase{
+		{name: "history", method: http.MethodGet, target: "/api/shifts?limit=1"},
+		{name: "history of another user", method: http.MethodGet, target: "/api/users/7/shifts"},
+		{name: "active shift on break", method: http.MethodGet, target: "/api/shifts/active"},
+		{name: "no active shift", method: http.MethodGet, target: "/api/shifts/active", before: func() { repo.active = nil }},
+		{name: "active shifts", method: http.MethodGet, target: "/api/active-slots"},
+		{name: "handover", method: http.MethodGet, target: "/api/shifts/handover?zone=Center"},
+		{name: "shift reports", method: http.MethodGet, target: "/api/admin/shift-reports?date=2026-03-10"},
+		{name: "positions", method: http.MethodGet, target: "/api/slots/positions"},
+		{name: "time slots", method: http.MethodGet, target: "/api/slots/times"},
+		{name: "end with report", method: http.MethodPost, target: "/api/slot/end",
+			body: `{"scooters": {"jet": 12}, "issues": ["flat tyre"], "notes": "Всё спокойно"}`},
+		{name: "end without report", method: http.MethodPost, target: "/api/slot/end"},
+		{name: "invalid user id", method: http.MethodGet, target: "/api/users/abc/shifts", status: http.StatusBadRequest},
+		{name: "end without active shift", method: http.MethodPost, target: "/api/slot/end", status: http.StatusBadRequest,
+			before: func() { repo.active = nil }},
+	})
+}
+
+type contractCase struct {
+	name, method, target, body string
+	status                     int // 0 — успешный код из спецификации
+	before                     func()
+}
+
+// runContractCases выполняет запросы и сверяет код и тело каждого ответа со
+// спецификацией; reset перед каждым случаем возвращает исходные данные.
+func runContractCases(t *testing.T, spec *openapi.Spec, router http.Handler, reset func(), cases []contractCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reset()
+			if c.before != nil {
+				c.before()
+			}
+			req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			if c.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			op, _ := spec.Find(c.method, req.URL.Path)
+			if op == nil {
+				t.Fatalf("%s %s is not described", c.method, req.URL.Path)
+			}
+			want := c.status
+			if want == 0 {
+				want = op.Status
+				if want == 0 {
+					want = http.StatusOK
+				}
+			}
+			if rec.Code != want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, want, rec.Body)
+			}
+
+			schema := op.Response
+			if rec.Code >= 400 {
+				schema = openapi.Ref("ErrorBody")
+			} else if op.ContentType != "" {
+				if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, op.ContentType) {
+					t.Errorf("Content-Type %q, want %s", got, op.ContentType)
+				}
+				return
+			}
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			for _, e := range spec.ValidateResponse(schema, body) {
+				t.Errorf("%s: %s %v", e.Field, e.Code, e.Params)
+			}
+		})
+	}
+}
+
+func hasError(errs []response.FieldError, field, code string) bool {
+	for _, e := range errs {
+		if e.Field == field && e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func asUser(userID int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.UserIDContextKey, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// contractRepo — смены, перерывы и отчёты в памяти. Встроенные интерфейсы
+// не заданы: вызов неподменённого метода роняет тест.
+type contractRepo struct {
+	repositories.ShiftRepository
+	breaks  contractBreaks
+	reports contractReports
+	users   contractUsers
+	active  *models.Shift
+}
+
+func (r *contractRepo) reset() {
+	start := testNow.Add(-2 * time.Hour)
+	r.active = &models.Shift{
+		ID: 11, UserID: testUserID, Username: "scout", StartTime: start,
+		SlotTimeRange: "07:00-15:00", Position: "Скаут", Zone: "Center",
+		SelfiePath: "/uploads/selfies/11.jpg", SelfieThumb: "/uploads/selfies/11_thumb.jpg",
+		LateMinutes: 5,
+	}
+	breakStart := testNow.Add(-10 * time.Minute)
+	r.breaks.list = []models.ShiftBreak{{ID: 3, ShiftID: 11, StartedAt: breakStart}}
+}
+
+func endedShift() models.Shift {
+	start := testNow.Add(-26 * time.Hour)
+	end := start.Add(8 * time.Hour)
+	return models.Shift{
+		ID: 9, UserID: testUserID, Username: "scout", StartTime: start, EndTime: &end,
+		SlotTimeRange: "07:00-15:00", Position: "Скаут", Zone: "Center",
+		WorkedDuration: 7 * 3600, BreakDuration: 3600, EarlyLeaveMinutes: 12,
+		EndReason: models.ShiftEndedAuto,
+	}
+}
+
+func (r *contractRepo) ListEndedByUser(ctx context.Context, userID int, p listing.Params) ([]models.Shift, response.Page, error) {
+	return []models.Shift{endedShift()}, response.Page{Total: 3, Limit: p.Limit, HasMore: true, NextCursor: "abc"}, nil
+}
+
+func (r *contractRepo) GetActiveByUser(ctx context.Context, userID int) (*models.Shift, error) {
+	if r.active == nil {
+		return nil, repositories.ErrNotFound
+	}
+	shift := *r.active
+	return &shift, nil
+}
+
+func (r *contractRepo) LockActiveByUser(ctx context.Context, userID int) (*models.Shift, error) {
+	return r.GetActiveByUser(ctx, userID)
+}
+
+func (r *contractRepo) ListActive(ctx context.Context) ([]models.Shift, error) {
+	return []models.Shift{*r.active}, nil
+}
+
+func (r *contractRepo) Finish(ctx context.Context, shift *models.Shift) error { return nil }
+
+func (r *contractRepo) GetBreakPolicy(ctx context.Context, slotTimeRange string) (*models.BreakPolicy, error) {
+	return nil, repositories.ErrNotFound
+}
+
+func (r *contractRepo) ListTimeSlots(ctx context.Context) ([]string, error) {
+	return []string{"07:00-15:00", "15:00-23:00"}, nil
+}
+
+type contractBreaks struct {
+	repositories.BreakRepository
+	list []models.ShiftBreak
+}
+
+func (b *contractBreaks) ListByShift(ctx context.Context, shiftID int) ([]models.ShiftBreak, error) {
+	return append([]models.ShiftBreak(nil), b.list...), nil
+}
+
+func (b *contractBreaks) ListByShifts(ctx context.Context, shiftIDs []int) (map[int][]models.ShiftBreak, error) {
+	return map[int][]models.ShiftBreak{11: b.list}, nil
+}
+
+func (b *contractBreaks) Finish(ctx context.Context, breakID int, endedAt time.Time, autoClosed bool) error {
+	return nil
+}
+
+type contractReports struct {
+	repositories.ReportRepository
+}
+
+func (contractReports) Create(ctx context.Context, report *models.ShiftReport) error {
+	report.CreatedAt = testNow
+	return nil
+}
+
+func (contractReports) LatestInZone(ctx context.Context, zone string, since time.Time) (*models.ShiftWithReport, error) {
+	return &models.ShiftWithReport{Shift: endedShift(), Report: &models.ShiftReport{
+		ShiftID: 9, Scooters: map[string]int{"jet": 4}, Notes: "Ключи у охраны",
+		Photos: []string{"/uploads/reports/9_1.jpg"}, CreatedAt: testNow,
+	}}, nil
+}
+
+func (contractReports) ListEnded(ctx context.Context, zone string, from, to time.Time) ([]models.ShiftWithReport, error) {
+	withReport, _ := contractReports{}.LatestInZone(ctx, zone, from)
+	return []models.ShiftWithReport{*withReport, {Shift: endedShift()}}, nil
+}
+
+type contractUsers struct {
+	repositories.UserRepository
+}
+
+func (contractUsers) GetRole(ctx context.Context, userID int) (string, error) { return "scout", nil }
+
+type contractTx struct{}
+
+func (contractTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
+
+func newContractShiftService() (*shiftService.ShiftService, *contractRepo) {
+	repo := &contractRepo{}
+	repo.reset()
+	shifts := shiftService.NewShiftService(contractTx{}, repo, &repo.breaks, repo.users, nil, nil, repo.reports, nil, nil)
+	return shifts, repo
+}
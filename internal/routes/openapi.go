@@ -0,0 +1,769 @@
+package routes
+
+import (
+	"net/http"
+
+	adminHandlers "github.com/evn/eom_backendl/internal/handlers/admin"
+	mapHandlers "github.com/evn/eom_backendl/internal/handlers/map"
+	shiftHandlers "github.com/evn/eom_backendl/internal/handlers/shift"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/openapi"
+	"github.com/evn/eom_backendl/internal/repositories"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+)
+
+// Адреса документации API.
+const (
+	DocsPath = "/api/docs"
+	SpecPath = "/api/docs/openapi.json"
+)
+
+// APISpec — описание всех маршрутов Setup. По нему строится /api/docs,
+// Validator проверяет запросы, а контрактный тест — ответы обработчиков.
+// Маршрут без описания (или описание без маршрута) не даст запустить сервер.
+func APISpec() *openapi.Spec {
+	spec := openapi.New("EOM API", "1.0", "API приложения для скаутов и администраторов. Ошибки — ErrorBody, язык текста — по Accept-Language (ru, kk, en).")
+	for name, schema := range apiSchemas() {
+		spec.Schemas[name] = schema
+	}
+
+	userID := openapi.PathParam("userID", openapi.Integer())
+	slotID := openapi.PathParam("slotID", openapi.Integer())
+	period := openapi.PathParam("period", openapi.String().Desc("Месяц YYYY-MM"))
+	message := func() *openapi.Schema { return openapi.Ref("Message") }
+	status := func() *openapi.Schema { return openapi.Ref("Status") }
+	correction := func(fields openapi.Fields) *openapi.Schema {
+		fields["reason"] = openapi.String().OneOf(models.CorrectionReasons...)
+		fields["comment"] = openapi.String().Desc("Обязателен для причины other")
+		return openapi.Input(fields, "reason")
+	}
+
+	spec.Add(
+		// Служебное
+		&openapi.Operation{Method: http.MethodGet, Path: "/health", Tag: "system", Summary: "Проверка работоспособности", Public: true,
+			Response: status()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "system", Summary: "Открытые ключи подписи токенов", Public: true,
+			Response: openapi.Map(openapi.Any())},
+		&openapi.Operation{Method: http.MethodGet, Path: DocsPath, Tag: "system", Summary: "Справочник по API", Public: true,
+			ContentType: "text/html"},
+		&openapi.Operation{Method: http.MethodGet, Path: SpecPath, Tag: "system", Summary: "Документ OpenAPI", Public: true,
+			Response: openapi.Map(openapi.Any())},
+
+		// Вход и регистрация
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/register", Tag: "auth", Summary: "Регистрация по логину и паролю", Public: true,
+			Body: openapi.Input(openapi.Fields{
+				"username":   openapi.String(),
+				"first_name": openapi.String(),
+				"password":   openapi.String(),
+			}, "username", "password"), BodyRequired: true,
+			Status: http.StatusCreated, Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/login", Tag: "auth", Summary: "Вход по логину и паролю", Public: true,
+			Body: openapi.Input(openapi.Fields{
+				"username": openapi.String(),
+				"password": openapi.String(),
+			}, "username", "password"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"token":                openapi.String().Optional(),
+				"refresh_token":        openapi.String().Optional(),
+				"role":                 openapi.String(),
+				"must_change_password": openapi.Boolean().Optional(),
+				"status":               openapi.String().Optional().Desc("pending — заявка ждёт одобрения, токенов нет"),
+				"message":              openapi.String().Optional(),
+				"user_id":              openapi.Integer().Optional(),
+				"username":             openapi.String().Optional(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/telegram", Tag: "auth", Summary: "Вход через Telegram Login Widget", Public: true,
+			Body: openapi.Map(openapi.String()), BodyRequired: true,
+			Response: telegramAuthResponse()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/telegram/webapp", Tag: "auth", Summary: "Вход из Telegram Mini App", Public: true,
+			Body: openapi.Input(openapi.Fields{"init_data": openapi.String()}, "init_data"), BodyRequired: true,
+			Response: telegramAuthResponse()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/auth_callback", Tag: "auth", Summary: "Возврат из Telegram Login Widget", Public: true,
+			Params: []openapi.Param{
+				openapi.Query("id", openapi.String(), "Telegram ID").Require(),
+				openapi.Query("hash", openapi.String(), "Подпись данных").Require(),
+			},
+			ContentType: "text/html"},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/refresh", Tag: "auth", Summary: "Обновление пары токенов", Public: true,
+			Body: openapi.Input(openapi.Fields{"refresh_token": openapi.String()}), BodyRequired: true,
+			Response: openapi.Ref("Tokens")},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/password-reset/request", Tag: "auth", Summary: "Код сброса пароля в Telegram", Public: true,
+			Body: openapi.Input(openapi.Fields{"username": openapi.String()}, "username"), BodyRequired: true,
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/password-reset/confirm", Tag: "auth", Summary: "Новый пароль по коду", Public: true,
+			Body: openapi.Input(openapi.Fields{
+				"username":     openapi.String(),
+				"code":         openapi.String(),
+				"new_password": openapi.String(),
+			}, "username", "code", "new_password"), BodyRequired: true,
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/auth/complete-registration", Tag: "auth", Summary: "Анкета для заявки на доступ",
+			Body: openapi.Input(openapi.Fields{
+				"first_name": openapi.String(),
+				"last_name":  openapi.String(),
+				"phone":      openapi.String(),
+			}, "first_name"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"message":   openapi.String(),
+				"status":    openapi.String(),
+				"is_active": openapi.Boolean(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/logout", Tag: "auth", Summary: "Выход из текущей сессии",
+			Response: message()},
+
+		// Профиль и сессии
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/profile", Tag: "profile", Summary: "Профиль текущего пользователя",
+			Response: openapi.Object(openapi.Fields{
+				"id":         openapi.Integer(),
+				"username":   openapi.String(),
+				"firstName":  openapi.String(),
+				"telegramId": openapi.Integer().Nullable(),
+				"role":       openapi.String(),
+				"avatarUrl":  openapi.String().Nullable(),
+				"position":   openapi.String(),
+				"zone":       openapi.String().Nullable(),
+				"status":     openapi.String(),
+				"is_active":  openapi.Boolean(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/profile/password", Tag: "profile", Summary: "Смена пароля",
+			Body: openapi.Input(openapi.Fields{
+				"current_password": openapi.String(),
+				"new_password":     openapi.String(),
+			}, "new_password"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"message":       openapi.String(),
+				"token":         openapi.String(),
+				"refresh_token": openapi.String(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/profile/telegram", Tag: "profile", Summary: "Привязка Telegram к аккаунту",
+			Body: openapi.Map(openapi.String()).Desc("init_data Mini App или поля Login Widget"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"message":     openapi.String(),
+				"telegram_id": openapi.Integer(),
+			})},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/profile/telegram", Tag: "profile", Summary: "Отвязка Telegram",
+			Response: message()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/sessions", Tag: "profile", Summary: "Активные сессии пользователя",
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"id":           openapi.String(),
+				"user_id":      openapi.Integer(),
+				"device_id":    openapi.String().Optional(),
+				"device_name":  openapi.String().Optional(),
+				"ip":           openapi.String().Optional(),
+				"user_agent":   openapi.String().Optional(),
+				"created_at":   openapi.DateTime(),
+				"last_seen_at": openapi.DateTime(),
+				"current":      openapi.Boolean(),
+			}))},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/sessions", Tag: "profile", Summary: "Завершение всех сессий, кроме текущей",
+			Response: openapi.Object(openapi.Fields{
+				"message": openapi.String(),
+				"revoked": openapi.Integer(),
+			})},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/sessions/{sessionID}", Tag: "profile", Summary: "Завершение сессии",
+			Params:   []openapi.Param{openapi.PathParam("sessionID", openapi.String())},
+			Response: message()},
+
+		// Смены сотрудника
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/time-slots/available-for-start", Tag: "shifts", Summary: "Слоты, которые можно начать сейчас", Public: true,
+			Response: openapi.Array(openapi.String()).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/slots/times", Tag: "shifts", Summary: "Все слоты смен",
+			Response: openapi.Array(openapi.String()).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/slots/positions", Tag: "shifts", Summary: "Должность пользователя для смены",
+			Response: openapi.Array(openapi.String())},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/slots/zones", Tag: "shifts", Summary: "Зоны",
+			Response: openapi.Array(openapi.Ref("Zone")).Nullable()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/slot/start", Tag: "shifts", Summary: "Начало смены с селфи",
+			Multipart: openapi.Object(openapi.Fields{
+				"slot_time_range": openapi.String(),
+				"zone":            openapi.String(),
+				"selfie":          openapi.Binary().Desc("JPEG или PNG, до 5 МБ"),
+			}),
+			Status: http.StatusCreated,
+			Response: openapi.Object(openapi.Fields{
+				"message":         openapi.String(),
+				"selfie":          openapi.String(),
+				"id":              openapi.Integer(),
+				"user_id":         openapi.Integer(),
+				"slot_time_range": openapi.String(),
+				"position":        openapi.String(),
+				"zone":            openapi.String(),
+				"start_time":      openapi.DateTime(),
+				"handover":        openapi.Ref("ReportedShift").Nullable().Desc("Отчёт предыдущей смены в зоне"),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/slot/end", Tag: "shifts", Summary: "Завершение смены с отчётом",
+			Body: openapi.Input(openapi.Fields{
+				"scooters": openapi.Map(openapi.Integer().Min(0)).Desc("Сервис → собрано самокатов"),
+				"issues":   openapi.Array(openapi.String()),
+				"notes":    openapi.String(),
+			}),
+			Multipart: openapi.Object(openapi.Fields{
+				"report": openapi.String().Optional().Desc("Отчёт в JSON, как в теле application/json"),
+				"photos": openapi.Array(openapi.Binary()).MaxCount(shiftService.MaxReportPhotos).Optional(),
+			}),
+			Response: openapi.Object(openapi.Fields{
+				"message":     openapi.String(),
+				"worked_time": openapi.String(),
+				"break_time":  openapi.String(),
+				"flags":       openapi.Ref("ShiftFlags"),
+				"report":      openapi.Ref("ShiftReport").Nullable(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/slot/pause", Tag: "shifts", Summary: "Начало перерыва",
+			Status: http.StatusCreated,
+			Response: openapi.Object(openapi.Fields{
+				"message":    openapi.String(),
+				"break_id":   openapi.Integer(),
+				"started_at": openapi.DateTime(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/slot/resume", Tag: "shifts", Summary: "Конец перерыва",
+			Response: openapi.Object(openapi.Fields{
+				"message":     openapi.String(),
+				"break_id":    openapi.Integer(),
+				"started_at":  openapi.DateTime(),
+				"ended_at":    openapi.DateTime(),
+				"break_time":  openapi.String(),
+				"auto_closed": openapi.Boolean(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/active", Tag: "shifts", Summary: "Активная смена пользователя или null",
+			Response: openapi.Object(openapi.Fields{
+				"id":               openapi.Integer(),
+				"user_id":          openapi.Integer(),
+				"username":         openapi.String(),
+				"slot_time_range":  openapi.String(),
+				"position":         openapi.String(),
+				"zone":             openapi.String(),
+				"start_time":       openapi.DateTime(),
+				"is_active":        openapi.Boolean(),
+				"on_break":         openapi.Boolean(),
+				"break_started_at": openapi.DateTime().Nullable(),
+				"breaks_taken":     openapi.Integer(),
+				"break_time":       openapi.String(),
+				"selfie":           openapi.String(),
+			}).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/handover", Tag: "shifts", Summary: "Отчёт предыдущей смены в зоне",
+			Params: []openapi.Param{openapi.Query("zone", openapi.String(), "По умолчанию — зона активной смены")},
+			Response: openapi.Object(openapi.Fields{
+				"zone":     openapi.String(),
+				"handover": openapi.Ref("ReportedShift").Nullable(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/shifts", Tag: "shifts", Summary: "История смен пользователя",
+			Params:   listParams(repositories.ShiftHistorySpec),
+			Response: page(openapi.Ref("ShiftHistoryItem"))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/users/{userID}/shifts", Tag: "shifts", Summary: "История смен сотрудника",
+			Params:   append([]openapi.Param{userID}, listParams(repositories.ShiftHistorySpec)...),
+			Response: page(openapi.Ref("ShiftHistoryItem"))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/users", Tag: "shifts", Summary: "Сотрудники; не персоналу — только id и имя",
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"id":         openapi.Integer(),
+				"first_name": openapi.String(),
+				"username":   openapi.String().Optional(),
+				"role":       openapi.String().Optional(),
+			})).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/scooter-stats/shift", Tag: "shifts", Summary: "Собранные самокаты за текущую смену",
+			Response: openapi.Object(openapi.Fields{
+				"shift_name": openapi.String(),
+				"start_time": openapi.DateTime(),
+				"end_time":   openapi.DateTime(),
+				"stats": openapi.Map(openapi.Object(openapi.Fields{
+					"username":  openapi.String(),
+					"full_name": openapi.String(),
+					"services":  openapi.Map(openapi.Integer()).Nullable(),
+					"total":     openapi.Integer(),
+				})).Nullable().Desc("Ключ — user_id"),
+				"totals":    openapi.Map(openapi.Integer()).Nullable(),
+				"total_all": openapi.Integer(),
+			})},
+
+		// Геотрекинг
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/geo", Tag: "geo", Summary: "Точка геотрека",
+			Body: openapi.Input(openapi.Fields{
+				"lat":      openapi.Number().Range(-90, 90),
+				"lon":      openapi.Number().Range(-180, 180),
+				"speed":    openapi.Number(),
+				"accuracy": openapi.Number(),
+				"battery":  openapi.Integer().Range(0, 100),
+				"event":    openapi.String(),
+				"ts":       openapi.DateTime(),
+			}, "lat", "lon"), BodyRequired: true,
+			Response: status()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/last", Tag: "geo", Summary: "Последние координаты сотрудников",
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"user_id": openapi.String(),
+				"lat":     openapi.Number(),
+				"lon":     openapi.Number(),
+				"battery": openapi.Integer(),
+				"ts":      openapi.DateTime(),
+			})).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/history", Tag: "geo", Summary: "Геотрек сотрудника за период",
+			Params: []openapi.Param{
+				openapi.Query("user_id", openapi.String(), "").Require(),
+				openapi.Query("from", openapi.DateTime(), "").Require(),
+				openapi.Query("to", openapi.DateTime(), "").Require(),
+			},
+			Response: openapi.Array(openapi.Ref("GeoUpdate")).Nullable()},
+
+		// Файлы
+		&openapi.Operation{Method: http.MethodGet, Path: "/uploads/*", Tag: "uploads", Summary: "Загруженный файл по подписанной ссылке", Public: true,
+			Params: []openapi.Param{
+				openapi.PathParam("*", openapi.String()),
+				openapi.Query("expires", openapi.Integer(), "Срок действия подписи, unix"),
+				openapi.Query("sig", openapi.String(), "Подпись"),
+			},
+			ContentType: "image/*"},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/uploads/sign", Tag: "uploads", Summary: "Подписанная ссылка на загрузку",
+			Params:   []openapi.Param{openapi.Query("path", openapi.String(), "/uploads/...").Require()},
+			Response: openapi.Object(openapi.Fields{"url": openapi.String()})},
+
+		// Карты
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/maps", Tag: "maps", Summary: "Карты",
+			Params:   listParams(mapHandlers.MapsSpec),
+			Response: page(openapi.Ref("Map"))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/maps/{mapID}", Tag: "maps", Summary: "Карта",
+			Params:   []openapi.Param{openapi.PathParam("mapID", openapi.Integer())},
+			Response: openapi.Ref("Map")},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/maps/files/{filename}", Tag: "maps", Summary: "Файл карты",
+			Params:      []openapi.Param{openapi.PathParam("filename", openapi.String())},
+			ContentType: "application/geo+json"},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/maps/upload", Tag: "maps", Summary: "Загрузка карты",
+			Multipart: openapi.Object(openapi.Fields{
+				"city":         openapi.String(),
+				"description":  openapi.String().Optional(),
+				"geojson_file": openapi.Binary(),
+			}),
+			Status: http.StatusCreated,
+			Response: openapi.Object(openapi.Fields{
+				"id":          openapi.Integer(),
+				"city":        openapi.String(),
+				"description": openapi.String(),
+				"file_name":   openapi.String(),
+				"file_size":   openapi.Integer(),
+				"message":     openapi.String(),
+			})},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/maps/{mapID}", Tag: "maps", Summary: "Удаление карты",
+			Params:   []openapi.Param{openapi.PathParam("mapID", openapi.Integer())},
+			Response: message()},
+
+		// Версии приложения
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/app/version/check", Tag: "app", Summary: "Проверка обновления",
+			Body: openapi.Input(openapi.Fields{
+				"platform":        openapi.String().Desc("android или ios; по умолчанию — по User-Agent"),
+				"current_version": openapi.String(),
+				"build_number":    openapi.Integer(),
+				"device_info":     openapi.String(),
+			}), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"has_update":     openapi.Boolean(),
+				"latest_version": openapi.Ref("AppVersion").Optional(),
+				"message":        openapi.String().Optional(),
+				"is_mandatory":   openapi.Boolean(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/app/version/latest", Tag: "app", Summary: "Последняя версия",
+			Params:   []openapi.Param{openapi.Query("platform", openapi.String(), "По умолчанию — по User-Agent")},
+			Response: openapi.Ref("AppVersion")},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/app/versions", Tag: "app", Summary: "Версии приложения",
+			Params:   append([]openapi.Param{openapi.Query("platform", openapi.String(), "")}, listParams(repositories.AppVersionsSpec)...),
+			Response: page(openapi.Ref("AppVersion"))},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/app/versions", Tag: "app", Summary: "Новая версия",
+			Body: appVersionInput(), BodyRequired: true,
+			Status: http.StatusCreated, Response: openapi.Ref("AppVersion")},
+		&openapi.Operation{Method: http.MethodPut, Path: "/api/admin/app/versions/{id}", Tag: "app", Summary: "Изменение версии",
+			Params: []openapi.Param{openapi.PathParam("id", openapi.Integer())},
+			Body:   appVersionInput(), BodyRequired: true,
+			Response: openapi.Ref("AppVersion")},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/app/versions/{id}", Tag: "app", Summary: "Удаление версии",
+			Params:   []openapi.Param{openapi.PathParam("id", openapi.Integer())},
+			Response: message()},
+
+		// Промокоды
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/promo/upload", Tag: "promo", Summary: "Загрузка промокодов из файла или Google Sheets",
+			Body:      openapi.Input(openapi.Fields{"google_sheet_url": openapi.String()}, "google_sheet_url"),
+			Multipart: openapi.Object(openapi.Fields{"file": openapi.Binary()}),
+			Response:  status()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/promo/stats", Tag: "promo", Summary: "Остаток промокодов",
+			Response: openapi.Object(openapi.Fields{
+				"summary": openapi.Map(openapi.Integer()),
+				"by_date": openapi.Array(openapi.Object(openapi.Fields{
+					"valid_until": openapi.String(),
+					"counts":      openapi.Map(openapi.Integer()),
+				})).Nullable(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/promo/claim/{brand}", Tag: "promo", Summary: "Получение промокода бренда",
+			Params: []openapi.Param{openapi.PathParam("brand", openapi.String().Desc("JET, YANDEX, WHOOSH или BOLT, без учёта регистра"))},
+			Response: openapi.Object(openapi.Fields{
+				"promo_codes":     openapi.Array(openapi.String()).Nullable(),
+				"already_claimed": openapi.Boolean(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/promo/activate-brand", Tag: "promo", Summary: "Активный бренд промокодов",
+			Body: openapi.Input(openapi.Fields{
+				"brand": openapi.String().Desc("JET, YANDEX, WHOOSH или BOLT"),
+				"days":  openapi.Integer().Desc("По умолчанию 10"),
+			}, "brand"), BodyRequired: true,
+			Response: status()},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/promo/activate-brand", Tag: "promo", Summary: "Сброс активного бренда",
+			Response: status()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/promo/active-brand", Tag: "promo", Summary: "Активный бренд или null",
+			Response: openapi.Object(openapi.Fields{
+				"brand":      openapi.String(),
+				"expires_at": openapi.Date(),
+			}).Nullable()},
+
+		// Смены: персонал
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/active-slots", Tag: "staff", Summary: "Открытые смены",
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"id":              openapi.Integer(),
+				"user_id":         openapi.Integer(),
+				"username":        openapi.String(),
+				"slot_time_range": openapi.String(),
+				"position":        openapi.String(),
+				"zone":            openapi.String(),
+				"start_time":      openapi.DateTime(),
+				"is_active":       openapi.Boolean(),
+				"on_break":        openapi.Boolean(),
+				"break_time":      openapi.String(),
+				"selfie":          openapi.String(),
+				"selfie_thumb":    openapi.String(),
+			}))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/active-shifts", Tag: "staff", Summary: "Открытые смены всех сотрудников",
+			Response: openapi.Array(openapi.Ref("ActiveShift")).Nullable()},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/ended-shifts", Tag: "staff", Summary: "Закрытые смены",
+			Params: listParams(shiftHandlers.EndedShiftsSpec),
+			Response: page(openapi.Object(openapi.Fields{
+				"id":              openapi.Integer(),
+				"user_id":         openapi.Integer(),
+				"username":        openapi.String(),
+				"start_time":      openapi.String(),
+				"end_time":        openapi.String(),
+				"slot_time_range": openapi.String(),
+				"position":        openapi.String(),
+				"zone":            openapi.String(),
+				"selfie":          openapi.String(),
+				"selfie_thumb":    openapi.String(),
+				"flags":           openapi.Ref("ShiftFlags"),
+			}))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/shifts/date/{date}", Tag: "staff", Summary: "Смены за день",
+			Params: []openapi.Param{openapi.PathParam("date", openapi.Date())},
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"id":           openapi.Integer(),
+				"user_id":      openapi.Integer(),
+				"username":     openapi.String(),
+				"first_name":   openapi.String(),
+				"start_time":   openapi.String(),
+				"shift_type":   openapi.String(),
+				"position":     openapi.String(),
+				"zone":         openapi.String(),
+				"selfie":       openapi.String(),
+				"selfie_thumb": openapi.String(),
+				"end_time":     openapi.String(),
+			})).Nullable()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/generate-shifts", Tag: "staff", Summary: "План выходов скаутов на день",
+			Body: openapi.Input(openapi.Fields{
+				"date":          openapi.Date(),
+				"morning_count": openapi.Integer().Min(0),
+				"evening_count": openapi.Integer().Min(0),
+				"scout_ids":     openapi.Array(openapi.Integer()),
+				"zone":          openapi.String(),
+			}, "date"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"status":      openapi.String(),
+				"message":     openapi.String(),
+				"assignments": openapi.Array(openapi.Ref("ShiftAssignment")),
+				"skipped":     openapi.Integer(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/punctuality", Tag: "staff", Summary: "Пунктуальность за период",
+			Params: []openapi.Param{
+				openapi.Query("from", openapi.Date(), "По умолчанию — 29 дней назад"),
+				openapi.Query("to", openapi.Date(), "По умолчанию — сегодня"),
+			},
+			Response: openapi.Object(openapi.Fields{
+				"from":          openapi.Date(),
+				"to":            openapi.Date(),
+				"grace_minutes": openapi.Integer(),
+				"scouts": openapi.Array(openapi.Object(openapi.Fields{
+					"user_id":             openapi.Integer(),
+					"username":            openapi.String(),
+					"shifts":              openapi.Integer(),
+					"late_shifts":         openapi.Integer(),
+					"late_minutes":        openapi.Integer(),
+					"early_leave_shifts":  openapi.Integer(),
+					"early_leave_minutes": openapi.Integer(),
+					"auto_closed_shifts":  openapi.Integer(),
+					"planned":             openapi.Integer(),
+					"no_shows":            openapi.Integer(),
+					"on_time_rate":        openapi.Number(),
+				})),
+				"no_shows": openapi.Array(openapi.Ref("ShiftAssignment")),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/shift-reports", Tag: "staff", Summary: "Отчёты о сменах за день",
+			Params: []openapi.Param{
+				openapi.Query("date", openapi.Date(), "По умолчанию — сегодня"),
+				openapi.Query("zone", openapi.String(), "По умолчанию — все зоны"),
+			},
+			Response: openapi.Object(openapi.Fields{
+				"date":            openapi.Date(),
+				"zone":            openapi.String(),
+				"shifts":          openapi.Array(openapi.Ref("ReportedShift")),
+				"scooters_total":  openapi.Map(openapi.Integer()),
+				"missing_reports": openapi.Integer(),
+			})},
+
+		// Пользователи: суперадмин
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/users", Tag: "admin", Summary: "Пользователи",
+			Params: append(listParams(adminHandlers.AdminUsersSpec),
+				openapi.Query("include_deleted", openapi.Boolean(), "Показать удалённых")),
+			Response: page(openapi.Object(openapi.Fields{
+				"id":          openapi.Integer(),
+				"username":    openapi.String(),
+				"first_name":  openapi.String(),
+				"role":        openapi.String(),
+				"status":      openapi.String().Nullable(),
+				"is_active":   openapi.Boolean(),
+				"created_at":  openapi.DateTime(),
+				"promo_codes": openapi.Map(openapi.Array(openapi.String())).Nullable(),
+				"deleted_at":  openapi.DateTime().Nullable(),
+			}))},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users", Tag: "admin", Summary: "Новый скаут с временным паролем",
+			Body: openapi.Input(openapi.Fields{
+				"username":   openapi.String(),
+				"first_name": openapi.String(),
+			}, "username"), BodyRequired: true,
+			Status: http.StatusCreated,
+			Response: openapi.Object(openapi.Fields{
+				"message":            openapi.String(),
+				"user_id":            openapi.Integer(),
+				"temporary_password": openapi.String(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/approvals", Tag: "admin", Summary: "Заявки на доступ",
+			Response: openapi.Array(openapi.Object(openapi.Fields{
+				"id":                        openapi.Integer(),
+				"username":                  openapi.String(),
+				"first_name":                openapi.String().Nullable(),
+				"last_name":                 openapi.String().Nullable(),
+				"phone":                     openapi.String().Nullable(),
+				"telegram_id":               openapi.Integer().Nullable(),
+				"avatar_url":                openapi.String().Nullable(),
+				"created_at":                openapi.DateTime(),
+				"registration_submitted_at": openapi.DateTime().Nullable(),
+			}))},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/approvals/{userID}/approve", Tag: "admin", Summary: "Одобрение заявки",
+			Params:   []openapi.Param{userID},
+			Body:     openapi.Input(openapi.Fields{"reason": openapi.String()}),
+			Response: approvalResponse()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/approvals/{userID}/reject", Tag: "admin", Summary: "Отклонение заявки",
+			Params: []openapi.Param{userID},
+			Body:   openapi.Input(openapi.Fields{"reason": openapi.String()}, "reason"), BodyRequired: true,
+			Response: approvalResponse()},
+		&openapi.Operation{Method: http.MethodPatch, Path: "/api/admin/users/{userID}/role", Tag: "admin", Summary: "Смена роли",
+			Params: []openapi.Param{userID},
+			Body:   openapi.Input(openapi.Fields{"role": openapi.String()}, "role"), BodyRequired: true,
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPatch, Path: "/api/admin/users/{userID}/status", Tag: "admin", Summary: "Смена статуса",
+			Params: []openapi.Param{userID},
+			Body: openapi.Input(openapi.Fields{
+				"status": openapi.String().OneOf(adminHandlers.StatusActive, adminHandlers.StatusPending, adminHandlers.StatusRejected, adminHandlers.StatusBlocked),
+				"reason": openapi.String().Desc("Обязательна для rejected и blocked"),
+			}, "status"), BodyRequired: true,
+			Response: message()},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/users/{userID}", Tag: "admin", Summary: "Удаление пользователя",
+			Params:   []openapi.Param{userID},
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/restore", Tag: "admin", Summary: "Восстановление удалённого",
+			Params:   []openapi.Param{userID},
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/anonymize", Tag: "admin", Summary: "Обезличивание удалённого",
+			Params:   []openapi.Param{userID},
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/temporary-password", Tag: "admin", Summary: "Временный пароль",
+			Params: []openapi.Param{userID},
+			Response: openapi.Object(openapi.Fields{
+				"user_id":            openapi.Integer(),
+				"temporary_password": openapi.String(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/unlock-login", Tag: "admin", Summary: "Снятие блокировки входа",
+			Params:   []openapi.Param{userID},
+			Body:     openapi.Input(openapi.Fields{"ip": openapi.String().Desc("Снять и блокировку адреса")}),
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/merge", Tag: "admin", Summary: "Слияние аккаунтов",
+			Params: []openapi.Param{userID},
+			Body:   openapi.Input(openapi.Fields{"source_user_id": openapi.Integer()}, "source_user_id"), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"message": openapi.String(),
+				"user_id": openapi.Integer(),
+				"moved":   openapi.Map(openapi.Integer()).Desc("Таблица → перенесено строк"),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/users/{userID}/sessions", Tag: "admin", Summary: "Сессии пользователя",
+			Params:   []openapi.Param{userID},
+			Response: openapi.Array(openapi.Ref("Session")).Nullable()},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/users/{userID}/sessions", Tag: "admin", Summary: "Завершение всех сессий пользователя",
+			Params:   []openapi.Param{userID},
+			Response: message()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/roles", Tag: "admin", Summary: "Новая роль",
+			Body: openapi.Input(openapi.Fields{"name": openapi.String()}, "name"), BodyRequired: true,
+			Status: http.StatusCreated, Response: message()},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/roles", Tag: "admin", Summary: "Удаление роли",
+			Body: openapi.Input(openapi.Fields{"name": openapi.String()}, "name"), BodyRequired: true,
+			Response: message()},
+
+		// Правка смен: суперадмин
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/users/{userID}/end-shift", Tag: "admin-shifts", Summary: "Принудительное закрытие смены",
+			Params: []openapi.Param{userID},
+			Body:   correction(openapi.Fields{"end_time": openapi.DateTime().Nullable()}), BodyRequired: true,
+			Response: openapi.Object(openapi.Fields{
+				"message":     openapi.String(),
+				"worked_time": openapi.String(),
+				"slot":        openapi.Ref("CorrectedShift"),
+			})},
+		&openapi.Operation{Method: http.MethodPatch, Path: "/api/admin/shifts/{slotID}", Tag: "admin-shifts", Summary: "Правка смены",
+			Params: []openapi.Param{slotID},
+			Body: correction(openapi.Fields{
+				"start_time":      openapi.DateTime().Nullable(),
+				"end_time":        openapi.DateTime().Nullable(),
+				"zone":            openapi.String().Nullable(),
+				"slot_time_range": openapi.String().Nullable(),
+			}), BodyRequired: true,
+			Response: openapi.Ref("CorrectedShift")},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/shifts/{slotID}/void", Tag: "admin-shifts", Summary: "Аннулирование смены",
+			Params: []openapi.Param{slotID},
+			Body:   correction(openapi.Fields{}), BodyRequired: true,
+			Response: openapi.Ref("CorrectedShift")},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/shifts/{slotID}/corrections", Tag: "admin-shifts", Summary: "История правок смены",
+			Params: []openapi.Param{slotID},
+			Response: openapi.Object(openapi.Fields{
+				"slot":        openapi.Ref("CorrectedShift"),
+				"original":    openapi.Ref("ShiftValues"),
+				"corrections": openapi.Array(openapi.Ref("ShiftCorrection")).Nullable(),
+			})},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/auto-end-shifts", Tag: "admin-shifts", Summary: "Автозакрытие просроченных смен",
+			Response: openapi.Object(openapi.Fields{
+				"message":     openapi.String(),
+				"slots_ended": openapi.Integer(),
+				"ended": openapi.Array(openapi.Object(openapi.Fields{
+					"id":          openapi.Integer(),
+					"user_id":     openapi.Integer(),
+					"username":    openapi.String(),
+					"worked_time": openapi.String(),
+					"break_time":  openapi.String(),
+					"flags":       openapi.Ref("ShiftFlags"),
+				})),
+				"breaks_closed": openapi.Integer(),
+				"processed_at":  openapi.DateTime(),
+			})},
+
+		// Зоны
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/zones", Tag: "zones", Summary: "Зоны",
+			Response: openapi.Array(openapi.Ref("Zone")).Nullable()},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/zones", Tag: "zones", Summary: "Новая зона",
+			Body: openapi.Input(openapi.Fields{"name": openapi.String()}, "name"), BodyRequired: true,
+			Status: http.StatusCreated, Response: openapi.Ref("Zone")},
+		&openapi.Operation{Method: http.MethodPut, Path: "/api/admin/zones/{id}", Tag: "zones", Summary: "Переименование зоны",
+			Params: []openapi.Param{openapi.PathParam("id", openapi.Integer())},
+			Body:   openapi.Input(openapi.Fields{"name": openapi.String()}, "name"), BodyRequired: true,
+			Response: openapi.Ref("Zone")},
+		&openapi.Operation{Method: http.MethodDelete, Path: "/api/admin/zones/{id}", Tag: "zones", Summary: "Удаление зоны",
+			Params:   []openapi.Param{openapi.PathParam("id", openapi.Integer())},
+			Response: status()},
+
+		// Загрузки
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/uploads/usage", Tag: "uploads", Summary: "Место под загрузки",
+			Response: openapi.Object(openapi.Fields{
+				"kinds": openapi.Array(openapi.Object(openapi.Fields{
+					"kind":        openapi.String(),
+					"files":       openapi.Integer(),
+					"bytes":       openapi.Integer(),
+					"thumb_files": openapi.Integer(),
+					"thumb_bytes": openapi.Integer(),
+					"oldest":      openapi.DateTime().Optional(),
+					"newest":      openapi.DateTime().Optional(),
+				})).Nullable(),
+				"total_bytes":    openapi.Integer(),
+				"retention_days": openapi.Integer(),
+				"retained_kinds": openapi.Array(openapi.String()),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/uploads/cleanup", Tag: "uploads", Summary: "Удаление загрузок старше срока хранения",
+			Response: openapi.Object(openapi.Fields{
+				"cutoff":          openapi.DateTime(),
+				"deleted_files":   openapi.Integer(),
+				"freed_bytes":     openapi.Integer(),
+				"cleared_shifts":  openapi.Integer(),
+				"cleared_reports": openapi.Integer(),
+			})},
+
+		// Табели
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/timesheets/{period}", Tag: "timesheets", Summary: "Табель за месяц",
+			Params:   []openapi.Param{period},
+			Response: openapi.Ref("Timesheet")},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/timesheets/{period}/export", Tag: "timesheets", Summary: "Выгрузка табеля",
+			Params: []openapi.Param{
+				period,
+				openapi.Query("format", openapi.String().OneOf("xlsx", "csv"), "По умолчанию xlsx"),
+			},
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/timesheets/{period}/approve", Tag: "timesheets", Summary: "Утверждение табеля",
+			Params:   []openapi.Param{period},
+			Response: openapi.Ref("Timesheet")},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/timesheets/{period}/reopen", Tag: "timesheets", Summary: "Возврат табеля в работу",
+			Params: []openapi.Param{period},
+			Response: openapi.Object(openapi.Fields{
+				"message": openapi.String(),
+				"period":  openapi.String(),
+			})},
+		&openapi.Operation{Method: http.MethodPost, Path: "/api/admin/timesheets/{period}/lock", Tag: "timesheets", Summary: "Передача табеля в расчёт зарплаты",
+			Params:   []openapi.Param{period},
+			Response: openapi.Ref("Timesheet")},
+
+		// Журнал действий
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/audit-log", Tag: "audit", Summary: "Журнал действий администраторов",
+			Params: append(auditParams(),
+				openapi.Query("limit", openapi.Integer().Min(0), "По умолчанию 100, не больше 500"),
+				openapi.Query("offset", openapi.Integer().Min(0), "")),
+			Response: openapi.Array(openapi.Ref("AuditEntry"))},
+		&openapi.Operation{Method: http.MethodGet, Path: "/api/admin/audit-log/export", Tag: "audit", Summary: "Выгрузка журнала в CSV",
+			Params:      auditParams(),
+			ContentType: "text/csv"},
+	)
+	return spec
+}
+
+func telegramAuthResponse() *openapi.Schema {
+	return openapi.Object(openapi.Fields{
+		"token":         openapi.String().Optional().Desc("Нет, пока заявка ждёт одобрения"),
+		"refresh_token": openapi.String().Optional(),
+		"message":       openapi.String().Optional(),
+		"user_id":       openapi.Integer(),
+		"username":      openapi.String(),
+		"first_name":    openapi.String(),
+		"telegram_id":   openapi.Integer(),
+		"role":          openapi.String(),
+		"status":        openapi.String(),
+	})
+}
+
+func approvalResponse() *openapi.Schema {
+	return openapi.Object(openapi.Fields{
+		"message": openapi.String(),
+		"status":  openapi.String(),
+	})
+}
+
+func appVersionInput() *openapi.Schema {
+	return openapi.Input(openapi.Fields{
+		"platform":        openapi.String(),
+		"version":         openapi.String(),
+		"build_number":    openapi.Integer(),
+		"release_notes":   openapi.String(),
+		"download_url":    openapi.String(),
+		"min_sdk_version": openapi.Integer(),
+		"is_mandatory":    openapi.Boolean(),
+		"is_active":       openapi.Boolean(),
+	})
+}
+
+func auditParams() []openapi.Param {
+	return []openapi.Param{
+		openapi.Query("actor_id", openapi.Integer(), ""),
+		openapi.Query("action", openapi.String(), ""),
+		openapi.Query("target_type", openapi.String(), ""),
+		openapi.Query("target_id", openapi.String(), ""),
+		openapi.Query("from", openapi.DateTime(), ""),
+		openapi.Query("to", openapi.DateTime(), ""),
+	}
+}
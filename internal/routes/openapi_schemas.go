@@ -0,0 +1,261 @@
+package routes
+
+import (
+	"sort"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/openapi"
+)
+
+// apiSchemas — общие схемы ответов (components/schemas).
+func apiSchemas() map[string]*openapi.Schema {
+	return map[string]*openapi.Schema{
+		"Message": openapi.Object(openapi.Fields{"message": openapi.String()}),
+		"Status":  openapi.Object(openapi.Fields{"status": openapi.String()}),
+		"Tokens": openapi.Object(openapi.Fields{
+			"token":         openapi.String(),
+			"refresh_token": openapi.String(),
+		}),
+		"Session": openapi.Object(openapi.Fields{
+			"id":           openapi.String(),
+			"user_id":      openapi.Integer(),
+			"device_id":    openapi.String().Optional(),
+			"device_name":  openapi.String().Optional(),
+			"ip":           openapi.String().Optional(),
+			"user_agent":   openapi.String().Optional(),
+			"created_at":   openapi.DateTime(),
+			"last_seen_at": openapi.DateTime(),
+		}),
+
+		"ShiftFlags": openapi.Object(openapi.Fields{
+			"late":                openapi.Boolean(),
+			"late_minutes":        openapi.Integer(),
+			"left_early":          openapi.Boolean(),
+			"early_leave_minutes": openapi.Integer(),
+			"auto_closed":         openapi.Boolean(),
+			"corrected":           openapi.Boolean(),
+			"voided":              openapi.Boolean(),
+		}),
+		"ShiftHistoryItem": openapi.Object(openapi.Fields{
+			"date":             openapi.Date(),
+			"selected_slot":    openapi.String(),
+			"worked_time":      openapi.String().Desc("ЧЧ:ММ:СС"),
+			"break_time":       openapi.String(),
+			"work_period":      openapi.String(),
+			"transport_status": openapi.String(),
+			"new_tasks":        openapi.Integer(),
+			"flags":            openapi.Ref("ShiftFlags"),
+		}),
+		"ShiftReport": openapi.Object(openapi.Fields{
+			"scooters": openapi.Map(openapi.Integer()).Nullable().Desc("Сервис → собрано самокатов"),
+			"issues":   openapi.Array(openapi.String()).Nullable(),
+			"notes":    openapi.String(),
+			"photos": openapi.Array(openapi.Object(openapi.Fields{
+				"url":   openapi.String(),
+				"thumb": openapi.String(),
+			})),
+			"created_at": openapi.DateTime(),
+		}),
+		"ReportedShift": openapi.Object(openapi.Fields{
+			"slot_id":         openapi.Integer(),
+			"user_id":         openapi.Integer(),
+			"username":        openapi.String(),
+			"zone":            openapi.String(),
+			"slot_time_range": openapi.String(),
+			"start_time":      openapi.DateTime(),
+			"end_time":        openapi.String().Desc("RFC 3339 или пустая строка"),
+			"worked_time":     openapi.String(),
+			"flags":           openapi.Ref("ShiftFlags"),
+			"report":          openapi.Ref("ShiftReport").Nullable(),
+		}),
+		"ActiveShift": openapi.Object(openapi.Fields{
+			"id":              openapi.Integer(),
+			"user_id":         openapi.Integer(),
+			"username":        openapi.String(),
+			"start_time":      openapi.String(),
+			"slot_time_range": openapi.String(),
+			"position":        openapi.String(),
+			"zone":            openapi.String(),
+			"selfie":          openapi.String(),
+			"selfie_thumb":    openapi.String(),
+		}),
+		"ShiftValues": openapi.Object(openapi.Fields{
+			"start_time":          openapi.DateTime(),
+			"end_time":            openapi.DateTime().Nullable(),
+			"zone":                openapi.String(),
+			"slot_time_range":     openapi.String(),
+			"worked_duration":     openapi.Integer().Desc("Секунды"),
+			"break_duration":      openapi.Integer(),
+			"late_minutes":        openapi.Integer(),
+			"early_leave_minutes": openapi.Integer(),
+			"voided":              openapi.Boolean(),
+		}),
+		"CorrectedShift": openapi.Object(openapi.Fields{
+			"id":          openapi.Integer(),
+			"user_id":     openapi.Integer(),
+			"username":    openapi.String(),
+			"values":      openapi.Ref("ShiftValues"),
+			"worked_time": openapi.String(),
+			"flags":       openapi.Ref("ShiftFlags"),
+		}),
+		"ShiftCorrection": openapi.Object(openapi.Fields{
+			"id":         openapi.Integer(),
+			"slot_id":    openapi.Integer(),
+			"action":     openapi.String().OneOf(models.ShiftCorrectionForceEnd, models.ShiftCorrectionEdit, models.ShiftCorrectionVoid),
+			"reason":     openapi.String(),
+			"comment":    openapi.String(),
+			"before":     openapi.Ref("ShiftValues"),
+			"after":      openapi.Ref("ShiftValues"),
+			"created_by": openapi.Integer().Nullable(),
+			"created_at": openapi.DateTime(),
+		}),
+		"ShiftAssignment": openapi.Object(openapi.Fields{
+			"id":              openapi.Integer(),
+			"user_id":         openapi.Integer(),
+			"username":        openapi.String(),
+			"shift_date":      openapi.Date(),
+			"slot_time_range": openapi.String(),
+			"zone":            openapi.String(),
+			"slot_id":         openapi.Integer().Nullable().Desc("Смена, которой выход отработан"),
+		}),
+
+		"TimesheetTotals": timesheetTotals(nil),
+		"Timesheet": openapi.Object(openapi.Fields{
+			"period": openapi.String().Desc("YYYY-MM"),
+			"status": openapi.String().OneOf(models.TimesheetOpen, models.TimesheetApproved, models.TimesheetLocked),
+			"rules": openapi.Object(openapi.Fields{
+				"rounding_minutes":      openapi.Integer(),
+				"rounding_mode":         openapi.String(),
+				"daily_overtime_hours":  openapi.Integer(),
+				"weekly_overtime_hours": openapi.Integer(),
+			}),
+			"employees": openapi.Array(openapi.Object(openapi.Fields{
+				"user_id":    openapi.Integer(),
+				"username":   openapi.String(),
+				"first_name": openapi.String(),
+				"month":      openapi.Ref("TimesheetTotals"),
+				"weeks": openapi.Array(timesheetTotals(openapi.Fields{
+					"week": openapi.String().Desc("2006-W01"),
+					"from": openapi.Date(),
+					"to":   openapi.Date(),
+				})).Nullable(),
+				"days": openapi.Array(timesheetTotals(openapi.Fields{
+					"date": openapi.Date(),
+				})).Nullable(),
+			})).Nullable(),
+			"computed_at": openapi.DateTime(),
+			"approved_by": openapi.Integer().Optional(),
+			"approved_at": openapi.DateTime().Optional(),
+			"locked_by":   openapi.Integer().Optional(),
+			"locked_at":   openapi.DateTime().Optional(),
+		}),
+
+		"AppVersion": openapi.Object(openapi.Fields{
+			"id":              openapi.Integer(),
+			"platform":        openapi.String(),
+			"version":         openapi.String(),
+			"build_number":    openapi.Integer(),
+			"release_notes":   openapi.String(),
+			"download_url":    openapi.String(),
+			"min_sdk_version": openapi.Integer(),
+			"is_mandatory":    openapi.Boolean(),
+			"is_active":       openapi.Boolean(),
+			"created_at":      openapi.DateTime(),
+			"updated_at":      openapi.DateTime(),
+		}),
+		"Zone": openapi.Object(openapi.Fields{
+			"id":   openapi.Integer(),
+			"name": openapi.String(),
+		}),
+		"Map": openapi.Object(openapi.Fields{
+			"id":          openapi.Integer(),
+			"city":        openapi.String(),
+			"description": openapi.String(),
+			"file_name":   openapi.String(),
+			"file_size":   openapi.Integer(),
+			"upload_date": openapi.String(),
+		}),
+		"GeoUpdate": openapi.Object(openapi.Fields{
+			"id":       openapi.Integer().Optional(),
+			"user_id":  openapi.String(),
+			"lat":      openapi.Number(),
+			"lon":      openapi.Number(),
+			"speed":    openapi.Number().Optional(),
+			"accuracy": openapi.Number().Optional(),
+			"battery":  openapi.Integer().Optional(),
+			"event":    openapi.String().Optional(),
+			"ts":       openapi.DateTime(),
+		}),
+		"AuditEntry": openapi.Object(openapi.Fields{
+			"id":          openapi.Integer(),
+			"actor_id":    openapi.Integer().Nullable(),
+			"actor_role":  openapi.String(),
+			"action":      openapi.String(),
+			"target_type": openapi.String(),
+			"target_id":   openapi.String(),
+			"before":      openapi.Any(),
+			"after":       openapi.Any(),
+			"ip":          openapi.String(),
+			"created_at":  openapi.DateTime(),
+		}),
+	}
+}
+
+// timesheetTotals — models.TimesheetTotals и поля, в которые он встроен.
+func timesheetTotals(extra openapi.Fields) *openapi.Schema {
+	fields := openapi.Fields{
+		"shifts":           openapi.Integer(),
+		"worked_minutes":   openapi.Integer(),
+		"regular_minutes":  openapi.Integer(),
+		"overtime_minutes": openapi.Integer(),
+		"by_slot":          openapi.Map(openapi.Integer()).Nullable(),
+		"by_zone":          openapi.Map(openapi.Integer()).Nullable(),
+	}
+	for name, field := range extra {
+		fields[name] = field
+	}
+	return openapi.Object(fields)
+}
+
+// page — конверт response.Page со списком items.
+func page(items *openapi.Schema) *openapi.Schema {
+	return openapi.Object(openapi.Fields{
+		"items":       openapi.Array(items),
+		"total":       openapi.Integer(),
+		"limit":       openapi.Integer(),
+		"offset":      openapi.Integer(),
+		"next_cursor": openapi.String().Optional(),
+		"has_more":    openapi.Boolean(),
+	})
+}
+
+// listParams — query-параметры списка по его listing.Spec: сортировки и
+// фильтры берутся из того же описания, что разбирает listing.Parse.
+func listParams(spec listing.Spec) []openapi.Param {
+	var sorts []string
+	for name := range spec.Sorts {
+		sorts = append(sorts, name, "-"+name)
+	}
+	sort.Strings(sorts)
+
+	params := []openapi.Param{
+		openapi.Query("limit", openapi.Integer().Range(1, listing.MaxLimit), "Размер страницы"),
+		openapi.Query("offset", openapi.Integer().Min(0), "Смещение; нельзя вместе с cursor"),
+		openapi.Query("cursor", openapi.String(), "next_cursor предыдущей страницы"),
+		openapi.Query("sort", openapi.String().OneOf(sorts...), "Поле сортировки, минус — по убыванию; по умолчанию "+spec.DefaultSort),
+	}
+	for _, filter := range spec.Filters {
+		switch filter {
+		case listing.FilterDate:
+			params = append(params,
+				openapi.Query("from", openapi.Date(), "С даты включительно"),
+				openapi.Query("to", openapi.Date(), "По дату включительно"))
+		case listing.FilterUser:
+			params = append(params, openapi.Query(filter, openapi.Integer(), ""))
+		default:
+			params = append(params, openapi.Query(filter, openapi.String(), ""))
+		}
+	}
+	return params
+}
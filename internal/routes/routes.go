@@ -2,6 +2,7 @@ package routes
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 
 	"github.com/evn/eom_backendl/config"
@@ -10,6 +11,7 @@ import (
 	authHandlers "github.com/evn/eom_backendl/internal/handlers/auth"
 	geoHandlers "github.com/evn/eom_backendl/internal/handlers/geo"
 	mapHandlers "github.com/evn/eom_backendl/internal/handlers/map"
+	mediaHandlers "github.com/evn/eom_backendl/internal/handlers/media"
 
 	// "github.com/evn/eom_backendl/internal/handlers/promo"
 	scooterHandlers "github.com/evn/eom_backendl/internal/handlers/scooter"
@@ -21,51 +23,103 @@ import (
 	"github.com/evn/eom_backendl/internal/repositories"
 	authService "github.com/evn/eom_backendl/internal/services/auth"
 	geoService "github.com/evn/eom_backendl/internal/services/geo"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	selfieService "github.com/evn/eom_backendl/internal/services/selfie"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	telegramService "github.com/evn/eom_backendl/internal/services/telegram"
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware" // ← алиас!
-	"github.com/go-chi/jwtauth/v5"
 	"github.com/redis/go-redis/v9"
 )
 
 // Setup инициализирует и возвращает настроенный маршрутизатор.
-func Setup(cfg *config.Config, database *sql.DB, redisClient *redis.Client) *chi.Mux {
-	jwtAuth := jwtauth.New("HS256", []byte(cfg.JwtSecret), nil)
-	jwtService := authService.NewJWTService(cfg.JwtSecret, redisClient)
+func Setup(cfg *config.Config, database *sql.DB, redisClient *redis.Client, store storageService.Storage) *chi.Mux {
+	keySet, err := authService.LoadKeySet(authService.KeySetConfig{
+		PrivateKeyFile: cfg.JwtPrivateKeyFile,
+		PreviousKeys:   cfg.JwtPreviousKeys,
+		HMACSecret:     cfg.JwtSecret,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	jwtService := authService.NewJWTService(keySet, redisClient)
 	telegramAuthService := authService.NewTelegramAuthService(cfg.TelegramBotToken)
+	loginLimiter := authService.NewLoginLimiter(redisClient)
+	telegramBot := telegramService.NewBotClient(cfg.TelegramBotToken)
+	passwordResetService := authService.NewPasswordResetService(redisClient, telegramBot)
+
+	auditLog := NewAuditLogger(database)
+
+	shiftSvc := NewShiftService(database)
+	timesheetSvc := NewTimesheetService(cfg, database)
 
 	posRepo := repositories.NewPositionRepository(database)
 	geoSvc := geoService.NewGeoTrackService(posRepo, redisClient)
 	geoHandler := geoHandlers.NewGeoTrackHandler(geoSvc)
-	authHandler := authHandlers.NewAuthHandler(database, jwtService, telegramAuthService)
+	authHandler := authHandlers.NewAuthHandler(database, jwtService, telegramAuthService, auditLog, loginLimiter)
 	profileHandler := authHandlers.NewProfileHandler(database)
-	mapHandler := mapHandlers.NewMapHandler(database)
+	mapHandler := mapHandlers.NewMapHandler(database, auditLog, store)
 	scooterStatsHandler := scooterHandlers.NewScooterStatsHandler("/root/tg_bot/Sharing/scooters.db")
-	appVersionHandler := handlers.NewAppVersionHandler(database)
-
+	appVersionHandler := handlers.NewAppVersionHandler(database, auditLog)
+	var presigner storageService.Presigner
+	if p, ok := store.(storageService.Presigner); ok && cfg.S3PresignedURLs {
+		presigner = p
+	}
+	urlSigner := mediaService.NewURLSigner(cfg.UploadsSigningKey, cfg.SignedURLTTL, presigner)
+	imageProcessor := mediaService.NewImageProcessor(cfg.UploadMaxDimension, cfg.UploadThumbDimension)
+	selfieVerifier := selfieService.NewVerifier(selfieService.VerifierConfig{
+		MaxClockSkew:    cfg.SelfieMaxClockSkew,
+		RequireExif:     cfg.SelfieRequireExif,
+		ReuseWindow:     cfg.SelfieReuseWindow,
+		MaxHashDistance: cfg.SelfieMaxHashDistance,
+	}, imageProcessor, repositories.NewShiftRepository(database), selfieService.StubFaceMatcher{})
+	uploadRetention := NewUploadRetention(cfg, database, store)
+	uploadsHandler := mediaHandlers.NewUploadsHandler(database, urlSigner, store)
+	sessionHandler := authHandlers.NewSessionHandler(jwtService, auditLog)
+	passwordHandler := authHandlers.NewPasswordHandler(database, jwtService, passwordResetService, loginLimiter, auditLog)
+
+	spec := APISpec()
 	router := chi.NewRouter()
 
 	// Используем chiMiddleware для Logger и Recoverer
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RealIP(cfg.TrustedProxies))
 	router.Use(chiMiddleware.Logger)
 	router.Use(chiMiddleware.Recoverer)
-	router.Use(jwtauth.Verifier(jwtAuth))
+	router.Use(middleware.Locale())
+	router.Use(middleware.Verifier(jwtService))
 	router.Use(middleware.AddUserIDToContext()) // ваш middleware
+	router.Use(spec.Validator(middleware.Authenticated))
+
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		response.RespondWithCode(w, http.StatusNotFound, response.CodeNotFound)
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		response.RespondWithCode(w, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed)
+	})
 
 	// Публичные маршруты
 	router.Post("/api/auth/register", authHandler.RegisterHandler)
 	router.Post("/api/auth/login", authHandler.LoginHandler)
 	router.Post("/api/auth/telegram", authHandler.TelegramAuthHandler)
+	router.Post("/api/auth/telegram/webapp", authHandler.TelegramWebAppAuthHandler)
 	router.Get("/auth_callback", authHandler.TelegramAuthCallbackHandler)
-	router.Get("/api/time-slots/available-for-start", shiftHandlers.GetAvailableTimeSlotsForStartHandler(database))
-	router.Get("/api/users", handlers.ListUsersHandler(database))
-	router.Handle("/uploads/*", http.StripPrefix("/uploads", http.FileServer(http.Dir("./uploads"))))
-	router.Get("/api/active-slots", shiftHandlers.GetActiveShiftsHandler(database))
+	router.Get("/api/time-slots/available-for-start", shiftHandlers.GetAvailableTimeSlotsForStartHandler(shiftSvc))
+	router.Get("/uploads/*", uploadsHandler.ServeUploadHandler)
 	router.Post("/api/auth/refresh", authHandler.RefreshTokenHandler)
+	router.Post("/api/auth/password-reset/request", passwordHandler.RequestReset)
+	router.Post("/api/auth/password-reset/confirm", passwordHandler.ConfirmReset)
+	router.Get("/.well-known/jwks.json", authHandler.JWKSHandler)
+	router.Get(DocsPath, spec.DocsHandler(SpecPath))
+	router.Get(SpecPath, spec.SpecHandler())
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		response.RespondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(jwtauth.Authenticator(jwtAuth))
+		r.Use(middleware.Authenticator())
+		r.Use(middleware.SessionGuard(jwtService))
+		r.Use(middleware.RequirePasswordChanged("/api/profile/password", "/api/logout"))
 
 		r.Post("/api/promo/upload", promoHandlers.UploadPromoCodesHandler(database))
 		r.Get("/api/promo/stats", promoHandlers.GetPromoStatsHandler(database))
@@ -73,24 +127,31 @@ func Setup(cfg *config.Config, database *sql.DB, redisClient *redis.Client) *chi
 
 		// Остальные маршруты
 		r.Get("/api/profile", profileHandler.GetProfile)
+		r.Post("/api/profile/password", passwordHandler.ChangePassword)
+		r.Post("/api/profile/telegram", authHandler.LinkTelegramHandler)
+		r.Delete("/api/profile/telegram", authHandler.UnlinkTelegramHandler)
+		r.Get("/api/users", handlers.ListUsersHandler(database))
+		r.Get("/api/uploads/sign", uploadsHandler.SignUploadHandler)
 		r.Post("/api/logout", authHandler.LogoutHandler)
+		r.Get("/api/sessions", sessionHandler.ListSessions)
+		r.Delete("/api/sessions", sessionHandler.RevokeOtherSessions)
+		r.Delete("/api/sessions/{sessionID}", sessionHandler.RevokeSession)
 		r.Post("/api/auth/complete-registration", authHandler.CompleteRegistrationHandler)
-		r.Get("/api/admin/active-shifts", adminHandlers.GetActiveShiftsForAllHandler(database))
-		r.Get("/api/admin/ended-shifts", shiftHandlers.GetEndedShiftsHandler(database))
-		r.Post("/api/slot/start", shiftHandlers.StartSlotHandler(database))
-		r.Post("/api/slot/end", shiftHandlers.EndSlotHandler(database))
-		r.Get("/api/shifts/active", shiftHandlers.GetUserActiveShiftHandler(database))
-		r.Get("/api/shifts", shiftHandlers.GetShiftsHandler(database))
-		r.Get("/api/shifts/date/{date}", shiftHandlers.GetShiftsByDateHandler(database))
-		r.Get("/api/users/{userID}/shifts", shiftHandlers.GetUserShiftsByIDHandler(database))
+		r.With(middleware.Idempotency(redisClient)).Post("/api/slot/start", shiftHandlers.StartSlotHandler(shiftSvc, selfieVerifier, imageProcessor, store, urlSigner))
+		r.With(middleware.Idempotency(redisClient)).Post("/api/slot/end", shiftHandlers.EndSlotHandler(shiftSvc, imageProcessor, store, urlSigner, cfg.ScooterServices))
+		r.With(middleware.Idempotency(redisClient)).Post("/api/slot/pause", shiftHandlers.PauseSlotHandler(shiftSvc))
+		r.With(middleware.Idempotency(redisClient)).Post("/api/slot/resume", shiftHandlers.ResumeSlotHandler(shiftSvc))
+		r.Get("/api/shifts/active", shiftHandlers.GetUserActiveShiftHandler(shiftSvc, urlSigner))
+		r.Get("/api/shifts/handover", shiftHandlers.GetHandoverHandler(shiftSvc, urlSigner))
+		r.Get("/api/shifts", shiftHandlers.GetShiftsHandler(shiftSvc))
+		r.Get("/api/users/{userID}/shifts", shiftHandlers.GetUserShiftsByIDHandler(shiftSvc))
 		r.Post("/api/geo", geoHandler.PostGeo)
 
 		r.Get("/api/last", geoHandler.GetLast)
 		r.Get("/api/history", geoHandler.GetHistory)
-		r.Get("/api/slots/positions", shiftHandlers.GetAvailablePositionsHandler(database))
-		r.Get("/api/slots/times", shiftHandlers.GetAvailableTimeSlotsHandler(database))
+		r.Get("/api/slots/positions", shiftHandlers.GetAvailablePositionsHandler(shiftSvc))
+		r.Get("/api/slots/times", shiftHandlers.GetAvailableTimeSlotsHandler(shiftSvc))
 		r.Get("/api/slots/zones", handlers.GetAvailableZonesHandler(database))
-		r.Post("/api/admin/generate-shifts", shiftHandlers.GenerateShiftsHandler(database))
 		r.Get("/api/scooter-stats/shift", scooterStatsHandler.GetShiftStatsHandler)
 		r.Get("/api/admin/maps", mapHandler.GetMapsHandler)
 		r.Get("/api/admin/maps/{mapID}", mapHandler.GetMapByIDHandler)
@@ -98,35 +159,76 @@ func Setup(cfg *config.Config, database *sql.DB, redisClient *redis.Client) *chi
 		r.Post("/api/app/version/check", appVersionHandler.CheckVersionHandler)
 		r.Get("/api/app/version/latest", appVersionHandler.GetLatestVersionHandler)
 
+		// Смены и селфи других сотрудников — только персонал
+		r.Group(func(sr chi.Router) {
+			sr.Use(middleware.RequireRoles(middleware.StaffRoles...))
+			sr.Get("/api/active-slots", shiftHandlers.GetActiveShiftsHandler(shiftSvc, urlSigner))
+			sr.Get("/api/admin/active-shifts", adminHandlers.GetActiveShiftsForAllHandler(database, urlSigner))
+			sr.Get("/api/admin/ended-shifts", shiftHandlers.GetEndedShiftsHandler(database, urlSigner))
+			sr.Get("/api/shifts/date/{date}", shiftHandlers.GetShiftsByDateHandler(database, urlSigner))
+			sr.Post("/api/admin/generate-shifts", shiftHandlers.GenerateShiftsHandler(shiftSvc))
+			sr.Get("/api/admin/punctuality", adminHandlers.PunctualityReportHandler(shiftSvc))
+			sr.Get("/api/admin/shift-reports", shiftHandlers.GetShiftReportsHandler(shiftSvc, urlSigner))
+		})
+
 		// Superadmin-only
 		r.Group(func(sr chi.Router) {
 			sr.Use(middleware.SuperadminOnly(jwtService))
 			sr.Get("/api/admin/users", adminHandlers.ListAdminUsersHandler(database))
-			sr.Patch("/api/admin/users/{userID}/role", adminHandlers.UpdateUserRoleHandler(database))
-			sr.Post("/api/admin/roles", adminHandlers.CreateRoleHandler(database))
-			sr.Delete("/api/admin/roles", adminHandlers.DeleteRoleHandler(database))
-			sr.Post("/api/admin/users", adminHandlers.CreateUserHandler(database))
-			sr.Patch("/api/admin/users/{userID}/status", adminHandlers.UpdateUserStatusHandler(database))
-			sr.Delete("/api/admin/users/{userID}", adminHandlers.DeleteUserHandler(database))
-			sr.Post("/api/admin/users/{userID}/end-shift", adminHandlers.ForceEndShiftHandler(database))
+			sr.Get("/api/admin/approvals", adminHandlers.ListPendingApprovalsHandler(database))
+			sr.Post("/api/admin/approvals/{userID}/approve", adminHandlers.ApproveUserHandler(database, auditLog, telegramBot))
+			sr.Post("/api/admin/approvals/{userID}/reject", adminHandlers.RejectUserHandler(database, auditLog, jwtService, telegramBot))
+			sr.Patch("/api/admin/users/{userID}/role", adminHandlers.UpdateUserRoleHandler(database, auditLog, jwtService))
+			sr.Post("/api/admin/roles", adminHandlers.CreateRoleHandler(database, auditLog))
+			sr.Delete("/api/admin/roles", adminHandlers.DeleteRoleHandler(database, auditLog))
+			sr.Post("/api/admin/users", adminHandlers.CreateUserHandler(database, auditLog))
+			sr.Patch("/api/admin/users/{userID}/status", adminHandlers.UpdateUserStatusHandler(database, auditLog, jwtService, telegramBot))
+			sr.Delete("/api/admin/users/{userID}", adminHandlers.DeleteUserHandler(database, auditLog, jwtService))
+			sr.Post("/api/admin/users/{userID}/restore", adminHandlers.RestoreUserHandler(database, auditLog))
+			sr.Post("/api/admin/users/{userID}/anonymize", adminHandlers.AnonymizeUserHandler(database, auditLog, jwtService, store))
+			sr.Post("/api/admin/users/{userID}/unlock-login", adminHandlers.UnlockLoginHandler(database, auditLog, loginLimiter))
+			sr.Post("/api/admin/users/{userID}/end-shift", adminHandlers.ForceEndShiftHandler(shiftSvc, auditLog))
+			sr.Patch("/api/admin/shifts/{slotID}", adminHandlers.EditShiftHandler(shiftSvc, auditLog))
+			sr.Post("/api/admin/shifts/{slotID}/void", adminHandlers.VoidShiftHandler(shiftSvc, auditLog))
+			sr.Get("/api/admin/shifts/{slotID}/corrections", adminHandlers.ShiftCorrectionsHandler(shiftSvc))
 			sr.Post("/api/admin/maps/upload", mapHandler.UploadMapHandler)
 			sr.Delete("/api/admin/maps/{mapID}", mapHandler.DeleteMapHandler)
 			sr.Get("/api/admin/zones", handlers.GetAvailableZonesHandler(database))
-			sr.Post("/api/admin/zones", handlers.CreateZoneHandler(database))
-			sr.Put("/api/admin/zones/{id}", handlers.UpdateZoneHandler(database))
-			sr.Delete("/api/admin/zones/{id}", handlers.DeleteZoneHandler(database))
+			sr.Post("/api/admin/zones", handlers.CreateZoneHandler(database, auditLog))
+			sr.Put("/api/admin/zones/{id}", handlers.UpdateZoneHandler(database, auditLog))
+			sr.Delete("/api/admin/zones/{id}", handlers.DeleteZoneHandler(database, auditLog))
 
-			r.Post("/api/admin/promo/activate-brand", promoHandlers.SetActivePromoBrandHandler(database))
-			r.Delete("/api/admin/promo/activate-brand", promoHandlers.ClearActivePromoBrandHandler(database))
-			r.Get("/api/admin/promo/active-brand", promoHandlers.GetActivePromoBrandHandler(database))
+			sr.Post("/api/admin/promo/activate-brand", promoHandlers.SetActivePromoBrandHandler(database, auditLog))
+			sr.Delete("/api/admin/promo/activate-brand", promoHandlers.ClearActivePromoBrandHandler(database, auditLog))
+			sr.Get("/api/admin/promo/active-brand", promoHandlers.GetActivePromoBrandHandler(database))
 
 			sr.Get("/api/admin/app/versions", appVersionHandler.ListVersionsHandler)
 			sr.Post("/api/admin/app/versions", appVersionHandler.CreateVersionHandler)
 			sr.Put("/api/admin/app/versions/{id}", appVersionHandler.UpdateVersionHandler)
 			sr.Delete("/api/admin/app/versions/{id}", appVersionHandler.DeleteVersionHandler)
-			sr.Get("/api/admin/auto-end-shifts", handlers.AutoEndShiftsHandler(database))
+			sr.Get("/api/admin/auto-end-shifts", handlers.AutoEndShiftsHandler(shiftSvc, auditLog))
+			sr.Get("/api/admin/uploads/usage", adminHandlers.UploadsUsageHandler(store, uploadRetention))
+			sr.Post("/api/admin/uploads/cleanup", adminHandlers.RunUploadsCleanupHandler(uploadRetention, auditLog))
+			sr.Get("/api/admin/timesheets/{period}", adminHandlers.GetTimesheetHandler(timesheetSvc))
+			sr.Get("/api/admin/timesheets/{period}/export", adminHandlers.ExportTimesheetHandler(timesheetSvc))
+			sr.Post("/api/admin/timesheets/{period}/approve", adminHandlers.ApproveTimesheetHandler(timesheetSvc, auditLog))
+			sr.Post("/api/admin/timesheets/{period}/reopen", adminHandlers.ReopenTimesheetHandler(timesheetSvc, auditLog))
+
+			sr.Group(func(ar chi.Router) {
+				ar.Use(middleware.RequireRoles("superadmin"))
+				ar.Get("/api/admin/audit-log", adminHandlers.ListAuditLogHandler(database))
+				ar.Get("/api/admin/audit-log/export", adminHandlers.ExportAuditLogHandler(database))
+				ar.Post("/api/admin/timesheets/{period}/lock", adminHandlers.LockTimesheetHandler(timesheetSvc, auditLog))
+				ar.Post("/api/admin/users/{userID}/temporary-password", adminHandlers.IssueTemporaryPasswordHandler(database, auditLog, jwtService))
+				ar.Post("/api/admin/users/{userID}/merge", adminHandlers.MergeUsersHandler(database, auditLog, jwtService))
+				ar.Get("/api/admin/users/{userID}/sessions", sessionHandler.ListUserSessions)
+				ar.Delete("/api/admin/users/{userID}/sessions", sessionHandler.RevokeUserSessions)
+			})
 		})
 	})
 
+	if err := spec.CheckRoutes(router); err != nil {
+		log.Fatalf("API spec: %v", err)
+	}
 	return router
 }
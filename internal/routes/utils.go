@@ -1,44 +1,139 @@
 package routes
 
 import (
+	"context"
+	"database/sql"
 	"log"
-	"os"
+	"strconv"
 	"time"
 
-	"database/sql"
-
-	"github.com/evn/eom_backendl/internal/handlers"
+	"github.com/evn/eom_backendl/config"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/repositories"
+	auditService "github.com/evn/eom_backendl/internal/services/audit"
+	mediaService "github.com/evn/eom_backendl/internal/services/media"
+	shiftService "github.com/evn/eom_backendl/internal/services/shift"
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+	timesheetService "github.com/evn/eom_backendl/internal/services/timesheet"
 )
 
-func EnsureUploadDirs() error {
-	dirs := []string{
-		"./uploads/selfies",
-		"./uploads/maps",
-		"./uploads/app",
+// NewStorage создаёт хранилище загрузок по STORAGE_BACKEND.
+func NewStorage(ctx context.Context, cfg *config.Config) (storageService.Storage, error) {
+	if cfg.StorageBackend == "s3" {
+		return storageService.NewS3Storage(ctx, storageService.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			UseSSL:    cfg.S3UseSSL,
+		})
 	}
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
-		}
-	}
-	return nil
+	return storageService.NewLocalStorage(cfg.StorageLocalRoot), nil
+}
+
+// NewShiftService собирает сервис смен поверх Postgres-репозиториев.
+func NewShiftService(database *sql.DB) *shiftService.ShiftService {
+	return shiftService.NewShiftService(
+		repositories.NewTxManager(database),
+		repositories.NewShiftRepository(database),
+		repositories.NewBreakRepository(database),
+		repositories.NewUserRepository(database),
+		repositories.NewZoneRepository(database),
+		repositories.NewAssignmentRepository(database),
+		repositories.NewReportRepository(database),
+		repositories.NewCorrectionRepository(database),
+		repositories.NewTimesheetRepository(database),
+	)
 }
 
-func AutoEndShiftsLoop(db *sql.DB) {
+// NewTimesheetService собирает сервис табеля с правилами округления и переработки из конфига.
+func NewTimesheetService(cfg *config.Config, database *sql.DB) *timesheetService.TimesheetService {
+	return timesheetService.NewTimesheetService(
+		repositories.NewTxManager(database),
+		repositories.NewTimesheetRepository(database),
+		models.TimesheetRules{
+			RoundingMinutes:     cfg.TimesheetRoundingMinutes,
+			RoundingMode:        cfg.TimesheetRoundingMode,
+			DailyOvertimeHours:  cfg.TimesheetDailyOvertimeHours,
+			WeeklyOvertimeHours: cfg.TimesheetWeeklyOvertimeHours,
+		},
+	)
+}
+
+// NewAuditLogger — журнал действий поверх Postgres.
+func NewAuditLogger(database *sql.DB) *auditService.AuditLogger {
+	return auditService.NewAuditLogger(repositories.NewAuditRepository(database))
+}
+
+// AutoEndShiftsLoop раз в минуту закрывает истёкшие смены и перерывы;
+// каждое закрытие попадает в журнал действий от имени системы.
+func AutoEndShiftsLoop(shifts *shiftService.ShiftService, auditLog *auditService.AuditLogger) {
 	log.Println("✅ Auto-end shifts job started")
-	if count, err := handlers.AutoEndShifts(db); err != nil {
+	if result, err := shifts.AutoEnd(context.Background()); err != nil {
 		log.Printf("❌ Startup failed: %v", err)
 	} else {
-		log.Printf("✅ Startup: ended %d slots", count)
+		recordAutoEnd(auditLog, result)
+		log.Printf("✅ Startup: ended %d slots, closed %d overdue breaks", len(result.Ended), result.BreaksClosed)
 	}
 
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 	for range ticker.C {
-		if count, err := handlers.AutoEndShifts(db); err != nil {
+		if result, err := shifts.AutoEnd(context.Background()); err != nil {
 			log.Printf("❌ AutoEndShifts failed: %v", err)
-		} else if count > 0 {
-			log.Printf("✅ AutoEndShifts: ended %d expired slots", count)
+		} else {
+			recordAutoEnd(auditLog, result)
+			if len(result.Ended) > 0 {
+				log.Printf("✅ AutoEndShifts: ended %d expired slots", len(result.Ended))
+			}
+			if result.BreaksClosed > 0 {
+				log.Printf("✅ AutoEndShifts: closed %d overdue breaks", result.BreaksClosed)
+			}
+		}
+	}
+}
+
+func recordAutoEnd(auditLog *auditService.AuditLogger, result *shiftService.AutoEndResult) {
+	ctx := context.Background()
+	for _, shift := range result.Ended {
+		auditLog.RecordSystem(ctx, "shift.auto_end", "slot", strconv.Itoa(shift.ID), nil, shift.Values())
+	}
+	if result.BreaksClosed > 0 {
+		auditLog.RecordSystem(ctx, "shift.auto_close_breaks", "slot", "", nil, map[string]int{"breaks_closed": result.BreaksClosed})
+	}
+}
+
+// NewUploadRetention собирает политику хранения селфи, фото заданий и отчётов.
+func NewUploadRetention(cfg *config.Config, database *sql.DB, store storageService.Storage) *mediaService.RetentionPolicy {
+	return mediaService.NewRetentionPolicy(store, cfg.UploadRetention,
+		repositories.NewShiftRepository(database), repositories.NewReportRepository(database))
+}
+
+// UploadRetentionLoop раз в сутки удаляет загрузки старше срока хранения.
+func UploadRetentionLoop(retention *mediaService.RetentionPolicy) {
+	if !retention.Enabled() {
+		log.Println("ℹ️ Upload retention is disabled")
+		return
+	}
+	log.Printf("✅ Upload retention job started (%s)", retention.MaxAge())
+
+	run := func() {
+		result, err := retention.Run(context.Background())
+		if err != nil {
+			log.Printf("❌ Upload retention failed: %v", err)
+			return
 		}
+		if result.DeletedFiles > 0 || result.ClearedShifts > 0 || result.ClearedReports > 0 {
+			log.Printf("✅ Upload retention: deleted %d files (%d bytes), cleared %d shifts and %d reports",
+				result.DeletedFiles, result.FreedBytes, result.ClearedShifts, result.ClearedReports)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		run()
 	}
 }
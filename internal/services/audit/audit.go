@@ -0,0 +1,76 @@
+// services/audit.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/evn/eom_backendl/internal/middleware"
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+// AuditLogger — общий помощник для записи действий администраторов.
+type AuditLogger struct {
+	repo *repositories.AuditRepository
+}
+
+func NewAuditLogger(repo *repositories.AuditRepository) *AuditLogger {
+	return &AuditLogger{repo: repo}
+}
+
+// Record пишет действие текущего пользователя запроса. Ошибка записи
+// только логируется: изменение уже выполнено, откатывать его поздно.
+func (l *AuditLogger) Record(r *http.Request, action, targetType, targetID string, before, after interface{}) {
+	entry := &models.AuditEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     marshalState(before),
+		After:      marshalState(after),
+		IP:         middleware.ClientIP(r),
+	}
+	if userID, ok := middleware.GetUserIDFromContext(r.Context()); ok {
+		entry.ActorID = &userID
+	}
+	if role, ok := middleware.GetUserRoleFromContext(r.Context()); ok {
+		entry.ActorRole = role
+	}
+	l.save(r.Context(), entry)
+}
+
+// RecordSystem пишет действие, выполненное без пользователя (фоновые задачи).
+func (l *AuditLogger) RecordSystem(ctx context.Context, action, targetType, targetID string, before, after interface{}) {
+	l.save(ctx, &models.AuditEntry{
+		ActorRole:  "system",
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     marshalState(before),
+		After:      marshalState(after),
+	})
+}
+
+func (l *AuditLogger) save(ctx context.Context, entry *models.AuditEntry) {
+	if err := l.repo.Save(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("❌ Failed to write audit log (%s %s/%s): %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+func marshalState(state interface{}) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal audit state: %v", err)
+		return nil
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	return data
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -13,23 +14,37 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// MustChangePasswordClaim — claim access-токена после входа по временному паролю.
+const MustChangePasswordClaim = "pwd_change"
+
+// TokenTypeClaim различает access- и refresh-токены: оба подписаны одним
+// ключом и несут user_id и sid, поэтому без него refresh-токен прошёл бы
+// как bearer-токен.
+const (
+	TokenTypeClaim   = "typ"
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type JWTService struct {
-	secretKey   []byte
+	keys        *KeySet
 	accessTTL   time.Duration
 	refreshTTL  time.Duration
 	redisClient *redis.Client
 }
 
-func NewJWTService(secretKey string, redisClient *redis.Client) *JWTService {
+func NewJWTService(keys *KeySet, redisClient *redis.Client) *JWTService {
 	return &JWTService{
-		secretKey:   []byte(secretKey),
+		keys:        keys,
 		accessTTL:   120 * time.Minute,
 		refreshTTL:  14 * 24 * time.Hour,
 		redisClient: redisClient,
 	}
 }
 
-func (s *JWTService) GenerateToken(userID int, username, role string) (string, string, error) {
+// GenerateToken выпускает пару токенов для сессии sessionID (см. CreateSession).
+// mustChangePassword попадает в access-токен: с ним доступна только смена пароля.
+func (s *JWTService) GenerateToken(userID int, username, role, sessionID string, mustChangePassword bool) (string, string, error) {
 	// Генерируем jti для refresh токена
 	refreshJTI, err := s.generateJTI()
 	if err != nil {
@@ -41,11 +56,15 @@ func (s *JWTService) GenerateToken(userID int, username, role string) (string, s
 		"user_id":  strconv.Itoa(userID),
 		"username": username,
 		"role":     role,
+		"sid":      sessionID,
+		"typ":      TokenTypeAccess,
 		"exp":      time.Now().Add(s.accessTTL).Unix(),
 		"iat":      time.Now().Unix(),
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(s.secretKey)
+	if mustChangePassword {
+		accessClaims[MustChangePasswordClaim] = true
+	}
+	accessTokenString, err := s.keys.Sign(accessClaims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign access token: %v", err)
 	}
@@ -54,11 +73,12 @@ func (s *JWTService) GenerateToken(userID int, username, role string) (string, s
 	refreshClaims := jwt.MapClaims{
 		"user_id": strconv.Itoa(userID),
 		"jti":     refreshJTI,
+		"sid":     sessionID,
+		"typ":     TokenTypeRefresh,
 		"exp":     time.Now().Add(s.refreshTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(s.secretKey)
+	refreshTokenString, err := s.keys.Sign(refreshClaims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign refresh token: %v", err)
 	}
@@ -70,78 +90,137 @@ func (s *JWTService) GenerateToken(userID int, username, role string) (string, s
 		return "", "", fmt.Errorf("failed to store refresh token in Redis: %v", err)
 	}
 
+	if err := s.TouchSession(ctx, sessionID, refreshJTI); err != nil {
+		return "", "", fmt.Errorf("failed to update session: %v", err)
+	}
+
 	return accessTokenString, refreshTokenString, nil
 }
 
-func (s *JWTService) ValidateToken(tokenString string) (map[string]interface{}, error) {
-	return s.parseToken(tokenString)
+// JWKS — публичные ключи для проверки наших токенов сторонними сервисами.
+func (s *JWTService) JWKS() map[string]interface{} {
+	return s.keys.JWKS()
 }
 
-func (s *JWTService) ValidateRefreshToken(tokenString string) (int, error) {
-	// Сначала парсим без проверки подписи, чтобы получить jti
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+// ValidateToken проверяет access-токен; refresh-токен отклоняется.
+func (s *JWTService) ValidateToken(tokenString string) (map[string]interface{}, error) {
+	claims, err := s.parseToken(tokenString)
 	if err != nil {
-		return 0, fmt.Errorf("invalid token format")
+		return nil, err
 	}
+	if claims[TokenTypeClaim] != TokenTypeAccess {
+		return nil, fmt.Errorf("not an access token")
+	}
+	return claims, nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return 0, fmt.Errorf("invalid claims")
+// ErrRefreshTokenReused — предъявлен уже использованный refresh-токен.
+// Это признак кражи: вся сессия (семейство токенов) отзывается.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// consumeRefreshScript атомарно гасит refresh jti. Погашенный jti остаётся
+// в refresh_used:<jti> до конца срока жизни, чтобы распознать повтор.
+// Возвращает {1, user_id} — токен действителен, {2, user_id} — повтор, {0, ""} — не найден.
+var consumeRefreshScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+	redis.call('DEL', KEYS[1])
+	redis.call('SET', KEYS[2], v, 'EX', ARGV[1])
+	return {1, v}
+end
+local used = redis.call('GET', KEYS[2])
+if used then
+	return {2, used}
+end
+return {0, ''}
+`)
+
+// ConsumeRefreshToken проверяет подпись refresh-токена и гасит его jti:
+// каждый токен обменивается на новую пару ровно один раз.
+// Возвращает user_id и идентификатор сессии. При повторном предъявлении
+// возвращает их же вместе с ErrRefreshTokenReused, уже отозвав сессию.
+func (s *JWTService) ConsumeRefreshToken(ctx context.Context, tokenString string) (int, string, error) {
+	// Подпись и срок проверяются до любых обращений к Redis
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid or expired refresh token: %v", err)
+	}
+	// Токены без typ выпущены до его появления: refresh-токен среди них
+	// отличается наличием jti, который проверяется ниже
+	if typ, ok := claims[TokenTypeClaim]; ok && typ != TokenTypeRefresh {
+		return 0, "", fmt.Errorf("not a refresh token")
 	}
 
 	jti, ok := claims["jti"].(string)
-	if !ok {
-		return 0, fmt.Errorf("missing jti in refresh token")
+	if !ok || jti == "" {
+		return 0, "", fmt.Errorf("missing jti in refresh token")
+	}
+	sessionID, _ := claims["sid"].(string)
+
+	res, err := consumeRefreshScript.Run(ctx, s.redisClient,
+		[]string{"refresh:" + jti, "refresh_used:" + jti},
+		int(s.refreshTTL.Seconds()),
+	).Slice()
+	if err != nil {
+		return 0, "", fmt.Errorf("redis error: %v", err)
+	}
+	if len(res) != 2 {
+		return 0, "", fmt.Errorf("unexpected redis reply")
 	}
+	status, _ := res[0].(int64)
+	val, _ := res[1].(string)
 
-	// Проверяем, что jti есть в Redis
-	val, err := s.redisClient.Get(context.Background(), "refresh:"+jti).Result()
-	if err == redis.Nil {
-		return 0, fmt.Errorf("refresh token not found or revoked")
-	} else if err != nil {
-		return 0, fmt.Errorf("redis error: %v", err)
+	if status == 0 {
+		return 0, "", fmt.Errorf("refresh token not found or revoked")
 	}
 
 	userID, err := strconv.Atoi(val)
 	if err != nil {
-		return 0, fmt.Errorf("invalid user_id in redis")
+		return 0, "", fmt.Errorf("invalid user_id in redis")
 	}
 
-	// Проверяем сам токен: подпись и срок действия
-	_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
+	if status == 2 {
+		if sessionID != "" {
+			if err := s.RevokeSession(ctx, userID, sessionID); err != nil {
+				return userID, sessionID, fmt.Errorf("%w: failed to revoke session: %v", ErrRefreshTokenReused, err)
+			}
+		}
+		return userID, sessionID, ErrRefreshTokenReused
+	}
+
+	// Сессия могла быть отозвана пользователем или администратором
+	if sessionID != "" {
+		if _, err := s.GetSession(ctx, sessionID); err == redis.Nil {
+			return 0, "", fmt.Errorf("session revoked")
+		} else if err != nil {
+			return 0, "", fmt.Errorf("redis error: %v", err)
 		}
-		return s.secretKey, nil
-	})
-	if err != nil {
-		return 0, fmt.Errorf("invalid or expired refresh token: %v", err)
 	}
 
-	return userID, nil
+	return userID, sessionID, nil
 }
 
-func (s *JWTService) GenerateAccessToken(userID int, username, role string) (string, error) {
+func (s *JWTService) GenerateAccessToken(userID int, username, role, sessionID string, mustChangePassword bool) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  strconv.Itoa(userID),
 		"username": username,
 		"role":     role,
+		"sid":      sessionID,
+		"typ":      TokenTypeAccess,
 		"exp":      time.Now().Add(s.accessTTL).Unix(),
 		"iat":      time.Now().Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	if mustChangePassword {
+		claims[MustChangePasswordClaim] = true
+	}
+	return s.keys.Sign(claims)
 }
 
 func (s *JWTService) RevokeRefreshToken(tokenString string) error {
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	claims, err := s.parseToken(tokenString)
 	if err != nil {
 		return nil
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil
-	}
 	jti, ok := claims["jti"].(string)
 	if !ok {
 		return nil
@@ -159,12 +238,7 @@ func (s *JWTService) generateJTI() (string, error) {
 
 // parseToken — внутренний метод парсинга JWT
 func (s *JWTService) parseToken(tokenString string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.secretKey, nil
-	})
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc, jwt.WithValidMethods(s.keys.Methods()))
 
 	if err != nil {
 		return nil, err
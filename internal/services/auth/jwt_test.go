@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestJWTService(t *testing.T) *JWTService {
+	t.Helper()
+	keys, err := LoadKeySet(KeySetConfig{HMACSecret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Redis недоступен: проверки типа токена идут до обращения к нему
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { redisClient.Close() })
+	return NewJWTService(keys, redisClient)
+}
+
+func signTestToken(t *testing.T, s *JWTService, typ string) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"user_id": "7",
+		"sid":     "session",
+		"jti":     "jti",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}
+	if typ != "" {
+		claims[TokenTypeClaim] = typ
+	}
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestValidateTokenAcceptsOnlyAccessTokens(t *testing.T) {
+	s := newTestJWTService(t)
+	if _, err := s.ValidateToken(signTestToken(t, s, TokenTypeAccess)); err != nil {
+		t.Errorf("access token rejected: %v", err)
+	}
+	for _, typ := range []string{TokenTypeRefresh, ""} {
+		if _, err := s.ValidateToken(signTestToken(t, s, typ)); err == nil {
+			t.Errorf("token with typ %q accepted as access token", typ)
+		}
+	}
+}
+
+func TestConsumeRefreshTokenRejectsAccessTokens(t *testing.T) {
+	s := newTestJWTService(t)
+	_, _, err := s.ConsumeRefreshToken(context.Background(), signTestToken(t, s, TokenTypeAccess))
+	if err == nil || !strings.Contains(err.Error(), "not a refresh token") {
+		t.Errorf("access token: %v, want not a refresh token", err)
+	}
+}
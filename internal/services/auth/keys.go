@@ -0,0 +1,228 @@
+// services/keys.go
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// legacyKeyID — ключ HMAC для токенов, выпущенных без заголовка kid.
+const legacyKeyID = "hs256"
+
+// KeySetConfig — источники ключей подписи JWT.
+type KeySetConfig struct {
+	// PrivateKeyFile — PEM с ключом RSA (RS256) или Ed25519 (EdDSA), которым подписываются новые токены.
+	PrivateKeyFile string
+	// PreviousKeys — старые ключи после ротации: "path" или "path@2025-01-31T00:00:00Z".
+	// До указанного момента ими ещё проверяются токены, после — ключ забывается.
+	PreviousKeys []string
+	// HMACSecret подписывает токены, если PrivateKeyFile не задан. Вместе с
+	// PrivateKeyFile принимается только для проверки старых токенов.
+	HMACSecret string
+}
+
+type signingKey struct {
+	kid      string
+	method   jwt.SigningMethod
+	private  interface{}
+	public   interface{}
+	notAfter time.Time // нулевое значение — без ограничения
+}
+
+func (k *signingKey) activeAt(t time.Time) bool {
+	return k.notAfter.IsZero() || t.Before(k.notAfter)
+}
+
+// KeySet — текущий ключ подписи и ключи, которыми ещё можно проверять токены.
+type KeySet struct {
+	current *signingKey
+	keys    map[string]*signingKey
+}
+
+// LoadKeySet собирает набор ключей из конфигурации.
+func LoadKeySet(cfg KeySetConfig) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*signingKey)}
+
+	if cfg.HMACSecret != "" {
+		ks.keys[legacyKeyID] = &signingKey{
+			kid:     legacyKeyID,
+			method:  jwt.SigningMethodHS256,
+			private: []byte(cfg.HMACSecret),
+			public:  []byte(cfg.HMACSecret),
+		}
+	}
+
+	if cfg.PrivateKeyFile != "" {
+		key, err := loadKeyFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.private == nil {
+			return nil, fmt.Errorf("%s: signing key must be a private key", cfg.PrivateKeyFile)
+		}
+		ks.current = key
+		ks.keys[key.kid] = key
+	} else if cfg.HMACSecret != "" {
+		ks.current = ks.keys[legacyKeyID]
+	} else {
+		return nil, fmt.Errorf("no JWT signing key configured")
+	}
+
+	for _, entry := range cfg.PreviousKeys {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		path, until, _ := strings.Cut(entry, "@")
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if until != "" {
+			key.notAfter, err = time.Parse(time.RFC3339, until)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid grace period end %q: %v", path, until, err)
+			}
+		}
+		// Старым ключом больше не подписываем
+		key.private = nil
+		if _, exists := ks.keys[key.kid]; !exists {
+			ks.keys[key.kid] = key
+		}
+	}
+
+	return ks, nil
+}
+
+// Sign подписывает claims текущим ключом и проставляет kid в заголовок.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.current.method, claims)
+	token.Header["kid"] = ks.current.kid
+	return token.SignedString(ks.current.private)
+}
+
+// Keyfunc выбирает ключ проверки по kid. Токены без kid — старые HMAC-токены.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = legacyKeyID
+	}
+	key, ok := ks.keys[kid]
+	if !ok || !key.activeAt(time.Now()) {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// Methods — алгоритмы, которые допускаются при разборе токена.
+func (ks *KeySet) Methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWKS возвращает публичные ключи для сторонних сервисов (боты, внешние API).
+// HMAC-ключи не публикуются.
+func (ks *KeySet) JWKS() map[string]interface{} {
+	now := time.Now()
+	keys := make([]map[string]string, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		if !key.activeAt(now) {
+			continue
+		}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"x":   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// loadKeyFile читает PEM с закрытым или открытым ключом RSA/Ed25519.
+// kid вычисляется из открытого ключа, поэтому не меняется при переносе файла.
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %v", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	key := &signingKey{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", path)
+	}
+	if rsaKey, ok := key.public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("%s: RSA key must be at least 2048 bits", path)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	sum := sha256.Sum256(der)
+	key.kid = base64.RawURLEncoding.EncodeToString(sum[:12])
+	return key, nil
+}
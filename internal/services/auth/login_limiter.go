@@ -0,0 +1,164 @@
+// services/login_limiter.go
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LoginLimiter ограничивает подбор паролей: считает неудачные попытки
+// по логину и по IP и после порога блокирует вход с растущей паузой.
+type LoginLimiter struct {
+	redisClient *redis.Client
+
+	userThreshold int           // попыток на логин до первой блокировки
+	ipThreshold   int           // попыток с одного IP до первой блокировки
+	baseLockout   time.Duration // первая блокировка, дальше удваивается
+	maxLockout    time.Duration
+	window        time.Duration // сколько помним неудачи после последней
+}
+
+func NewLoginLimiter(redisClient *redis.Client) *LoginLimiter {
+	return &LoginLimiter{
+		redisClient:   redisClient,
+		userThreshold: 5,
+		ipThreshold:   20,
+		baseLockout:   30 * time.Second,
+		maxLockout:    time.Hour,
+		window:        24 * time.Hour,
+	}
+}
+
+// Lockout — блокировка, выставленная после очередной неудачи.
+type Lockout struct {
+	Scope    string // "username" или "ip"
+	Key      string
+	Failures int64
+	Duration time.Duration
+}
+
+// reserveAttemptScript атомарно проверяет блокировки логина и IP и, если их
+// нет, засчитывает попытку в оба счётчика ещё до проверки пароля. Так
+// параллельные запросы не проходят проверку все разом, пока блокировки нет.
+// Достигнутый порог сразу ставит блокировку: base * 2^(n - порог), не больше max.
+// Ответ: {ожидание в мс} при блокировке или {0, n логина, мс блокировки
+// логина, n IP, мс блокировки IP}.
+//
+// KEYS: счётчик и блокировка логина, счётчик и блокировка IP.
+// ARGV: окно в секундах, порог логина, порог IP, base в мс, max в мс.
+var reserveAttemptScript = redis.NewScript(`
+local wait = 0
+for _, i in ipairs({2, 4}) do
+	if KEYS[i] ~= '' then
+		local ttl = redis.call('PTTL', KEYS[i])
+		if ttl > wait then wait = ttl end
+	end
+end
+if wait > 0 then return {wait} end
+
+local result = {0}
+for _, i in ipairs({1, 3}) do
+	local n, duration = 0, 0
+	if KEYS[i] ~= '' then
+		n = redis.call('INCR', KEYS[i])
+		redis.call('EXPIRE', KEYS[i], ARGV[1])
+		local threshold = tonumber(ARGV[i == 1 and 2 or 3])
+		if n >= threshold then
+			duration = tonumber(ARGV[4])
+			for _ = 1, n - threshold do
+				if duration >= tonumber(ARGV[5]) then break end
+				duration = duration * 2
+			end
+			duration = math.min(duration, tonumber(ARGV[5]))
+			redis.call('SET', KEYS[i + 1], n, 'PX', duration)
+		end
+	end
+	table.insert(result, n)
+	table.insert(result, duration)
+end
+return result
+`)
+
+// releaseAttemptScript возвращает попытку, засчитанную успешному входу:
+// счётчик и блокировка логина сбрасываются, счётчик IP уменьшается на один.
+var releaseAttemptScript = redis.NewScript(`
+redis.call('DEL', KEYS[1], KEYS[2])
+if KEYS[3] ~= '' and redis.call('EXISTS', KEYS[3]) == 1 then
+	if redis.call('DECR', KEYS[3]) <= 0 then redis.call('DEL', KEYS[3]) end
+end
+return 1
+`)
+
+func normalizeLogin(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginFailKey(scope, key string) string { return "login_fail:" + scope + ":" + key }
+func loginLockKey(scope, key string) string { return "login_lock:" + scope + ":" + key }
+
+// Attempt засчитывает попытку входа до проверки пароля. Если логин или IP
+// заблокированы, возвращает, сколько ещё ждать, и попытку не засчитывает.
+// Иначе возвращает блокировки, которые выставила эта попытка, — их стоит
+// записать в журнал, если пароль не подошёл. После успешного входа попытку
+// нужно вернуть через Reset.
+func (l *LoginLimiter) Attempt(ctx context.Context, username, ip string) (time.Duration, []Lockout, error) {
+	login := normalizeLogin(username)
+	keys := []string{loginFailKey("username", login), loginLockKey("username", login), "", ""}
+	if ip != "" {
+		keys[2], keys[3] = loginFailKey("ip", ip), loginLockKey("ip", ip)
+	}
+	result, err := reserveAttemptScript.Run(ctx, l.redisClient, keys,
+		int(l.window.Seconds()),
+		l.userThreshold,
+		l.ipThreshold,
+		l.baseLockout.Milliseconds(),
+		l.maxLockout.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, nil, err
+	}
+	if result[0] > 0 {
+		return time.Duration(result[0]) * time.Millisecond, nil, nil
+	}
+
+	var lockouts []Lockout
+	for i, target := range []struct{ scope, key string }{{"username", login}, {"ip", ip}} {
+		failures, lockedMs := result[1+2*i], result[2+2*i]
+		if lockedMs > 0 {
+			lockouts = append(lockouts, Lockout{
+				Scope:    target.scope,
+				Key:      target.key,
+				Failures: failures,
+				Duration: time.Duration(lockedMs) * time.Millisecond,
+			})
+		}
+	}
+	return 0, lockouts, nil
+}
+
+// Reset возвращает попытку успешного входа: счётчик и блокировка логина
+// сбрасываются, а с IP снимается только эта попытка — с одного адреса
+// могут перебирать много логинов.
+func (l *LoginLimiter) Reset(ctx context.Context, username, ip string) error {
+	login := normalizeLogin(username)
+	keys := []string{loginFailKey("username", login), loginLockKey("username", login), ""}
+	if ip != "" {
+		keys[2] = loginFailKey("ip", ip)
+	}
+	return releaseAttemptScript.Run(ctx, l.redisClient, keys).Err()
+}
+
+// Unlock снимает блокировку по логину и, если передан, по IP.
+func (l *LoginLimiter) Unlock(ctx context.Context, username, ip string) error {
+	keys := []string{
+		loginFailKey("username", normalizeLogin(username)),
+		loginLockKey("username", normalizeLogin(username)),
+	}
+	if ip != "" {
+		keys = append(keys, loginFailKey("ip", ip), loginLockKey("ip", ip))
+	}
+	return l.redisClient.Del(ctx, keys...).Err()
+}
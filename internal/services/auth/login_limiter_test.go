@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Тесты Lua-скриптов на живом Redis. Без REDIS_TEST_ADDR пропускаются:
+//
+//	docker run -p 6379:6379 redis
+//	REDIS_TEST_ADDR=localhost:6379 go test ./internal/services/auth
+//
+// Ключи уникальны для каждого запуска и удаляются после теста.
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("connect to %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLoginLimiterBackoff(t *testing.T) {
+	redisClient := newTestRedis(t)
+	ctx := context.Background()
+	l := &LoginLimiter{
+		redisClient:   redisClient,
+		userThreshold: 3,
+		ipThreshold:   100,
+		baseLockout:   time.Second,
+		maxLockout:    4 * time.Second,
+		window:        time.Minute,
+	}
+	login := fmt.Sprintf("limiter-test-%d", time.Now().UnixNano())
+	t.Cleanup(func() { l.Unlock(ctx, login, "") })
+
+	// Порог 3: третья неудача блокирует на base, дальше пауза удваивается до max
+	for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		wait, lockouts, err := l.Attempt(ctx, login, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != 0 {
+			t.Fatalf("attempt %d: locked for %v", i+1, wait)
+		}
+		var got time.Duration
+		if len(lockouts) > 0 {
+			got = lockouts[0].Duration
+			if lockouts[0].Scope != "username" || lockouts[0].Failures != int64(i+1) {
+				t.Errorf("attempt %d: lockout %+v", i+1, lockouts[0])
+			}
+		}
+		if got != want {
+			t.Errorf("attempt %d: lockout %v, want %v", i+1, got, want)
+		}
+
+		if want > 0 {
+			// Пока блокировка стоит, попытка не засчитывается
+			if wait, _, _ := l.Attempt(ctx, login, ""); wait <= 0 || wait > want {
+				t.Errorf("attempt %d: wait %v while locked for %v", i+1, wait, want)
+			}
+			// Снимаем только блокировку: счётчик неудач остаётся
+			redisClient.Del(ctx, loginLockKey("username", normalizeLogin(login)))
+		}
+	}
+
+	if err := l.Reset(ctx, login, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, lockouts, err := l.Attempt(ctx, login, ""); err != nil || len(lockouts) != 0 {
+		t.Errorf("after reset: %v, %v", lockouts, err)
+	}
+}
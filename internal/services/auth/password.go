@@ -2,9 +2,25 @@ package services
 
 import (
 	"crypto/rand"
+	"errors"
+	"math/big"
+	"unicode"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Требования к паролю. bcrypt учитывает только первые 72 байта.
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72
+)
+
+var (
+	ErrPasswordTooShort = errors.New("password must be at least 8 characters long")
+	ErrPasswordTooLong  = errors.New("password must be at most 72 bytes long")
+	ErrPasswordTooWeak  = errors.New("password must contain both letters and digits")
+)
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
@@ -15,6 +31,66 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+// ValidatePassword проверяет пароль на соответствие политике.
+// Вызывается перед HashPassword везде, где пароль задаёт пользователь.
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrPasswordTooWeak
+	}
+	return nil
+}
+
+// temporaryPasswordAlphabet — без похожих символов (0/O, 1/l/I), чтобы пароль
+// можно было продиктовать.
+const temporaryPasswordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateTemporaryPassword создаёт одноразовый пароль, удовлетворяющий политике.
+func GenerateTemporaryPassword() (string, error) {
+	for {
+		password, err := randomString(temporaryPasswordAlphabet, 12)
+		if err != nil {
+			return "", err
+		}
+		if ValidatePassword(password) == nil {
+			return password, nil
+		}
+	}
+}
+
+// GenerateNumericCode возвращает код из цифр заданной длины (для сброса пароля).
+func GenerateNumericCode(length int) (string, error) {
+	return randomString("0123456789", length)
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	result := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[n.Int64()]
+	}
+	return string(result), nil
+}
+
 func GenerateSecureToken(length int) (string, error) {
 	bytes := make([]byte, length)
 	_, err := rand.Read(bytes)
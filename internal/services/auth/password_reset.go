@@ -0,0 +1,155 @@
+// services/password_reset.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrResetCooldown    = errors.New("reset code was sent recently")
+	ErrInvalidResetCode = errors.New("invalid or expired reset code")
+)
+
+// MessageSender доставляет сообщение пользователю в Telegram.
+type MessageSender interface {
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+// PasswordResetService выдаёт одноразовые коды сброса пароля через Telegram-бота.
+type PasswordResetService struct {
+	redisClient *redis.Client
+	sender      MessageSender
+	codeTTL     time.Duration
+	cooldown    time.Duration
+	maxAttempts int64
+
+	// Запросы кода за requestWindow: с одного IP и на один логин
+	requestWindow   time.Duration
+	maxIPRequests   int64
+	maxUserRequests int64
+}
+
+func NewPasswordResetService(redisClient *redis.Client, sender MessageSender) *PasswordResetService {
+	return &PasswordResetService{
+		redisClient: redisClient,
+		sender:      sender,
+		codeTTL:     10 * time.Minute,
+		cooldown:    time.Minute,
+		maxAttempts: 5,
+
+		requestWindow:   time.Hour,
+		maxIPRequests:   10,
+		maxUserRequests: 5,
+	}
+}
+
+func resetCodeKey(userID int) string           { return "pwd_reset:" + strconv.Itoa(userID) }
+func resetCooldownKey(userID int) string       { return "pwd_reset_cooldown:" + strconv.Itoa(userID) }
+func resetRequestKey(scope, key string) string { return "pwd_reset_requests:" + scope + ":" + key }
+
+func hashResetCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// AllowRequest считает запрос кода с IP и на логин и возвращает false, если
+// за requestWindow их уже слишком много. Считаются все запросы, в том числе
+// на несуществующие логины, чтобы по ответам нельзя было перебирать аккаунты.
+func (s *PasswordResetService) AllowRequest(ctx context.Context, username, ip string) (bool, error) {
+	keys := []string{resetRequestKey("username", normalizeLogin(username))}
+	limits := []int64{s.maxUserRequests}
+	if ip != "" {
+		keys = append(keys, resetRequestKey("ip", ip))
+		limits = append(limits, s.maxIPRequests)
+	}
+
+	allowed := true
+	for i, key := range keys {
+		count, err := countRequestScript.Run(ctx, s.redisClient, []string{key}, int(s.requestWindow.Seconds())).Int64()
+		if err != nil {
+			return false, err
+		}
+		if count > limits[i] {
+			allowed = false
+		}
+	}
+	return allowed, nil
+}
+
+// countRequestScript считает запросы в окне, которое начинается с первого из них.
+var countRequestScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('EXPIRE', KEYS[1], ARGV[1]) end
+return n
+`)
+
+// SendCode создаёт новый код (старый перестаёт действовать) и отправляет его в Telegram.
+func (s *PasswordResetService) SendCode(ctx context.Context, userID int, telegramID int64) error {
+	ok, err := s.redisClient.SetNX(ctx, resetCooldownKey(userID), 1, s.cooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrResetCooldown
+	}
+
+	code, err := GenerateNumericCode(6)
+	if err != nil {
+		return fmt.Errorf("failed to generate reset code: %v", err)
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, resetCodeKey(userID))
+	pipe.HSet(ctx, resetCodeKey(userID), "code", hashResetCode(code), "attempts", 0)
+	pipe.Expire(ctx, resetCodeKey(userID), s.codeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("Код для сброса пароля: %s\nДействует %d минут. Если вы не запрашивали сброс, просто проигнорируйте это сообщение.",
+		code, int(s.codeTTL.Minutes()))
+	if err := s.sender.SendMessage(ctx, telegramID, text); err != nil {
+		s.redisClient.Del(ctx, resetCodeKey(userID))
+		return err
+	}
+	return nil
+}
+
+// verifyCodeScript атомарно засчитывает попытку и сверяет хэш кода, чтобы
+// параллельные запросы не получили больше maxAttempts проверок. Верный код
+// и последняя неудачная попытка удаляют код. Сравниваются хэши, поэтому
+// время сравнения ничего не говорит о самом коде.
+// Ответ: 1 — код верный, 0 — неверный или уже погашен.
+//
+// KEYS: код сброса. ARGV: хэш введённого кода, maxAttempts.
+var verifyCodeScript = redis.NewScript(`
+local stored = redis.call('HGET', KEYS[1], 'code')
+if not stored then return 0 end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if stored == ARGV[1] and attempts <= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+if attempts >= tonumber(ARGV[2]) then redis.call('DEL', KEYS[1]) end
+return 0
+`)
+
+// VerifyCode проверяет код и гасит его. После maxAttempts ошибок код удаляется.
+func (s *PasswordResetService) VerifyCode(ctx context.Context, userID int, code string) error {
+	ok, err := verifyCodeScript.Run(ctx, s.redisClient, []string{resetCodeKey(userID)}, hashResetCode(code), s.maxAttempts).Int()
+	if err != nil {
+		return err
+	}
+	if ok != 1 {
+		return ErrInvalidResetCode
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyCodeAttemptsAreAtomic(t *testing.T) {
+	redisClient := newTestRedis(t)
+	ctx := context.Background()
+	s := NewPasswordResetService(redisClient, nil)
+	userID := int(time.Now().UnixNano() % 1_000_000_000)
+	key := resetCodeKey(userID)
+	t.Cleanup(func() { redisClient.Del(ctx, key) })
+
+	if err := redisClient.HSet(ctx, key, "code", hashResetCode("123456"), "attempts", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Параллельные неверные коды не получают больше maxAttempts проверок
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.VerifyCode(ctx, userID, fmt.Sprintf("%06d", i))
+		}(i)
+	}
+	wg.Wait()
+	if exists, _ := redisClient.Exists(ctx, key).Result(); exists != 0 {
+		t.Error("code survived 20 wrong attempts")
+	}
+	if err := s.VerifyCode(ctx, userID, "123456"); err != ErrInvalidResetCode {
+		t.Errorf("right code after lockout: %v, want ErrInvalidResetCode", err)
+	}
+
+	redisClient.HSet(ctx, key, "code", hashResetCode("123456"), "attempts", 0)
+	if err := s.VerifyCode(ctx, userID, "000000"); err != ErrInvalidResetCode {
+		t.Errorf("wrong code: %v", err)
+	}
+	if err := s.VerifyCode(ctx, userID, "123456"); err != nil {
+		t.Errorf("right code: %v", err)
+	}
+	if err := s.VerifyCode(ctx, userID, "123456"); err != ErrInvalidResetCode {
+		t.Errorf("reused code: %v, want ErrInvalidResetCode", err)
+	}
+}
@@ -0,0 +1,200 @@
+// services/session.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Session — вход пользователя с конкретного устройства.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	DeviceID   string    `json:"device_id,omitempty"`
+	DeviceName string    `json:"device_name,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// SessionMeta — сведения об устройстве, с которого выполнен вход.
+type SessionMeta struct {
+	DeviceID   string
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+func sessionKey(sessionID string) string        { return "session:" + sessionID }
+func userSessionsKey(userID int) string         { return "user_sessions:" + strconv.Itoa(userID) }
+func revokedSessionKey(sessionID string) string { return "revoked_session:" + sessionID }
+
+// CreateSession регистрирует новую сессию. Старая сессия с тем же device_id
+// отзывается: на одном устройстве держим один вход.
+func (s *JWTService) CreateSession(ctx context.Context, userID int, meta SessionMeta) (string, error) {
+	if meta.DeviceID != "" {
+		sessions, err := s.ListSessions(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		for _, existing := range sessions {
+			if existing.DeviceID == meta.DeviceID {
+				if err := s.RevokeSession(ctx, userID, existing.ID); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	sessionID, err := s.generateJTI()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session id: %v", err)
+	}
+
+	now := time.Now().Unix()
+	pipe := s.redisClient.TxPipeline()
+	pipe.HSet(ctx, sessionKey(sessionID), map[string]interface{}{
+		"user_id":     userID,
+		"device_id":   meta.DeviceID,
+		"device_name": meta.DeviceName,
+		"ip":          meta.IP,
+		"user_agent":  meta.UserAgent,
+		"created_at":  now,
+		"last_seen":   now,
+	})
+	pipe.Expire(ctx, sessionKey(sessionID), s.refreshTTL)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+	pipe.Expire(ctx, userSessionsKey(userID), s.refreshTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store session in Redis: %v", err)
+	}
+
+	return sessionID, nil
+}
+
+// GetSession возвращает сессию или redis.Nil, если её нет.
+func (s *JWTService) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	fields, err := s.redisClient.HGetAll(ctx, sessionKey(sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+
+	userID, _ := strconv.Atoi(fields["user_id"])
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	lastSeen, _ := strconv.ParseInt(fields["last_seen"], 10, 64)
+	return &Session{
+		ID:         sessionID,
+		UserID:     userID,
+		DeviceID:   fields["device_id"],
+		DeviceName: fields["device_name"],
+		IP:         fields["ip"],
+		UserAgent:  fields["user_agent"],
+		CreatedAt:  time.Unix(createdAt, 0),
+		LastSeenAt: time.Unix(lastSeen, 0),
+	}, nil
+}
+
+// ListSessions возвращает активные сессии пользователя, новые сверху.
+// Истёкшие идентификаторы попутно вычищаются из индекса.
+func (s *JWTService) ListSessions(ctx context.Context, userID int) ([]Session, error) {
+	ids, err := s.redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := s.GetSession(ctx, id)
+		if err == redis.Nil {
+			s.redisClient.SRem(ctx, userSessionsKey(userID), id)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// TouchSession продлевает сессию и запоминает текущий refresh jti.
+func (s *JWTService) TouchSession(ctx context.Context, sessionID, refreshJTI string) error {
+	pipe := s.redisClient.TxPipeline()
+	pipe.HSet(ctx, sessionKey(sessionID), "last_seen", time.Now().Unix(), "refresh_jti", refreshJTI)
+	pipe.Expire(ctx, sessionKey(sessionID), s.refreshTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeSession завершает сессию: её токены попадают в denylist до истечения
+// самого долгого из них (refresh), refresh-токен удаляется.
+func (s *JWTService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	refreshJTI, err := s.redisClient.HGet(ctx, sessionKey(sessionID), "refresh_jti").Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, revokedSessionKey(sessionID), userID, s.refreshTTL)
+	pipe.Del(ctx, sessionKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+	if refreshJTI != "" {
+		pipe.Del(ctx, "refresh:"+refreshJTI)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RevokeAllSessions завершает все сессии пользователя (блокировка, удаление, смена роли).
+func (s *JWTService) RevokeAllSessions(ctx context.Context, userID int) error {
+	ids, err := s.redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.RevokeSession(ctx, userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме keepSessionID.
+func (s *JWTService) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID string) (int, error) {
+	sessions, err := s.ListSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == keepSessionID {
+			continue
+		}
+		if err := s.RevokeSession(ctx, userID, session.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// IsSessionRevoked проверяет denylist отозванных сессий.
+func (s *JWTService) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	n, err := s.redisClient.Exists(ctx, revokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
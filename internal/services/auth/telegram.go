@@ -4,6 +4,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"sort"
@@ -88,18 +89,75 @@ func (s *TelegramAuthService) validateHash(data map[string]string) bool {
 	return calculatedHash == hash
 }
 
-func (s *TelegramAuthService) ValidateWebAppData(initData string) (map[string]string, error) {
+// WebAppMaxAge — сколько действительны initData Mini App. Telegram выдаёт
+// их при открытии приложения, поэтому окно короткое: защита от повтора.
+const WebAppMaxAge = time.Hour
+
+// WebAppUser — поле user из initData Telegram Mini App.
+type WebAppUser struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	PhotoURL     string `json:"photo_url"`
+	LanguageCode string `json:"language_code"`
+}
+
+// ValidateWebAppData проверяет initData Mini App по схеме Telegram:
+// secret = HMAC_SHA256("WebAppData", bot_token), hash = HMAC_SHA256(secret, data_check_string).
+// Это не то же самое, что проверка Login Widget в validateHash.
+func (s *TelegramAuthService) ValidateWebAppData(initData string) (*WebAppUser, error) {
 	values, err := url.ParseQuery(initData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse init data: %v", err)
 	}
 
-	data := make(map[string]string)
-	for k, v := range values {
-		if len(v) > 0 {
-			data[k] = v[0]
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, fmt.Errorf("missing required field: hash")
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "hash" {
+			keys = append(keys, k)
 		}
 	}
+	sort.Strings(keys)
+
+	dataCheckArr := make([]string, 0, len(keys))
+	for _, k := range keys {
+		dataCheckArr = append(dataCheckArr, k+"="+values.Get(k))
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(s.BotToken))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(strings.Join(dataCheckArr, "\n")))
+	expected := hex.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(hash))) {
+		return nil, fmt.Errorf("hash validation failed")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth_date")
+	}
+	age := time.Since(time.Unix(authDate, 0))
+	if age > WebAppMaxAge {
+		return nil, fmt.Errorf("init data expired")
+	}
+	if age < -time.Minute {
+		return nil, fmt.Errorf("auth_date is in the future")
+	}
+
+	var user WebAppUser
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil {
+		return nil, fmt.Errorf("invalid user field: %v", err)
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("missing user id")
+	}
 
-	return s.ValidateAndExtract(data)
+	return &user, nil
 }
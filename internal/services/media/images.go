@@ -0,0 +1,119 @@
+// services/images.go
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"path"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	fullJPEGQuality  = 85
+	thumbJPEGQuality = 75
+
+	// MaxImageMegapixels — сколько пикселей можно декодировать из одной загрузки.
+	// Файл в пару мегабайт может объявить 30000×30000 и занять гигабайты памяти.
+	MaxImageMegapixels = 40
+)
+
+var (
+	ErrImageInvalid  = errors.New("file is not a valid image")
+	ErrImageTooLarge = errors.New("image dimensions are too large")
+)
+
+// ImageProcessor приводит загруженные фото к единому виду: JPEG не больше
+// maxDimension по длинной стороне плюс миниатюра для списков в админке.
+type ImageProcessor struct {
+	maxDimension   int
+	thumbDimension int
+}
+
+func NewImageProcessor(maxDimension, thumbDimension int) *ImageProcessor {
+	return &ImageProcessor{maxDimension: maxDimension, thumbDimension: thumbDimension}
+}
+
+// ProcessedImage — перекодированный файл и его миниатюра. Метаданные
+// исходника при перекодировании не сохраняются.
+type ProcessedImage struct {
+	Full  []byte
+	Thumb []byte
+}
+
+// Decode декодирует JPEG или PNG, сначала проверив по заголовку, что в нём
+// не больше MaxImageMegapixels.
+func (p *ImageProcessor) Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxImageMegapixels*1_000_000 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+	return img, nil
+}
+
+func (p *ImageProcessor) Process(img image.Image) (*ProcessedImage, error) {
+	full, err := encodeJPEG(fitInto(img, p.maxDimension), fullJPEGQuality)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := encodeJPEG(fitInto(img, p.thumbDimension), thumbJPEGQuality)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessedImage{Full: full, Thumb: thumb}, nil
+}
+
+// ThumbPath возвращает путь миниатюры: /uploads/selfies/a.jpg → /uploads/selfies/thumbs/a.jpg.
+func ThumbPath(filePath string) string {
+	dir, name := path.Split(filePath)
+	return dir + "thumbs/" + name
+}
+
+// fitInto уменьшает изображение так, чтобы длинная сторона была не больше max.
+// Прозрачные области (PNG) заливаются белым — в JPEG альфа-канала нет.
+func fitInto(img image.Image, max int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if max > 0 && (w > max || h > max) {
+		if w >= h {
+			w, h = max, h*max/w
+		} else {
+			w, h = w*max/h, max
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
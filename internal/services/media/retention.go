@@ -0,0 +1,150 @@
+// services/retention.go
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+)
+
+// RetainedKinds — виды загрузок, которые удаляются по сроку хранения.
+// Карты и сборки приложения хранятся, пока их не удалят вручную.
+var RetainedKinds = []string{"selfies", "tasks", "reports"}
+
+// SelfieReferences убирает из смен ссылки на селфи, которые удаляются по сроку.
+type SelfieReferences interface {
+	ClearSelfiesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ReportPhotoReferences убирает из отчётов о сменах ссылки на удаляемые фото.
+type ReportPhotoReferences interface {
+	ClearPhotosBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RetentionPolicy удаляет селфи, фото заданий и отчётов старше maxAge.
+type RetentionPolicy struct {
+	store   storageService.Storage
+	maxAge  time.Duration
+	selfies SelfieReferences
+	reports ReportPhotoReferences
+	now     func() time.Time
+}
+
+func NewRetentionPolicy(store storageService.Storage, maxAge time.Duration, selfies SelfieReferences, reports ReportPhotoReferences) *RetentionPolicy {
+	return &RetentionPolicy{store: store, maxAge: maxAge, selfies: selfies, reports: reports, now: time.Now}
+}
+
+// Enabled — false, если срок хранения не задан (UPLOAD_RETENTION_DAYS=0).
+func (p *RetentionPolicy) Enabled() bool {
+	return p.maxAge > 0
+}
+
+func (p *RetentionPolicy) MaxAge() time.Duration {
+	return p.maxAge
+}
+
+type RetentionResult struct {
+	Cutoff         time.Time `json:"cutoff"`
+	DeletedFiles   int       `json:"deleted_files"`
+	FreedBytes     int64     `json:"freed_bytes"`
+	ClearedShifts  int64     `json:"cleared_shifts"`
+	ClearedReports int64     `json:"cleared_reports"`
+}
+
+// Run сначала убирает ссылки из закрытых смен и отчётов, затем удаляет файлы,
+// чтобы ни одна смена не указывала на несуществующее фото.
+func (p *RetentionPolicy) Run(ctx context.Context) (*RetentionResult, error) {
+	result := &RetentionResult{Cutoff: p.now().Add(-p.maxAge)}
+	if !p.Enabled() {
+		return result, nil
+	}
+
+	cleared, err := p.selfies.ClearSelfiesBefore(ctx, result.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	result.ClearedShifts = cleared
+	if result.ClearedReports, err = p.reports.ClearPhotosBefore(ctx, result.Cutoff); err != nil {
+		return nil, err
+	}
+
+	for _, kind := range RetainedKinds {
+		// Удаляем после обхода: не все хранилища переносят удаление во время листинга
+		var expired []storageService.ObjectInfo
+		err := p.store.List(ctx, kind+"/", func(obj storageService.ObjectInfo) error {
+			if obj.ModTime.Before(result.Cutoff) {
+				expired = append(expired, obj)
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		for _, obj := range expired {
+			if err := p.store.Delete(ctx, obj.Key); err != nil {
+				return result, err
+			}
+			result.DeletedFiles++
+			result.FreedBytes += obj.Size
+		}
+	}
+	return result, nil
+}
+
+// KindUsage — сколько места занимает один вид загрузок.
+type KindUsage struct {
+	Kind       string     `json:"kind"`
+	Files      int        `json:"files"`
+	Bytes      int64      `json:"bytes"`
+	ThumbFiles int        `json:"thumb_files"`
+	ThumbBytes int64      `json:"thumb_bytes"`
+	Oldest     *time.Time `json:"oldest,omitempty"`
+	Newest     *time.Time `json:"newest,omitempty"`
+}
+
+// Usage считает занятое место по видам загрузок (selfies, tasks, reports, maps, app).
+func Usage(ctx context.Context, store storageService.Storage) ([]KindUsage, error) {
+	byKind := map[string]*KindUsage{}
+	err := store.List(ctx, "", func(obj storageService.ObjectInfo) error {
+		parts := strings.SplitN(obj.Key, "/", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+		kind, ok := byKind[parts[0]]
+		if !ok {
+			kind = &KindUsage{Kind: parts[0]}
+			byKind[parts[0]] = kind
+		}
+
+		if strings.HasPrefix(parts[1], "thumbs/") {
+			kind.ThumbFiles++
+			kind.ThumbBytes += obj.Size
+			return nil
+		}
+		kind.Files++
+		kind.Bytes += obj.Size
+		modTime := obj.ModTime
+		if kind.Oldest == nil || modTime.Before(*kind.Oldest) {
+			kind.Oldest = &modTime
+		}
+		if kind.Newest == nil || modTime.After(*kind.Newest) {
+			kind.Newest = &modTime
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]KindUsage, 0, len(byKind))
+	for _, kind := range byKind {
+		usage = append(usage, *kind)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Bytes+usage[i].ThumbBytes > usage[j].Bytes+usage[j].ThumbBytes
+	})
+	return usage, nil
+}
@@ -0,0 +1,96 @@
+// services/url_signer.go
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	storageService "github.com/evn/eom_backendl/internal/services/storage"
+)
+
+// URLSigner выдаёт и проверяет подписанные ссылки на файлы из /uploads.
+// Если хранилище умеет выдавать прямые ссылки (S3), отдаются они, и файл
+// скачивается мимо нашего сервера.
+type URLSigner struct {
+	secret    []byte
+	ttl       time.Duration
+	presigner storageService.Presigner
+}
+
+// NewURLSigner: presigner может быть nil — тогда все ссылки ведут на /uploads.
+func NewURLSigner(secret string, ttl time.Duration, presigner storageService.Presigner) *URLSigner {
+	return &URLSigner{
+		secret:    []byte(secret),
+		ttl:       ttl,
+		presigner: presigner,
+	}
+}
+
+// Sign возвращает прямую ссылку хранилища или ссылку вида /uploads/...?expires=...&sig=...
+// Пустой путь возвращается как есть, чтобы не ломать ответы без селфи.
+func (s *URLSigner) Sign(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = "/" + strings.TrimPrefix(path, "/")
+
+	if s.presigner != nil {
+		if key, ok := storageService.KeyFromPath(path); ok {
+			signed, err := s.presigner.PresignGet(context.Background(), key, s.ttl)
+			if err == nil {
+				return signed
+			}
+			log.Printf("Failed to presign %s, falling back to /uploads link: %v", path, err)
+		}
+	}
+	expires := time.Now().Add(s.ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(path, expires))
+	return path + "?" + query.Encode()
+}
+
+// SignPreview подписывает миниатюру, а если её нет (старые загрузки) — сам файл.
+func (s *URLSigner) SignPreview(thumbPath, fullPath string) string {
+	if thumbPath == "" {
+		return s.Sign(fullPath)
+	}
+	return s.Sign(thumbPath)
+}
+
+// Verify проверяет подпись и срок действия ссылки.
+func (s *URLSigner) Verify(path, expiresStr, sig string) error {
+	if expiresStr == "" || sig == "" {
+		return fmt.Errorf("missing signature")
+	}
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expires value")
+	}
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("link expired")
+	}
+
+	expected := s.signature("/"+strings.TrimPrefix(path, "/"), expires)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+func (s *URLSigner) signature(path string, expires int64) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(path))
+	h.Write([]byte("\n"))
+	h.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,301 @@
+// services/selfie/exif.go
+
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ExifInfo — то, что мы сохраняем из EXIF до перекодирования файла.
+type ExifInfo struct {
+	TakenAt     *time.Time
+	Latitude    *float64
+	Longitude   *float64
+	Orientation int // 1..8, 0 — не указана
+}
+
+var errNoExif = errors.New("no EXIF data")
+
+const (
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// ReadExif достаёт время съёмки, координаты и ориентацию из JPEG (APP1) или PNG (eXIf).
+func ReadExif(data []byte) (*ExifInfo, error) {
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		tiff = jpegExif(data)
+	case bytes.HasPrefix(data, pngSignature):
+		tiff = pngExif(data)
+	}
+	if tiff == nil {
+		return nil, errNoExif
+	}
+	return parseTIFF(tiff)
+}
+
+var (
+	jpegSOI      = []byte{0xFF, 0xD8}
+	pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+)
+
+type jpegSegment struct {
+	marker byte
+	data   []byte // без маркера и длины
+}
+
+// jpegSegments разбирает заголовок JPEG до начала сжатых данных (SOS).
+func jpegSegments(data []byte) ([]jpegSegment, error) {
+	var segments []jpegSegment
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errors.New("malformed JPEG")
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // заполнитель
+			pos++
+			continue
+		}
+		if marker == 0xDA {
+			return segments, nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, errors.New("malformed JPEG segment")
+		}
+		segments = append(segments, jpegSegment{
+			marker: marker,
+			data:   data[pos+4 : pos+2+length],
+		})
+		pos += 2 + length
+	}
+	return nil, errors.New("JPEG has no image data")
+}
+
+func jpegExif(data []byte) []byte {
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil
+	}
+	for _, seg := range segments {
+		if seg.marker == 0xE1 && bytes.HasPrefix(seg.data, []byte("Exif\x00\x00")) {
+			return seg.data[6:]
+		}
+	}
+	return nil
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func pngChunks(data []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errors.New("malformed PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{
+			typ:  string(data[pos+4 : pos+8]),
+			data: data[pos+8 : pos+8+length],
+		})
+		pos = end
+	}
+	return chunks, nil
+}
+
+func pngExif(data []byte) []byte {
+	chunks, err := pngChunks(data)
+	if err != nil {
+		return nil
+	}
+	for _, chunk := range chunks {
+		if chunk.typ == "eXIf" {
+			return chunk.data
+		}
+	}
+	return nil
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func parseTIFF(data []byte) (*ExifInfo, error) {
+	if len(data) < 8 {
+		return nil, errNoExif
+	}
+	r := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid TIFF header")
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ExifInfo{}
+	if e, ok := ifd0[tagOrientation]; ok {
+		info.Orientation = int(r.uint(e))
+	}
+
+	dateTime, offset := r.ascii(ifd0[tagDateTime]), ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exif, err := r.readIFD(r.uint(e)); err == nil {
+			if original := r.ascii(exif[tagDateTimeOriginal]); original != "" {
+				dateTime = original
+			}
+			offset = r.ascii(exif[tagOffsetTimeOriginal])
+		}
+	}
+	if takenAt, ok := parseExifTime(dateTime, offset); ok {
+		info.TakenAt = &takenAt
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := r.readIFD(r.uint(e)); err == nil {
+			lat, okLat := r.coordinate(gps[tagGPSLatitude], r.ascii(gps[tagGPSLatitudeRef]), "S")
+			lon, okLon := r.coordinate(gps[tagGPSLongitude], r.ascii(gps[tagGPSLongitudeRef]), "W")
+			if okLat && okLon {
+				info.Latitude, info.Longitude = &lat, &lon
+			}
+		}
+	}
+	return info, nil
+}
+
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	if int(offset)+2 > len(r.data) {
+		return nil, errors.New("IFD offset out of range")
+	}
+	count := int(r.order.Uint16(r.data[offset:]))
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		pos := int(offset) + 2 + i*12
+		if pos+12 > len(r.data) {
+			return nil, errors.New("IFD entry out of range")
+		}
+		tag := r.order.Uint16(r.data[pos:])
+		typ := r.order.Uint16(r.data[pos+2:])
+		n := r.order.Uint32(r.data[pos+4:])
+
+		size := int(n) * typeSize(typ)
+		if size < 0 || size > len(r.data) {
+			continue
+		}
+		var value []byte
+		if size <= 4 {
+			value = r.data[pos+8 : pos+8+size]
+		} else {
+			valueOffset := int(r.order.Uint32(r.data[pos+8:]))
+			if valueOffset+size > len(r.data) {
+				continue
+			}
+			value = r.data[valueOffset : valueOffset+size]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: n, value: value}
+	}
+	return entries, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9, 11: // LONG, SLONG, FLOAT
+		return 4
+	case 5, 10, 12: // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	}
+	return 0
+}
+
+func (r *tiffReader) uint(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(r.order.Uint16(e.value))
+	case (e.typ == 4 || e.typ == 9) && len(e.value) >= 4:
+		return r.order.Uint32(e.value)
+	}
+	return 0
+}
+
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+// coordinate переводит градусы/минуты/секунды в десятичные градусы.
+func (r *tiffReader) coordinate(e ifdEntry, ref, negativeRef string) (float64, bool) {
+	if e.typ != 5 || len(e.value) < 24 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := r.order.Uint32(e.value[i*8:])
+		den := r.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negativeRef) {
+		value = -value
+	}
+	return value, true
+}
+
+// parseExifTime разбирает "2006:01:02 15:04:05". Без OffsetTimeOriginal время
+// считается местным временем сервера — телефоны пишут его в поясе съёмки.
+func parseExifTime(value, offset string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset); err == nil {
+			return t, true
+		}
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,28 @@
+// services/selfie/face.go
+
+package services
+
+import (
+	"context"
+	"image"
+)
+
+// FaceMatchResult — ответ провайдера сверки лица.
+type FaceMatchResult struct {
+	Provider string  `json:"provider"`
+	Matched  bool    `json:"matched"`
+	Score    float64 `json:"score"`
+}
+
+// FaceMatcher сверяет лицо на селфи с эталоном сотрудника. Реализацию для
+// внешнего сервиса подключают вместо StubFaceMatcher.
+type FaceMatcher interface {
+	Match(ctx context.Context, userID int, img image.Image) (*FaceMatchResult, error)
+}
+
+// StubFaceMatcher ничего не проверяет и всегда подтверждает совпадение.
+type StubFaceMatcher struct{}
+
+func (StubFaceMatcher) Match(ctx context.Context, userID int, img image.Image) (*FaceMatchResult, error) {
+	return &FaceMatchResult{Provider: "stub", Matched: true, Score: 1}, nil
+}
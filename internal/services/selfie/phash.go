@@ -0,0 +1,65 @@
+// services/selfie/phash.go
+
+package services
+
+import (
+	"image"
+	"math/bits"
+)
+
+// DHash — разностный перцептивный хэш (64 бита). Перекодирование, сжатие
+// и небольшое изменение размера почти не меняют хэш, поэтому повторно
+// отправленное фото находится по расстоянию Хэмминга.
+func DHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	var gray [h][w]float64
+
+	b := img.Bounds()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gray[y][x] = cellLuminance(img,
+				b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h,
+				b.Min.X+(x+1)*b.Dx()/w, b.Min.Y+(y+1)*b.Dy()/h)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// cellLuminance — средняя яркость прямоугольника по сетке не более 16×16 точек:
+// на снимках в десятки мегапикселей полный проход слишком дорог.
+func cellLuminance(img image.Image, x0, y0, x1, y1 int) float64 {
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+	stepX := max(1, (x1-x0)/16)
+	stepY := max(1, (y1-y0)/16)
+
+	var sum float64
+	var n int
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+// HammingDistance — число различающихся бит двух хэшей.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
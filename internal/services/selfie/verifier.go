@@ -0,0 +1,121 @@
+// services/selfie/verifier.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+var (
+	ErrSelfieInvalid    = errors.New("selfie is not a valid image")
+	ErrSelfieNoMetadata = errors.New("selfie has no capture time")
+	ErrSelfieStale      = errors.New("selfie was not taken just now")
+	ErrSelfieReused     = errors.New("selfie was already used for another shift")
+	ErrFaceMismatch     = errors.New("face on selfie does not match the employee")
+)
+
+// HashLookup — источник хэшей недавних селфи для поиска повторов.
+type HashLookup interface {
+	ListRecentSelfieHashes(ctx context.Context, since time.Time) ([]models.SelfieHash, error)
+}
+
+// ImageDecoder декодирует фото с ограничением размеров (media.ImageProcessor).
+type ImageDecoder interface {
+	Decode(data []byte) (image.Image, error)
+}
+
+type VerifierConfig struct {
+	MaxClockSkew    time.Duration // допустимая разница между временем съёмки и загрузки
+	RequireExif     bool          // отклонять селфи без времени съёмки
+	ReuseWindow     time.Duration // за какой период ищем повторы
+	MaxHashDistance int           // расстояние Хэмминга, при котором фото считаются одинаковыми
+}
+
+// Verifier проверяет селфи перед открытием смены: время съёмки, повтор
+// старого фото, сверку лица.
+type Verifier struct {
+	cfg     VerifierConfig
+	decoder ImageDecoder
+	hashes  HashLookup
+	faces   FaceMatcher
+	now     func() time.Time
+}
+
+func NewVerifier(cfg VerifierConfig, decoder ImageDecoder, hashes HashLookup, faces FaceMatcher) *Verifier {
+	return &Verifier{cfg: cfg, decoder: decoder, hashes: hashes, faces: faces, now: time.Now}
+}
+
+// Verified — декодированное и повёрнутое по EXIF изображение и то, что о нём
+// нужно сохранить в смене. Исходный файл с метаданными на диск не пишется.
+type Verified struct {
+	Image image.Image
+	Meta  models.SelfieMeta
+}
+
+func (v *Verifier) Verify(ctx context.Context, userID int, data []byte) (*Verified, error) {
+	info, err := ReadExif(data)
+	if err != nil {
+		if !errors.Is(err, errNoExif) {
+			log.Printf("Unreadable EXIF in selfie of user %d: %v", userID, err)
+		}
+		info = &ExifInfo{}
+	}
+
+	now := v.now()
+	meta := models.SelfieMeta{
+		TakenAt:   info.TakenAt,
+		Latitude:  info.Latitude,
+		Longitude: info.Longitude,
+	}
+	if info.TakenAt == nil {
+		if v.cfg.RequireExif {
+			return nil, ErrSelfieNoMetadata
+		}
+	} else {
+		skew := now.Sub(*info.TakenAt)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > v.cfg.MaxClockSkew {
+			return nil, fmt.Errorf("%w: taken at %s", ErrSelfieStale, info.TakenAt.Format(time.RFC3339))
+		}
+	}
+
+	img, err := v.decoder.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSelfieInvalid, err)
+	}
+	img = applyOrientation(img, info.Orientation)
+
+	hash := DHash(img)
+	signedHash := int64(hash)
+	meta.PHash = &signedHash
+
+	recent, err := v.hashes.ListRecentSelfieHashes(ctx, now.Add(-v.cfg.ReuseWindow))
+	if err != nil {
+		return nil, err
+	}
+	for _, prev := range recent {
+		if distance := HammingDistance(hash, uint64(prev.Hash)); distance <= v.cfg.MaxHashDistance {
+			return nil, fmt.Errorf("%w: matches shift %d of user %d (distance %d)", ErrSelfieReused, prev.ShiftID, prev.UserID, distance)
+		}
+	}
+
+	face, err := v.faces.Match(ctx, userID, img)
+	if err != nil {
+		return nil, fmt.Errorf("face match failed: %v", err)
+	}
+	if !face.Matched {
+		return nil, ErrFaceMismatch
+	}
+	meta.Verification = map[string]interface{}{"face_match": face}
+
+	return &Verified{Image: img, Meta: meta}, nil
+}
@@ -0,0 +1,319 @@
+// services/shift/correction.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+var (
+	ErrShiftNotFound = errors.New("shift not found")
+	ErrShiftNotEnded = errors.New("shift is not ended yet")
+	ErrShiftVoided   = errors.New("shift is voided")
+	// ErrInvalidCorrection — правка не прошла проверку; подробности по полям —
+	// в response.ValidationError, которым обёрнута ошибка.
+	ErrInvalidCorrection = errors.New("invalid shift correction")
+	// ErrPeriodClosed — табель за месяц смены утверждён или закрыт: смену
+	// можно поправить только после снятия утверждения.
+	ErrPeriodClosed = errors.New("timesheet period is approved or locked")
+)
+
+// MaxShiftLength — смена после правки не может быть длиннее: защита от опечатки в дате.
+const MaxShiftLength = 24 * time.Hour
+
+const maxCommentLength = 1000
+
+// Correction — кто и почему правит смену.
+type Correction struct {
+	AdminID int
+	Reason  string // models.Correction*
+	Comment string // обязателен для models.CorrectionOther
+}
+
+func (c *Correction) validate() error {
+	c.Comment = strings.TrimSpace(c.Comment)
+	if !slices.Contains(models.CorrectionReasons, c.Reason) {
+		return invalidCorrection("reason", response.FieldOneOf, map[string]interface{}{"allowed": strings.Join(models.CorrectionReasons, ", ")})
+	}
+	if c.Reason == models.CorrectionOther && c.Comment == "" {
+		return invalidCorrection("comment", response.FieldRequired, nil)
+	}
+	if utf8.RuneCountInString(c.Comment) > maxCommentLength {
+		return invalidCorrection("comment", response.FieldTooLong, map[string]interface{}{"max": maxCommentLength})
+	}
+	return nil
+}
+
+// ShiftEdit — новые значения закрытой смены; nil — поле не меняется.
+type ShiftEdit struct {
+	StartTime     *time.Time
+	EndTime       *time.Time
+	Zone          *string
+	SlotTimeRange *string
+}
+
+// ForceEnd закрывает активную смену пользователя по решению администратора.
+// endTime nil — сейчас; иначе смена закрывается задним числом, например там,
+// где сотрудник на самом деле ушёл.
+func (s *ShiftService) ForceEnd(ctx context.Context, userID int, endTime *time.Time, c Correction) (*models.Shift, *models.ShiftCorrection, error) {
+	if err := c.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var shift *models.Shift
+	var correction *models.ShiftCorrection
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if shift, err = s.lockActive(ctx, userID); err != nil {
+			return err
+		}
+		before := shift.Values()
+
+		now := s.now()
+		end := now
+		if endTime != nil {
+			end = *endTime
+			if err := checkTimes(shift.StartTime, end, now); err != nil {
+				return err
+			}
+		}
+		if err := s.finish(ctx, shift, end, models.ShiftEndedByAdmin); err != nil {
+			return err
+		}
+		correction, err = s.recordCorrection(ctx, shift, models.ShiftCorrectionForceEnd, before, c)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return shift, correction, nil
+}
+
+// Edit правит время, зону и тип слота закрытой смены и пересчитывает
+// отработанное время и пунктуальность. Исходные значения остаются в истории правок.
+func (s *ShiftService) Edit(ctx context.Context, shiftID int, edit ShiftEdit, c Correction) (*models.Shift, *models.ShiftCorrection, error) {
+	if err := c.validate(); err != nil {
+		return nil, nil, err
+	}
+	if edit.Zone != nil {
+		if exists, err := s.zones.Exists(ctx, *edit.Zone); err != nil {
+			return nil, nil, err
+		} else if !exists {
+			return nil, nil, ErrInvalidZone
+		}
+	}
+	if edit.SlotTimeRange != nil {
+		if exists, err := s.shifts.TimeSlotExists(ctx, *edit.SlotTimeRange); err != nil {
+			return nil, nil, err
+		} else if !exists {
+			return nil, nil, ErrInvalidTimeSlot
+		}
+	}
+
+	var shift *models.Shift
+	var correction *models.ShiftCorrection
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if shift, err = s.lockCorrectable(ctx, shiftID); err != nil {
+			return err
+		}
+		if shift.EndTime == nil {
+			return ErrShiftNotEnded
+		}
+		before := shift.Values()
+
+		if edit.StartTime != nil {
+			shift.StartTime = *edit.StartTime
+		}
+		if edit.EndTime != nil {
+			shift.EndTime = edit.EndTime
+		}
+		if edit.Zone != nil {
+			shift.Zone = *edit.Zone
+		}
+		if edit.SlotTimeRange != nil {
+			shift.SlotTimeRange = *edit.SlotTimeRange
+		}
+		if shift.StartTime.Equal(before.StartTime) && shift.EndTime.Equal(*before.EndTime) &&
+			shift.Zone == before.Zone && shift.SlotTimeRange == before.SlotTimeRange {
+			return fmt.Errorf("%w: nothing to change", ErrInvalidCorrection)
+		}
+		if err := checkTimes(shift.StartTime, *shift.EndTime, s.now()); err != nil {
+			return err
+		}
+		// Пересекающиеся смены табель посчитал бы дважды
+		overlapping, err := s.shifts.OverlappingShift(ctx, shift.UserID, shift.ID, shift.StartTime, *shift.EndTime)
+		if err != nil {
+			return err
+		}
+		if overlapping != 0 {
+			field := "end_time"
+			if edit.StartTime != nil {
+				field = "start_time"
+			}
+			return invalidCorrection(field, response.FieldOverlaps, map[string]interface{}{"shift_id": overlapping})
+		}
+		// Смену нельзя перенести и в месяц с утверждённым табелем
+		if err := s.checkPeriod(ctx, shift.StartTime); err != nil {
+			return err
+		}
+
+		breaks, err := s.breaks.ListByShift(ctx, shift.ID)
+		if err != nil {
+			return err
+		}
+		shift.Breaks = breaks
+		recalculate(shift, breaks)
+		if err := s.shifts.Correct(ctx, shift); err != nil {
+			return err
+		}
+		correction, err = s.recordCorrection(ctx, shift, models.ShiftCorrectionEdit, before, c)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return shift, correction, nil
+}
+
+// Void аннулирует смену, например открытую по ошибке: она остаётся в базе,
+// но не учитывается в табеле, пунктуальности и отчётах, а плановый выход
+// снова считается неотработанным. Активная смена сначала закрывается.
+func (s *ShiftService) Void(ctx context.Context, shiftID int, c Correction) (*models.Shift, *models.ShiftCorrection, error) {
+	if err := c.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var shift *models.Shift
+	var correction *models.ShiftCorrection
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if shift, err = s.lockCorrectable(ctx, shiftID); err != nil {
+			return err
+		}
+		before := shift.Values()
+
+		now := s.now()
+		if shift.EndTime == nil {
+			if err := s.finish(ctx, shift, now, models.ShiftEndedByAdmin); err != nil {
+				return err
+			}
+		}
+		shift.VoidedAt = &now
+		if err := s.shifts.Correct(ctx, shift); err != nil {
+			return err
+		}
+		if err := s.plans.DetachShift(ctx, shift.ID); err != nil {
+			return err
+		}
+		correction, err = s.recordCorrection(ctx, shift, models.ShiftCorrectionVoid, before, c)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return shift, correction, nil
+}
+
+// CorrectionHistory — смена, её значения до первой правки и все правки.
+type CorrectionHistory struct {
+	Shift       models.Shift
+	Original    models.ShiftValues
+	Corrections []models.ShiftCorrection
+}
+
+func (s *ShiftService) Corrections(ctx context.Context, shiftID int) (*CorrectionHistory, error) {
+	shift, err := s.shifts.GetByID(ctx, shiftID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrShiftNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	corrections, err := s.corrections.ListByShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &CorrectionHistory{Shift: *shift, Original: shift.Values(), Corrections: corrections}
+	if len(corrections) > 0 {
+		history.Original = corrections[0].Before
+	} else {
+		history.Corrections = []models.ShiftCorrection{}
+	}
+	return history, nil
+}
+
+// lockCorrectable блокирует смену, которую ещё можно править: не аннулированную
+// и из месяца без утверждённого табеля.
+func (s *ShiftService) lockCorrectable(ctx context.Context, shiftID int) (*models.Shift, error) {
+	shift, err := s.shifts.LockByID(ctx, shiftID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrShiftNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if shift.VoidedAt != nil {
+		return nil, ErrShiftVoided
+	}
+	if err := s.checkPeriod(ctx, shift.StartTime); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// checkPeriod — ErrPeriodClosed, если табель за месяц startTime утверждён или закрыт.
+func (s *ShiftService) checkPeriod(ctx context.Context, startTime time.Time) error {
+	startTime = startTime.Local()
+	month := time.Date(startTime.Year(), startTime.Month(), 1, 0, 0, 0, 0, time.Local)
+	_, err := s.periods.GetPeriod(ctx, month)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return ErrPeriodClosed
+}
+
+// checkTimes проверяет время смены, заданное администратором.
+func checkTimes(start, end, now time.Time) error {
+	switch {
+	case !end.After(start):
+		return invalidCorrection("end_time", response.FieldNotAfterStart, nil)
+	case end.After(now):
+		return invalidCorrection("end_time", response.FieldInFuture, nil)
+	case end.Sub(start) > MaxShiftLength:
+		return invalidCorrection("end_time", response.FieldMaxDuration, map[string]interface{}{"hours": int(MaxShiftLength.Hours())})
+	}
+	return nil
+}
+
+func invalidCorrection(field, code string, params map[string]interface{}) error {
+	return response.Invalid(ErrInvalidCorrection, response.FieldError{Field: field, Code: code, Params: params})
+}
+
+func (s *ShiftService) recordCorrection(ctx context.Context, shift *models.Shift, action string, before models.ShiftValues, c Correction) (*models.ShiftCorrection, error) {
+	adminID := c.AdminID
+	correction := &models.ShiftCorrection{
+		ShiftID:   shift.ID,
+		Action:    action,
+		Reason:    c.Reason,
+		Comment:   c.Comment,
+		Before:    before,
+		After:     shift.Values(),
+		CreatedBy: &adminID,
+	}
+	if err := s.corrections.Create(ctx, correction); err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
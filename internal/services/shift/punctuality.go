@@ -0,0 +1,137 @@
+// services/shift/punctuality.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+// Assign планирует выходы сотрудников. Уже запланированные пропускаются,
+// возвращаются только созданные.
+func (s *ShiftService) Assign(ctx context.Context, planned []models.ShiftAssignment, createdBy int) ([]models.ShiftAssignment, error) {
+	for _, a := range planned {
+		exists, err := s.shifts.TimeSlotExists(ctx, a.SlotTimeRange)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrInvalidTimeSlot
+		}
+		if a.Zone == "" {
+			continue
+		}
+		if exists, err = s.zones.Exists(ctx, a.Zone); err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, ErrInvalidZone
+		}
+	}
+
+	var created []models.ShiftAssignment
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		for _, a := range planned {
+			err := s.plans.Create(ctx, &a, createdBy)
+			if errors.Is(err, repositories.ErrConflict) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			created = append(created, a)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// PunctualityReport — пунктуальность сотрудников за период и невыходы.
+type PunctualityReport struct {
+	Scouts  []models.PunctualityRow
+	NoShows []models.ShiftAssignment
+}
+
+// Punctuality считает опоздания, ранние уходы, автозакрытия и невыходы по дням
+// с from по to включительно. Невыход — плановый выход без смены после конца
+// слота; выходы, слот которых ещё идёт, в отчёт не попадают.
+func (s *ShiftService) Punctuality(ctx context.Context, from, to time.Time) (*PunctualityReport, error) {
+	shifts, err := s.shifts.ListEndedBetween(ctx, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.plans.ListBetween(ctx, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[int]*models.PunctualityRow)
+	row := func(userID int, username string) *models.PunctualityRow {
+		if r, ok := rows[userID]; ok {
+			return r
+		}
+		r := &models.PunctualityRow{UserID: userID, Username: username}
+		rows[userID] = r
+		return r
+	}
+
+	onTime := make(map[int]int)
+	for _, shift := range shifts {
+		r := row(shift.UserID, shift.Username)
+		flags := shift.Flags()
+		r.Shifts++
+		if flags.Late {
+			r.LateShifts++
+			r.LateMinutes += flags.LateMinutes
+		}
+		if flags.LeftEarly {
+			r.EarlyLeaveShifts++
+			r.EarlyLeaveMinutes += flags.EarlyLeaveMinutes
+		}
+		if flags.AutoClosed {
+			r.AutoClosedShifts++
+		}
+		if !flags.Late && !flags.LeftEarly && !flags.AutoClosed {
+			onTime[shift.UserID]++
+		}
+	}
+
+	report := &PunctualityReport{NoShows: []models.ShiftAssignment{}}
+	now := s.now()
+	for _, plan := range plans {
+		if plan.ShiftID == nil {
+			day, err := time.ParseInLocation("2006-01-02", plan.ShiftDate, time.Local)
+			if err != nil {
+				return nil, err
+			}
+			if _, end, ok := slotBounds(plan.SlotTimeRange, day); !ok || now.Before(end) {
+				continue
+			}
+		}
+		r := row(plan.UserID, plan.Username)
+		r.Planned++
+		if plan.ShiftID == nil {
+			r.NoShows++
+			report.NoShows = append(report.NoShows, plan)
+		}
+	}
+
+	report.Scouts = make([]models.PunctualityRow, 0, len(rows))
+	for userID, r := range rows {
+		if r.Shifts > 0 {
+			r.OnTimeRate = math.Round(float64(onTime[userID])/float64(r.Shifts)*100) / 100
+		}
+		report.Scouts = append(report.Scouts, *r)
+	}
+	sort.Slice(report.Scouts, func(i, j int) bool {
+		return report.Scouts[i].Username < report.Scouts[j].Username
+	})
+	return report, nil
+}
@@ -0,0 +1,104 @@
+// services/shift/report.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+// ErrInvalidReport — отчёт о смене не прошёл проверку; какое поле не так —
+// в response.ValidationError, которым обёрнута ошибка.
+var ErrInvalidReport = errors.New("invalid shift report")
+
+// Ограничения отчёта о смене.
+const (
+	MaxReportPhotos      = 5
+	MaxReportIssues      = 20
+	maxIssueLength       = 500
+	maxNotesLength       = 2000
+	maxScootersPerReport = 1000
+)
+
+// HandoverMaxAge — заметки смены старше этого следующему скауту не показываются.
+const HandoverMaxAge = 24 * time.Hour
+
+// NormalizeReport проверяет отчёт и приводит названия сервисов к написанию
+// из services (как их записывает бот в accepted_scooters.service).
+func NormalizeReport(report *models.ShiftReport, services []string) error {
+	known := make(map[string]string, len(services))
+	for _, service := range services {
+		known[strings.ToLower(service)] = service
+	}
+
+	scooters := make(map[string]int, len(report.Scooters))
+	for service, count := range report.Scooters {
+		name, ok := known[strings.ToLower(strings.TrimSpace(service))]
+		if !ok {
+			return invalidReport("scooters."+service, response.FieldOneOf, map[string]interface{}{"allowed": strings.Join(services, ", ")})
+		}
+		if count < 0 || count > maxScootersPerReport {
+			return invalidReport("scooters."+name, response.FieldOutOfRange, map[string]interface{}{"min": 0, "max": maxScootersPerReport})
+		}
+		if count > 0 {
+			scooters[name] += count
+		}
+	}
+	report.Scooters = scooters
+
+	issues := make([]string, 0, len(report.Issues))
+	for i, issue := range report.Issues {
+		if issue = strings.TrimSpace(issue); issue == "" {
+			continue
+		}
+		if utf8.RuneCountInString(issue) > maxIssueLength {
+			return invalidReport(fmt.Sprintf("issues[%d]", i), response.FieldTooLong, map[string]interface{}{"max": maxIssueLength})
+		}
+		issues = append(issues, issue)
+	}
+	if len(issues) > MaxReportIssues {
+		return invalidReport("issues", response.FieldTooMany, map[string]interface{}{"max": MaxReportIssues})
+	}
+	report.Issues = issues
+
+	report.Notes = strings.TrimSpace(report.Notes)
+	if utf8.RuneCountInString(report.Notes) > maxNotesLength {
+		return invalidReport("notes", response.FieldTooLong, map[string]interface{}{"max": maxNotesLength})
+	}
+	if len(report.Photos) > MaxReportPhotos {
+		return invalidReport("photos", response.FieldTooMany, map[string]interface{}{"max": MaxReportPhotos})
+	}
+	if report.Photos == nil {
+		report.Photos = []string{}
+	}
+	return nil
+}
+
+func invalidReport(field, code string, params map[string]interface{}) error {
+	return response.Invalid(ErrInvalidReport, response.FieldError{Field: field, Code: code, Params: params})
+}
+
+// Handover — отчёт предыдущей смены в зоне для следующего скаута; nil, если
+// за HandoverMaxAge в зоне никто не оставлял отчёт.
+func (s *ShiftService) Handover(ctx context.Context, zone string) (*models.ShiftWithReport, error) {
+	item, err := s.reports.LatestInZone(ctx, zone, s.now().Add(-HandoverMaxAge))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil
+	}
+	return item, err
+}
+
+// Reports — закрытые смены за день с отчётами (у кого отчёта нет, Report == nil).
+// zone "" — все зоны.
+func (s *ShiftService) Reports(ctx context.Context, zone string, day time.Time) ([]models.ShiftWithReport, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	return s.reports.ListEnded(ctx, zone, start, start.AddDate(0, 0, 1))
+}
@@ -0,0 +1,580 @@
+// services/shift/shift.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/listing"
+	"github.com/evn/eom_backendl/internal/pkg/response"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+var (
+	ErrShiftAlreadyActive = errors.New("shift already active")
+	ErrNoActiveShift      = errors.New("no active shift")
+	ErrOutsideStartWindow = errors.New("shift can't be started at this time")
+	ErrInvalidZone        = errors.New("invalid zone")
+	ErrInvalidTimeSlot    = errors.New("invalid time slot")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrAlreadyOnBreak     = errors.New("shift is already on break")
+	ErrNotOnBreak         = errors.New("shift is not on break")
+	ErrBreakLimitReached  = errors.New("break limit reached")
+)
+
+// EarlyStart — за сколько до начала слота можно открыть смену.
+const EarlyStart = 20 * time.Minute
+
+// PunctualityGrace — опоздание и ранний уход в этих пределах не отмечаются.
+const PunctualityGrace = 5 * time.Minute
+
+// DefaultBreakPolicy — лимиты для смен, чей слот убрали из справочника.
+var DefaultBreakPolicy = models.BreakPolicy{MaxBreaks: 1, MaxBreakMinutes: 30}
+
+var positionTitles = map[string]string{
+	"superadmin":  "Суперадмин",
+	"admin":       "Администратор",
+	"coordinator": "Координатор",
+	"scout":       "Скаут",
+	"user":        "Пользователь",
+}
+
+// PositionTitle — должность для смены по роли пользователя.
+func PositionTitle(role string) string {
+	if title, ok := positionTitles[role]; ok {
+		return title
+	}
+	return "Сотрудник"
+}
+
+// ShiftService — единственное место, где смены открываются и закрываются.
+// Каждая операция идёт в транзакции с блокировкой строк, поэтому параллельные
+// запросы (двойной тап, админ и автозакрытие одновременно) не расходятся.
+type ShiftService struct {
+	tx      repositories.Transactor
+	shifts  repositories.ShiftRepository
+	breaks  repositories.BreakRepository
+	users   repositories.UserRepository
+	zones   repositories.ZoneRepository
+	plans   repositories.AssignmentRepository
+	reports repositories.ReportRepository
+	// Правки администратора и утверждённые табели, которые они не должны менять
+	corrections repositories.CorrectionRepository
+	periods     repositories.TimesheetRepository
+	now         func() time.Time
+}
+
+func NewShiftService(tx repositories.Transactor, shifts repositories.ShiftRepository, breaks repositories.BreakRepository, users repositories.UserRepository, zones repositories.ZoneRepository, plans repositories.AssignmentRepository, reports repositories.ReportRepository, corrections repositories.CorrectionRepository, periods repositories.TimesheetRepository) *ShiftService {
+	return &ShiftService{
+		tx:          tx,
+		shifts:      shifts,
+		breaks:      breaks,
+		users:       users,
+		zones:       zones,
+		plans:       plans,
+		reports:     reports,
+		corrections: corrections,
+		periods:     periods,
+		now:         time.Now,
+	}
+}
+
+// StartInput — данные для открытия смены. SelfiePath — уже сохранённое
+// и проверенное селфи, Selfie — результаты его проверки.
+type StartInput struct {
+	UserID        int
+	SlotTimeRange string
+	Zone          string
+	SelfiePath    string
+	SelfieThumb   string
+	Selfie        models.SelfieMeta
+}
+
+// CheckStart проверяет, можно ли открыть смену, не меняя данных. Вызывается
+// до сохранения селфи, чтобы не писать файл для заведомо неудачного запроса.
+func (s *ShiftService) CheckStart(ctx context.Context, userID int, slotTimeRange, zone string) error {
+	if _, err := s.shifts.GetActiveByUser(ctx, userID); err == nil {
+		return ErrShiftAlreadyActive
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	return s.validateStart(ctx, slotTimeRange, zone)
+}
+
+func (s *ShiftService) validateStart(ctx context.Context, slotTimeRange, zone string) error {
+	if !CanStart(slotTimeRange, s.now()) {
+		return ErrOutsideStartWindow
+	}
+
+	zoneExists, err := s.zones.Exists(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if !zoneExists {
+		return ErrInvalidZone
+	}
+
+	slotExists, err := s.shifts.TimeSlotExists(ctx, slotTimeRange)
+	if err != nil {
+		return err
+	}
+	if !slotExists {
+		return ErrInvalidTimeSlot
+	}
+	return nil
+}
+
+// Start открывает смену. Строка пользователя блокируется, поэтому два
+// одновременных запроса не откроют две смены. Опоздание считается от начала
+// слота, смена отмечает плановый выход сотрудника на этот слот, если он был.
+func (s *ShiftService) Start(ctx context.Context, in StartInput) (*models.Shift, error) {
+	if err := s.validateStart(ctx, in.SlotTimeRange, in.Zone); err != nil {
+		return nil, err
+	}
+
+	var shift *models.Shift
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.users.LockForUpdate(ctx, in.UserID); errors.Is(err, repositories.ErrNotFound) {
+			return ErrUserNotFound
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := s.shifts.LockActiveByUser(ctx, in.UserID); err == nil {
+			return ErrShiftAlreadyActive
+		} else if !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+
+		role, err := s.users.GetRole(ctx, in.UserID)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		slotStart, _, ok := shiftWindow(in.SlotTimeRange, now)
+		if !ok {
+			return ErrInvalidTimeSlot
+		}
+		shift = &models.Shift{
+			UserID:        in.UserID,
+			StartTime:     now,
+			SlotTimeRange: in.SlotTimeRange,
+			Position:      PositionTitle(role),
+			Zone:          in.Zone,
+			SelfiePath:    in.SelfiePath,
+			SelfieThumb:   in.SelfieThumb,
+			Selfie:        in.Selfie,
+			LateMinutes:   overdueMinutes(now.Sub(slotStart)),
+		}
+		if err := s.shifts.Create(ctx, shift); errors.Is(err, repositories.ErrConflict) {
+			return ErrShiftAlreadyActive
+		} else if err != nil {
+			return err
+		}
+		return s.plans.AttachShift(ctx, in.UserID, slotStart.Format("2006-01-02"), in.SlotTimeRange, shift.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// End закрывает активную смену пользователя по его запросу. Отчёт (может
+// быть nil) сохраняется в той же транзакции, его проверяет NormalizeReport.
+func (s *ShiftService) End(ctx context.Context, userID int, report *models.ShiftReport) (*models.Shift, error) {
+	var shift *models.Shift
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if shift, err = s.lockActive(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.finish(ctx, shift, s.now(), models.ShiftEndedByUser); err != nil {
+			return err
+		}
+		if report == nil || report.Empty() {
+			return nil
+		}
+		report.ShiftID = shift.ID
+		return s.reports.Create(ctx, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// AutoEndResult — итог прохода автозакрытия.
+type AutoEndResult struct {
+	Ended        []models.Shift
+	BreaksClosed int // перерывы, закрытые по лимиту длительности
+}
+
+// AutoEnd закрывает смены, у которых закончился временной слот, и перерывы,
+// превысившие лимит длительности: после лимита время снова считается рабочим.
+func (s *ShiftService) AutoEnd(ctx context.Context) (*AutoEndResult, error) {
+	result := &AutoEndResult{}
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		shifts, err := s.shifts.LockActive(ctx)
+		if err != nil {
+			return err
+		}
+		policies, err := s.shifts.ListBreakPolicies(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		for i := range shifts {
+			shift := &shifts[i]
+			_, slotEnd, ok := shiftWindow(shift.SlotTimeRange, shift.StartTime)
+			if !ok {
+				log.Printf("Invalid slot time range %q of shift %d", shift.SlotTimeRange, shift.ID)
+				continue
+			}
+			if now.After(slotEnd) {
+				if err := s.finish(ctx, shift, now, models.ShiftEndedAuto); err != nil {
+					return fmt.Errorf("failed to end shift %d: %v", shift.ID, err)
+				}
+				result.Ended = append(result.Ended, *shift)
+				continue
+			}
+
+			open, err := s.breaks.GetOpen(ctx, shift.ID)
+			if errors.Is(err, repositories.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			policy, ok := policies[shift.SlotTimeRange]
+			if !ok {
+				policy = DefaultBreakPolicy
+			}
+			if end, overdue := breakEnd(*open, policy, now); overdue {
+				if err := s.breaks.Finish(ctx, open.ID, end, true); err != nil {
+					return fmt.Errorf("failed to close break %d: %v", open.ID, err)
+				}
+				result.BreaksClosed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Pause начинает перерыв в активной смене.
+func (s *ShiftService) Pause(ctx context.Context, userID int) (*models.ShiftBreak, error) {
+	var started *models.ShiftBreak
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		shift, err := s.lockActive(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		breaks, err := s.breaks.ListByShift(ctx, shift.ID)
+		if err != nil {
+			return err
+		}
+		shift.Breaks = breaks
+		if shift.OpenBreak() != nil {
+			return ErrAlreadyOnBreak
+		}
+
+		policy, err := s.breakPolicy(ctx, shift.SlotTimeRange)
+		if err != nil {
+			return err
+		}
+		if len(breaks) >= policy.MaxBreaks {
+			return ErrBreakLimitReached
+		}
+
+		started = &models.ShiftBreak{ShiftID: shift.ID, StartedAt: s.now()}
+		if err := s.breaks.Create(ctx, started); errors.Is(err, repositories.ErrConflict) {
+			return ErrAlreadyOnBreak
+		} else if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return started, nil
+}
+
+// Resume завершает текущий перерыв. Если сотрудник вернулся позже лимита,
+// перерыв всё равно засчитывается только до лимита.
+func (s *ShiftService) Resume(ctx context.Context, userID int) (*models.ShiftBreak, error) {
+	var ended *models.ShiftBreak
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		shift, err := s.lockActive(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		open, err := s.breaks.GetOpen(ctx, shift.ID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrNotOnBreak
+		} else if err != nil {
+			return err
+		}
+
+		policy, err := s.breakPolicy(ctx, shift.SlotTimeRange)
+		if err != nil {
+			return err
+		}
+		end, overdue := breakEnd(*open, policy, s.now())
+		if err := s.breaks.Finish(ctx, open.ID, end, overdue); err != nil {
+			return err
+		}
+		open.EndedAt = &end
+		open.AutoClosed = overdue
+		ended = open
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ended, nil
+}
+
+func (s *ShiftService) lockActive(ctx context.Context, userID int) (*models.Shift, error) {
+	shift, err := s.shifts.LockActiveByUser(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrNoActiveShift
+	}
+	return shift, err
+}
+
+func (s *ShiftService) breakPolicy(ctx context.Context, slotTimeRange string) (models.BreakPolicy, error) {
+	policy, err := s.shifts.GetBreakPolicy(ctx, slotTimeRange)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return DefaultBreakPolicy, nil
+	} else if err != nil {
+		return models.BreakPolicy{}, err
+	}
+	return *policy, nil
+}
+
+// overdueMinutes — отклонение от границы слота в минутах; в пределах
+// PunctualityGrace (и с другой стороны границы) — ноль.
+func overdueMinutes(d time.Duration) int {
+	if d <= PunctualityGrace {
+		return 0
+	}
+	return int(d / time.Minute)
+}
+
+// breakEnd — когда закрыть перерыв, если сотрудник возвращается в at:
+// не позже лимита длительности. overdue — лимит превышен.
+func breakEnd(b models.ShiftBreak, policy models.BreakPolicy, at time.Time) (time.Time, bool) {
+	limit := b.StartedAt.Add(policy.MaxLength())
+	if at.After(limit) {
+		return limit, true
+	}
+	return at, false
+}
+
+// finish — единый расчёт конца смены и отработанного времени. Незавершённый
+// перерыв закрывается вместе со сменой, время перерывов не оплачивается.
+// Ранний уход считается до конца слота (см. recalculate), reason — кто закрыл смену.
+func (s *ShiftService) finish(ctx context.Context, shift *models.Shift, endTime time.Time, reason string) error {
+	breaks, err := s.breaks.ListByShift(ctx, shift.ID)
+	if err != nil {
+		return err
+	}
+	for i := range breaks {
+		if breaks[i].EndedAt != nil {
+			continue
+		}
+		policy, err := s.breakPolicy(ctx, shift.SlotTimeRange)
+		if err != nil {
+			return err
+		}
+		end, overdue := breakEnd(breaks[i], policy, endTime)
+		if err := s.breaks.Finish(ctx, breaks[i].ID, end, overdue); err != nil {
+			return err
+		}
+		breaks[i].EndedAt = &end
+		breaks[i].AutoClosed = overdue
+	}
+
+	shift.EndTime = &endTime
+	shift.EndReason = reason
+	shift.Breaks = breaks
+	recalculate(shift, breaks)
+	return s.shifts.Finish(ctx, shift)
+}
+
+// recalculate пересчитывает по времени начала и конца закрытой смены
+// отработанное время, перерывы, опоздание и ранний уход. Перерывы считаются
+// только в пределах смены: после правки времени часть перерыва может выпасть.
+func recalculate(shift *models.Shift, breaks []models.ShiftBreak) {
+	end := *shift.EndTime
+	breakDuration := 0
+	for _, b := range breaks {
+		from, to := b.StartedAt, end
+		if b.EndedAt != nil && b.EndedAt.Before(to) {
+			to = *b.EndedAt
+		}
+		if from.Before(shift.StartTime) {
+			from = shift.StartTime
+		}
+		if to.After(from) {
+			breakDuration += int(to.Sub(from).Seconds())
+		}
+	}
+	duration := int(end.Sub(shift.StartTime).Seconds()) - breakDuration
+	if duration < 0 {
+		duration = 0
+	}
+
+	shift.WorkedDuration = duration
+	shift.BreakDuration = breakDuration
+	shift.LateMinutes, shift.EarlyLeaveMinutes = 0, 0
+	if slotStart, slotEnd, ok := shiftWindow(shift.SlotTimeRange, shift.StartTime); ok {
+		shift.LateMinutes = overdueMinutes(shift.StartTime.Sub(slotStart))
+		shift.EarlyLeaveMinutes = overdueMinutes(slotEnd.Sub(end))
+	}
+}
+
+// Active — активная смена пользователя вместе с её перерывами.
+func (s *ShiftService) Active(ctx context.Context, userID int) (*models.Shift, error) {
+	shift, err := s.shifts.GetActiveByUser(ctx, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrNoActiveShift
+	} else if err != nil {
+		return nil, err
+	}
+	if shift.Breaks, err = s.breaks.ListByShift(ctx, shift.ID); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// ListActive — все открытые смены вместе с перерывами.
+func (s *ShiftService) ListActive(ctx context.Context) ([]models.Shift, error) {
+	shifts, err := s.shifts.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(shifts))
+	for i := range shifts {
+		ids[i] = shifts[i].ID
+	}
+	breaks, err := s.breaks.ListByShifts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shifts {
+		shifts[i].Breaks = breaks[shifts[i].ID]
+	}
+	return shifts, nil
+}
+
+// Now — текущее время сервиса, по нему считаются незавершённые перерывы.
+func (s *ShiftService) Now() time.Time {
+	return s.now()
+}
+
+// History — страница закрытых смен сотрудника, параметры по repositories.ShiftHistorySpec.
+func (s *ShiftService) History(ctx context.Context, userID int, p listing.Params) ([]models.Shift, response.Page, error) {
+	return s.shifts.ListEndedByUser(ctx, userID, p)
+}
+
+// CanViewHistory — свою историю видит каждый, чужую только администраторы.
+func (s *ShiftService) CanViewHistory(ctx context.Context, viewerID, targetID int) (bool, error) {
+	if viewerID == targetID {
+		return true, nil
+	}
+	role, err := s.users.GetRole(ctx, viewerID)
+	if err != nil {
+		return false, err
+	}
+	return role == "admin" || role == "superadmin", nil
+}
+
+// Position — должность, под которой пользователь откроет смену.
+func (s *ShiftService) Position(ctx context.Context, userID int) (string, error) {
+	role, err := s.users.GetRole(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return PositionTitle(role), nil
+}
+
+func (s *ShiftService) TimeSlots(ctx context.Context) ([]string, error) {
+	return s.shifts.ListTimeSlots(ctx)
+}
+
+// TimeSlotsAvailableNow — слоты, смену в которых можно открыть прямо сейчас.
+func (s *ShiftService) TimeSlotsAvailableNow(ctx context.Context) ([]string, error) {
+	slots, err := s.shifts.ListTimeSlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	var available []string
+	for _, slot := range slots {
+		if CanStart(slot, now) {
+			available = append(available, slot)
+		}
+	}
+	return available, nil
+}
+
+// CanStart — смену можно открыть за EarlyStart до начала слота и до его конца
+// (включая последнюю минуту).
+func CanStart(slotTimeRange string, now time.Time) bool {
+	for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		start, end, ok := slotBounds(slotTimeRange, day)
+		if !ok {
+			return false
+		}
+		if !now.Before(start.Add(-EarlyStart)) && now.Before(end.Add(time.Minute)) {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftWindow находит границы слота, к которому относится смена, начатая в startTime.
+func shiftWindow(slotTimeRange string, startTime time.Time) (time.Time, time.Time, bool) {
+	for _, day := range []time.Time{startTime, startTime.AddDate(0, 0, -1)} {
+		start, end, ok := slotBounds(slotTimeRange, day)
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+		if !startTime.Before(start.Add(-EarlyStart)) && !startTime.After(end) {
+			return start, end, true
+		}
+	}
+	// Смена открыта вне окна (например, сгенерирована заранее) — берём слот дня начала
+	return slotBounds(slotTimeRange, startTime)
+}
+
+// slotBounds разбирает слот вида "07:00-15:00" на дату day. Слот через
+// полночь ("23:00-07:00") заканчивается на следующий день.
+func slotBounds(slotTimeRange string, day time.Time) (time.Time, time.Time, bool) {
+	var startHour, startMin, endHour, endMin int
+	_, err := fmt.Sscanf(response.NormalizeSlot(slotTimeRange), "%d:%d-%d:%d", &startHour, &startMin, &endHour, &endMin)
+	if err != nil || startHour > 23 || endHour > 24 || startMin > 59 || endMin > 59 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	day = day.Local()
+	start := time.Date(day.Year(), day.Month(), day.Day(), startHour, startMin, 0, 0, day.Location())
+	end := time.Date(day.Year(), day.Month(), day.Day(), endHour, endMin, 0, 0, day.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true
+}
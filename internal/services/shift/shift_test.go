@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+func TestRecalculate(t *testing.T) {
+	// slotBounds считает слот в локальном времени сервера
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, time.March, 10, hour, min, 0, 0, time.Local)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+	pause := func(from, to time.Time) models.ShiftBreak {
+		return models.ShiftBreak{StartedAt: from, EndedAt: &to}
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		breaks     []models.ShiftBreak
+		wantWorked time.Duration
+		wantBreak  time.Duration
+		wantLate   int
+		wantEarly  int
+	}{
+		{
+			name: "on time, one break", start: at(9, 0), end: at(18, 0),
+			breaks:     []models.ShiftBreak{pause(at(13, 0), at(13, 30))},
+			wantWorked: 8*time.Hour + 30*time.Minute, wantBreak: 30 * time.Minute,
+		},
+		{
+			name: "start moved past a break", start: at(13, 15), end: at(18, 0),
+			breaks:     []models.ShiftBreak{pause(at(13, 0), at(13, 30))},
+			wantWorked: 4*time.Hour + 30*time.Minute, wantBreak: 15 * time.Minute, wantLate: 255,
+		},
+		{
+			name: "end moved into a break", start: at(9, 0), end: at(13, 10),
+			breaks:     []models.ShiftBreak{pause(at(13, 0), at(13, 30))},
+			wantWorked: 4 * time.Hour, wantBreak: 10 * time.Minute, wantEarly: 290,
+		},
+		{
+			name: "break outside the shift", start: at(9, 0), end: at(12, 0),
+			breaks:     []models.ShiftBreak{pause(at(13, 0), at(13, 30))},
+			wantWorked: 3 * time.Hour, wantEarly: 360,
+		},
+		{
+			name: "open break ends with the shift", start: at(9, 0), end: at(18, 0),
+			breaks:     []models.ShiftBreak{{StartedAt: at(17, 0)}},
+			wantWorked: 8 * time.Hour, wantBreak: time.Hour,
+		},
+		{
+			name: "lateness within grace", start: at(9, 4), end: at(17, 57),
+			wantWorked: 8*time.Hour + 53*time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift := &models.Shift{StartTime: tt.start, EndTime: ptr(tt.end), SlotTimeRange: "09:00-18:00"}
+			recalculate(shift, tt.breaks)
+			if got := time.Duration(shift.WorkedDuration) * time.Second; got != tt.wantWorked {
+				t.Errorf("worked %v, want %v", got, tt.wantWorked)
+			}
+			if got := time.Duration(shift.BreakDuration) * time.Second; got != tt.wantBreak {
+				t.Errorf("break %v, want %v", got, tt.wantBreak)
+			}
+			if shift.LateMinutes != tt.wantLate || shift.EarlyLeaveMinutes != tt.wantEarly {
+				t.Errorf("late %d, early leave %d; want %d, %d",
+					shift.LateMinutes, shift.EarlyLeaveMinutes, tt.wantLate, tt.wantEarly)
+			}
+		})
+	}
+}
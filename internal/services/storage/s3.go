@@ -0,0 +1,153 @@
+// services/storage/s3.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config — параметры S3-совместимого хранилища. Для локальной проверки
+// подходит MinIO: Endpoint "localhost:9000", UseSSL false, Region "us-east-1".
+type S3Config struct {
+	Endpoint  string // host[:port] без схемы
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string // задаётся явно, чтобы подпись ссылок не требовала запроса к S3
+	UseSSL    bool
+}
+
+// S3Storage хранит загрузки в бакете S3 (AWS, MinIO, Yandex Object Storage и т.п.).
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Storage подключается к хранилищу и создаёт бакет, если его ещё нет.
+func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+	return &S3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+// mtimeMeta — метаданные объекта (x-amz-meta-mtime) с исходным временем
+// изменения файла; их пишет PutWithModTime, а ObjectInfo.ModTime берёт вместо
+// времени загрузки объекта.
+const mtimeMeta = "Mtime"
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// PutWithModTime сохраняет modTime в метаданных: время загрузки (LastModified)
+// в S3 задать нельзя.
+func (s *S3Storage) PutWithModTime(ctx context.Context, key string, r io.Reader, size int64, contentType string, modTime time.Time) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: map[string]string{mtimeMeta: modTime.UTC().Format(time.RFC3339Nano)},
+	})
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+	// GetObject ленивый: ошибка «нет такого ключа» видна только после Stat
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s3Error(err)
+	}
+	return obj, s3ObjectInfo(stat), nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	stat, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return s3ObjectInfo(stat), nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// List запрашивает метаданные объектов (расширение MinIO), чтобы ModTime
+// учитывал mtimeMeta. AWS S3 их в листинге не отдаёт: там ModTime перенесённых
+// файлов — время переноса, и срок хранения для них отсчитывается от него.
+func (s *S3Storage) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // останавливает листинг, если fn вернула ошибку
+
+	opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true, WithMetadata: true}
+	for obj := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(*s3ObjectInfo(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet подписывает ссылку локально, без запроса к S3.
+func (s *S3Storage) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, ttl, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func s3Error(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotExist
+	}
+	return err
+}
+
+func s3ObjectInfo(obj minio.ObjectInfo) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         obj.Key,
+		Size:        obj.Size,
+		ModTime:     obj.LastModified,
+		ContentType: obj.ContentType,
+	}
+	// StatObject отдаёт ключ без префикса, листинг MinIO — с ним
+	for _, name := range []string{mtimeMeta, "X-Amz-Meta-" + mtimeMeta} {
+		if modTime, err := time.Parse(time.RFC3339Nano, obj.UserMetadata[name]); err == nil {
+			info.ModTime = modTime
+			break
+		}
+	}
+	return info
+}
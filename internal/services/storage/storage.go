@@ -0,0 +1,77 @@
+// services/storage/storage.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrNotExist = errors.New("object does not exist")
+
+// ObjectInfo — сведения о файле в хранилище.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+}
+
+// Storage — хранилище загрузок (селфи, карты, фото заданий, сборки приложения).
+// Ключ — путь относительно /uploads, например "selfies/selfie_1_ab.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get возвращает ErrNotExist, если файла нет. Объект поддерживает Seek,
+	// поэтому его можно отдавать через http.ServeContent (Range, If-Modified-Since).
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete не считает ошибкой отсутствие файла.
+	Delete(ctx context.Context, key string) error
+	// List обходит все файлы, ключ которых начинается с prefix.
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
+
+// Presigner — хранилище, умеющее выдавать прямые временные ссылки на скачивание.
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// ModTimePutter — хранилище, которое при записи сохраняет исходное время
+// изменения файла. Его использует `server storage migrate`: иначе срок хранения
+// загрузок считался бы от переноса, а не от исходной загрузки.
+type ModTimePutter interface {
+	PutWithModTime(ctx context.Context, key string, r io.Reader, size int64, contentType string, modTime time.Time) error
+}
+
+const uploadsPrefix = "/uploads/"
+
+// KeyFromPath переводит путь из БД (/uploads/selfies/a.jpg) в ключ хранилища.
+// Пути вне /uploads и с выходом за его пределы отклоняются.
+func KeyFromPath(uploadPath string) (string, bool) {
+	if !strings.HasPrefix(uploadPath, uploadsPrefix) {
+		return "", false
+	}
+	return CleanKey(strings.TrimPrefix(uploadPath, uploadsPrefix))
+}
+
+// PathFromKey — обратное преобразование: ключ → путь, который хранится в БД и отдаётся клиентам.
+func PathFromKey(key string) string {
+	return uploadsPrefix + key
+}
+
+// CleanKey нормализует ключ и отсекает выход за пределы хранилища.
+func CleanKey(raw string) (string, bool) {
+	raw = strings.TrimPrefix(raw, "/")
+	if raw == "" {
+		return "", false
+	}
+	cleaned := path.Clean("/" + raw)
+	if cleaned == "/" || strings.Contains(cleaned, "..") {
+		return "", false
+	}
+	return strings.TrimPrefix(cleaned, "/"), true
+}
@@ -0,0 +1,66 @@
+// services/bot.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// BotClient отправляет сообщения от имени Telegram-бота.
+// Пользователю можно написать, только если он запускал бота.
+type BotClient struct {
+	token      string
+	httpClient *http.Client
+}
+
+func NewBotClient(token string) *BotClient {
+	return &BotClient{
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendMessage отправляет текст в личный чат (chat_id совпадает с telegram_id).
+func (b *BotClient) SendMessage(ctx context.Context, chatID int64, text string) error {
+	if b.token == "" {
+		return fmt.Errorf("telegram bot token is not configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		"https://api.telegram.org/bot"+b.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		// Текст ошибки содержит URL с токеном — не пробрасываем его
+		return fmt.Errorf("telegram request failed")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid telegram response: %v", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram sendMessage failed: %s", result.Description)
+	}
+	return nil
+}
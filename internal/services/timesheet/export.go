@@ -0,0 +1,180 @@
+// services/timesheet/export.go
+
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/pkg/csvsafe"
+	"github.com/xuri/excelize/v2"
+)
+
+// Листы XLSX-выгрузки: итоги за месяц, по неделям и по дням.
+const (
+	sheetMonth = "Месяц"
+	sheetWeeks = "Недели"
+	sheetDays  = "Дни"
+)
+
+// exportColumns — типы смен и зоны, встречающиеся в табеле; для каждого
+// в выгрузке своя колонка с часами.
+func exportColumns(ts *models.Timesheet) (slots, zones []string) {
+	seenSlots := map[string]bool{}
+	seenZones := map[string]bool{}
+	for _, e := range ts.Employees {
+		for slot := range e.Month.BySlot {
+			if !seenSlots[slot] {
+				seenSlots[slot] = true
+				slots = append(slots, slot)
+			}
+		}
+		for zone := range e.Month.ByZone {
+			if !seenZones[zone] {
+				seenZones[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+	}
+	sort.Strings(slots)
+	sort.Strings(zones)
+	return slots, zones
+}
+
+func exportHeader(first []string, slots, zones []string) []string {
+	header := append([]string{}, first...)
+	header = append(header, "shifts", "worked_hours", "regular_hours", "overtime_hours")
+	for _, slot := range slots {
+		header = append(header, "slot "+slot)
+	}
+	for _, zone := range zones {
+		header = append(header, "zone "+zone)
+	}
+	return header
+}
+
+// totalsRow — количество смен и часы (с долями) для одной строки выгрузки.
+func totalsRow(t models.TimesheetTotals, slots, zones []string) []interface{} {
+	row := []interface{}{t.Shifts, hours(t.WorkedMinutes), hours(t.RegularMinutes), hours(t.OvertimeMinutes)}
+	for _, slot := range slots {
+		row = append(row, hours(t.BySlot[slot]))
+	}
+	for _, zone := range zones {
+		row = append(row, hours(t.ByZone[zone]))
+	}
+	return row
+}
+
+func hours(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
+// WriteCSV выгружает табель одной таблицей: строки за каждый день, неделю и
+// месяц по каждому сотруднику, вид строки — в колонке level.
+func WriteCSV(w io.Writer, ts *models.Timesheet) error {
+	slots, zones := exportColumns(ts)
+	writer := csv.NewWriter(w)
+	writer.Write(exportHeader([]string{"period", "status", "user_id", "username", "first_name", "level", "key"}, slots, zones))
+
+	write := func(e models.TimesheetEmployee, level, key string, t models.TimesheetTotals) {
+		record := []string{ts.Period, ts.Status, strconv.Itoa(e.UserID), csvsafe.Cell(e.Username), csvsafe.Cell(e.FirstName), level, key}
+		for _, v := range totalsRow(t, slots, zones) {
+			switch v := v.(type) {
+			case float64:
+				record = append(record, strconv.FormatFloat(v, 'f', 2, 64))
+			default:
+				record = append(record, fmt.Sprint(v))
+			}
+		}
+		writer.Write(record)
+	}
+	for _, e := range ts.Employees {
+		for _, d := range e.Days {
+			write(e, "day", d.Date, d.TimesheetTotals)
+		}
+		for _, wk := range e.Weeks {
+			write(e, "week", wk.Week, wk.TimesheetTotals)
+		}
+		write(e, "month", ts.Period, e.Month)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteXLSX выгружает табель в книгу с листами за месяц, по неделям и по дням.
+func WriteXLSX(w io.Writer, ts *models.Timesheet) error {
+	slots, zones := exportColumns(ts)
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", sheetMonth); err != nil {
+		return err
+	}
+	for _, sheet := range []string{sheetWeeks, sheetDays} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+	}
+
+	person := []string{"user_id", "username", "first_name"}
+	sheets := []struct {
+		name   string
+		header []string
+		rows   func(e models.TimesheetEmployee) [][]interface{}
+	}{
+		{sheetMonth, exportHeader(person, slots, zones), func(e models.TimesheetEmployee) [][]interface{} {
+			return [][]interface{}{totalsRow(e.Month, slots, zones)}
+		}},
+		{sheetWeeks, exportHeader(append(person, "week", "from", "to"), slots, zones), func(e models.TimesheetEmployee) [][]interface{} {
+			var rows [][]interface{}
+			for _, wk := range e.Weeks {
+				rows = append(rows, append([]interface{}{wk.Week, wk.From, wk.To}, totalsRow(wk.TimesheetTotals, slots, zones)...))
+			}
+			return rows
+		}},
+		{sheetDays, exportHeader(append(person, "date"), slots, zones), func(e models.TimesheetEmployee) [][]interface{} {
+			var rows [][]interface{}
+			for _, d := range e.Days {
+				rows = append(rows, append([]interface{}{d.Date}, totalsRow(d.TimesheetTotals, slots, zones)...))
+			}
+			return rows
+		}},
+	}
+
+	for _, sheet := range sheets {
+		title := fmt.Sprintf("Табель за %s (%s)", ts.Period, ts.Status)
+		if err := f.SetSheetRow(sheet.name, "A1", &[]interface{}{title}); err != nil {
+			return err
+		}
+		header := make([]interface{}, len(sheet.header))
+		for i, h := range sheet.header {
+			header[i] = h
+		}
+		if err := f.SetSheetRow(sheet.name, "A2", &header); err != nil {
+			return err
+		}
+
+		rowNum := 3
+		for _, e := range ts.Employees {
+			for _, values := range sheet.rows(e) {
+				row := append([]interface{}{e.UserID, e.Username, e.FirstName}, values...)
+				cell, err := excelize.CoordinatesToCellName(1, rowNum)
+				if err != nil {
+					return err
+				}
+				if err := f.SetSheetRow(sheet.name, cell, &row); err != nil {
+					return err
+				}
+				rowNum++
+			}
+		}
+	}
+
+	_, err := f.WriteTo(w)
+	return err
+}
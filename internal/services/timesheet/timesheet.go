@@ -0,0 +1,333 @@
+// services/timesheet/timesheet.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+	"github.com/evn/eom_backendl/internal/repositories"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("invalid timesheet period")
+	ErrPeriodNotOver = errors.New("timesheet period is not over yet")
+	ErrOpenShifts    = errors.New("timesheet period has open shifts")
+	ErrNotApproved   = errors.New("timesheet is not approved")
+	ErrPeriodLocked  = errors.New("timesheet is locked")
+)
+
+// Способы округления отработанного за смену времени.
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// TimesheetService считает табель за месяц по закрытым сменам и ведёт его
+// утверждение. Утверждённый табель хранится снимком, поэтому правки смен,
+// смена правил в конфиге и удаление сотрудников его цифры уже не меняют.
+type TimesheetService struct {
+	tx    repositories.Transactor
+	repo  repositories.TimesheetRepository
+	rules models.TimesheetRules
+	now   func() time.Time
+}
+
+func NewTimesheetService(tx repositories.Transactor, repo repositories.TimesheetRepository, rules models.TimesheetRules) *TimesheetService {
+	return &TimesheetService{
+		tx:    tx,
+		repo:  repo,
+		rules: rules,
+		now:   time.Now,
+	}
+}
+
+// ParsePeriod разбирает месяц вида 2006-01 и возвращает его первое число
+// в часовом поясе сервера (в нём же считаются окна смен).
+func ParsePeriod(s string) (time.Time, error) {
+	month, err := time.ParseInLocation("2006-01", s, time.Local)
+	if err != nil {
+		return time.Time{}, ErrInvalidPeriod
+	}
+	return month, nil
+}
+
+// Get — табель за месяц: снимок, если он утверждён, иначе текущий расчёт.
+func (s *TimesheetService) Get(ctx context.Context, month time.Time) (*models.Timesheet, error) {
+	period, err := s.repo.GetPeriod(ctx, month)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return s.compute(ctx, month)
+	} else if err != nil {
+		return nil, err
+	}
+	return fromSnapshot(period)
+}
+
+// Approve фиксирует табель за прошедший месяц. Повторное утверждение
+// пересчитывает снимок, пока табель не закрыт.
+func (s *TimesheetService) Approve(ctx context.Context, month time.Time, userID int) (*models.Timesheet, error) {
+	var ts *models.Timesheet
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		end := month.AddDate(0, 1, 0)
+		if s.now().Before(end) {
+			return ErrPeriodNotOver
+		}
+		open, err := s.repo.CountOpenShifts(ctx, month, end)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: %d", ErrOpenShifts, open)
+		}
+
+		if ts, err = s.compute(ctx, month); err != nil {
+			return err
+		}
+		snapshot, err := json.Marshal(ts)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Approve(ctx, month, snapshot, userID); errors.Is(err, repositories.ErrConflict) {
+			return ErrPeriodLocked
+		} else if err != nil {
+			return err
+		}
+
+		period, err := s.repo.GetPeriod(ctx, month)
+		if err != nil {
+			return err
+		}
+		ts, err = fromSnapshot(period)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Reopen снимает утверждение, чтобы поправить смены и утвердить заново.
+// Закрытый табель вернуть нельзя.
+func (s *TimesheetService) Reopen(ctx context.Context, month time.Time) error {
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		err := s.repo.Reopen(ctx, month)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return s.statusError(ctx, month)
+		}
+		return err
+	})
+}
+
+// Lock закрывает утверждённый табель после расчёта зарплаты.
+func (s *TimesheetService) Lock(ctx context.Context, month time.Time, userID int) (*models.Timesheet, error) {
+	var ts *models.Timesheet
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		err := s.repo.Lock(ctx, month, userID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return s.statusError(ctx, month)
+		} else if err != nil {
+			return err
+		}
+		period, err := s.repo.GetPeriod(ctx, month)
+		if err != nil {
+			return err
+		}
+		ts, err = fromSnapshot(period)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// statusError объясняет, почему табель не в статусе approved.
+func (s *TimesheetService) statusError(ctx context.Context, month time.Time) error {
+	period, err := s.repo.GetPeriod(ctx, month)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrNotApproved
+	} else if err != nil {
+		return err
+	}
+	if period.Status == models.TimesheetLocked {
+		return ErrPeriodLocked
+	}
+	return ErrNotApproved
+}
+
+func fromSnapshot(period *models.TimesheetPeriod) (*models.Timesheet, error) {
+	var ts models.Timesheet
+	if err := json.Unmarshal(period.Snapshot, &ts); err != nil {
+		return nil, err
+	}
+	approvedAt := period.ApprovedAt
+	ts.Status = period.Status
+	ts.ApprovedBy = period.ApprovedBy
+	ts.ApprovedAt = &approvedAt
+	ts.LockedBy = period.LockedBy
+	ts.LockedAt = period.LockedAt
+	return &ts, nil
+}
+
+// compute считает табель по сменам. Смены берутся с понедельника первой
+// недели месяца: недельная переработка учитывает и дни прошлого месяца,
+// но в табель они не попадают.
+func (s *TimesheetService) compute(ctx context.Context, month time.Time) (*models.Timesheet, error) {
+	end := month.AddDate(0, 1, 0)
+	entries, err := s.repo.ListEntries(ctx, weekStart(month), end)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Timesheet{
+		Period:     month.Format("2006-01"),
+		Status:     models.TimesheetOpen,
+		Rules:      s.rules,
+		Employees:  buildEmployees(entries, month, s.rules),
+		ComputedAt: s.now(),
+	}, nil
+}
+
+// buildEmployees раскладывает смены по сотрудникам, дням и неделям месяца.
+// Каждая смена округляется отдельно; переработка сначала считается за день,
+// затем часы сверх недельной нормы переносятся в неё по порядку дней.
+func buildEmployees(entries []models.TimesheetEntry, month time.Time, rules models.TimesheetRules) []models.TimesheetEmployee {
+	type employeeDays struct {
+		employee models.TimesheetEmployee
+		days     map[string]*models.TimesheetDay
+	}
+	byUser := make(map[int]*employeeDays)
+	var order []int
+	for _, e := range entries {
+		ed, ok := byUser[e.UserID]
+		if !ok {
+			ed = &employeeDays{
+				employee: models.TimesheetEmployee{UserID: e.UserID, Username: e.Username, FirstName: e.FirstName},
+				days:     make(map[string]*models.TimesheetDay),
+			}
+			byUser[e.UserID] = ed
+			order = append(order, e.UserID)
+		}
+
+		date := e.StartTime.In(month.Location()).Format("2006-01-02")
+		day, ok := ed.days[date]
+		if !ok {
+			day = &models.TimesheetDay{Date: date, TimesheetTotals: newTotals()}
+			ed.days[date] = day
+		}
+		minutes := roundMinutes(e.WorkedSeconds, rules)
+		day.Shifts++
+		day.WorkedMinutes += minutes
+		day.BySlot[e.SlotTimeRange] += minutes
+		day.ByZone[e.Zone] += minutes
+	}
+
+	monthStart := month.Format("2006-01-02")
+	employees := make([]models.TimesheetEmployee, 0, len(order))
+	for _, userID := range order {
+		ed := byUser[userID]
+		dates := make([]string, 0, len(ed.days))
+		for date := range ed.days {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+
+		employee := ed.employee
+		employee.Month = newTotals()
+		employee.Days = []models.TimesheetDay{}
+		employee.Weeks = []models.TimesheetWeek{}
+		weekRegular := make(map[string]int)
+		for _, date := range dates {
+			day := ed.days[date]
+			week := isoWeek(date, month.Location())
+			applyOvertime(&day.TimesheetTotals, weekRegular[week], rules)
+			weekRegular[week] += day.RegularMinutes
+			if date < monthStart {
+				continue
+			}
+
+			employee.Days = append(employee.Days, *day)
+			addTotals(&employee.Month, day.TimesheetTotals)
+			if n := len(employee.Weeks); n == 0 || employee.Weeks[n-1].Week != week {
+				employee.Weeks = append(employee.Weeks, models.TimesheetWeek{Week: week, From: date, TimesheetTotals: newTotals()})
+			}
+			last := &employee.Weeks[len(employee.Weeks)-1]
+			last.To = date
+			addTotals(&last.TimesheetTotals, day.TimesheetTotals)
+		}
+		if len(employee.Days) > 0 {
+			employees = append(employees, employee)
+		}
+	}
+
+	sort.Slice(employees, func(i, j int) bool {
+		return employees[i].Username < employees[j].Username
+	})
+	return employees
+}
+
+// roundMinutes округляет отработанное за смену время до шага из правил.
+func roundMinutes(seconds int, rules models.TimesheetRules) int {
+	step := rules.RoundingMinutes * 60
+	if step <= 0 {
+		step = 60
+	}
+	var n int
+	switch rules.RoundingMode {
+	case RoundUp:
+		n = (seconds + step - 1) / step
+	case RoundDown:
+		n = seconds / step
+	default:
+		n = (seconds + step/2) / step
+	}
+	return n * step / 60
+}
+
+// applyOvertime делит часы дня на обычные и переработку. weekRegular —
+// обычные минуты, уже набранные в эту неделю до этого дня.
+func applyOvertime(day *models.TimesheetTotals, weekRegular int, rules models.TimesheetRules) {
+	regular := day.WorkedMinutes
+	if limit := rules.DailyOvertimeHours * 60; limit > 0 && regular > limit {
+		regular = limit
+	}
+	if limit := rules.WeeklyOvertimeHours * 60; limit > 0 && weekRegular+regular > limit {
+		regular = max(0, limit-weekRegular)
+	}
+	day.RegularMinutes = regular
+	day.OvertimeMinutes = day.WorkedMinutes - regular
+}
+
+func newTotals() models.TimesheetTotals {
+	return models.TimesheetTotals{BySlot: map[string]int{}, ByZone: map[string]int{}}
+}
+
+func addTotals(dst *models.TimesheetTotals, src models.TimesheetTotals) {
+	dst.Shifts += src.Shifts
+	dst.WorkedMinutes += src.WorkedMinutes
+	dst.RegularMinutes += src.RegularMinutes
+	dst.OvertimeMinutes += src.OvertimeMinutes
+	for slot, minutes := range src.BySlot {
+		dst.BySlot[slot] += minutes
+	}
+	for zone, minutes := range src.ByZone {
+		dst.ByZone[zone] += minutes
+	}
+}
+
+// weekStart — понедельник недели, в которую попадает day.
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func isoWeek(date string, loc *time.Location) string {
+	day, _ := time.ParseInLocation("2006-01-02", date, loc)
+	year, week := day.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
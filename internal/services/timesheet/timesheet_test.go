@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evn/eom_backendl/internal/models"
+)
+
+func TestRoundMinutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		step    int
+		mode    string
+		want    int
+	}{
+		{"nearest down", 7*60 + 29, 15, RoundNearest, 0},
+		{"nearest half up", 7*60 + 30, 15, RoundNearest, 15},
+		{"nearest exact", 8 * 3600, 15, RoundNearest, 480},
+		{"nearest above", 8*3600 + 8*60, 15, RoundNearest, 495},
+		{"up one second", 8*3600 + 1, 15, RoundUp, 495},
+		{"up exact", 8 * 3600, 15, RoundUp, 480},
+		{"down", 8*3600 + 14*60 + 59, 15, RoundDown, 480},
+		{"unknown mode is nearest", 8*3600 + 10*60, 15, "bogus", 495},
+		{"zero step rounds to minutes", 90, 0, RoundNearest, 2},
+		{"zero seconds", 0, 15, RoundUp, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := models.TimesheetRules{RoundingMinutes: tt.step, RoundingMode: tt.mode}
+			if got := roundMinutes(tt.seconds, rules); got != tt.want {
+				t.Errorf("roundMinutes(%d) = %d, want %d", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOvertime(t *testing.T) {
+	rules := models.TimesheetRules{DailyOvertimeHours: 8, WeeklyOvertimeHours: 40}
+	tests := []struct {
+		name         string
+		worked       int
+		weekRegular  int
+		rules        models.TimesheetRules
+		wantRegular  int
+		wantOvertime int
+	}{
+		{"under daily limit", 420, 0, rules, 420, 0},
+		{"over daily limit", 600, 0, rules, 480, 120},
+		{"reaches weekly limit", 480, 2160, rules, 240, 240},
+		{"week already full", 300, 2400, rules, 0, 300},
+		{"daily and weekly", 600, 2100, rules, 300, 300},
+		{"no limits", 900, 3000, models.TimesheetRules{}, 900, 0},
+		{"weekly only", 600, 2280, models.TimesheetRules{WeeklyOvertimeHours: 40}, 120, 480},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := models.TimesheetTotals{WorkedMinutes: tt.worked}
+			applyOvertime(&day, tt.weekRegular, tt.rules)
+			if day.RegularMinutes != tt.wantRegular || day.OvertimeMinutes != tt.wantOvertime {
+				t.Errorf("regular %d, overtime %d; want %d, %d",
+					day.RegularMinutes, day.OvertimeMinutes, tt.wantRegular, tt.wantOvertime)
+			}
+		})
+	}
+}
+
+// Неделя 23 февраля — 1 марта 2026 начинается в феврале: часы прошлого месяца
+// выбирают недельную норму, но в мартовский табель не попадают.
+func TestBuildEmployeesWeeklyOvertimeAcrossMonths(t *testing.T) {
+	month := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	rules := models.TimesheetRules{RoundingMinutes: 15, RoundingMode: RoundNearest, DailyOvertimeHours: 8, WeeklyOvertimeHours: 40}
+	shift := func(day time.Time, hours int) models.TimesheetEntry {
+		return models.TimesheetEntry{UserID: 7, Username: "scout", StartTime: day.Add(9 * time.Hour),
+			SlotTimeRange: "09:00-18:00", Zone: "center", WorkedSeconds: hours * 3600}
+	}
+
+	var entries []models.TimesheetEntry
+	for day := 23; day <= 27; day++ {
+		entries = append(entries, shift(time.Date(2026, time.February, day, 0, 0, 0, 0, time.UTC), 9))
+	}
+	entries = append(entries,
+		shift(time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), 6),
+		shift(time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC), 6),
+	)
+
+	employees := buildEmployees(entries, month, rules)
+	if len(employees) != 1 {
+		t.Fatalf("got %d employees, want 1", len(employees))
+	}
+	e := employees[0]
+
+	if len(e.Days) != 2 || e.Days[0].Date != "2026-03-01" || e.Days[1].Date != "2026-03-02" {
+		t.Fatalf("days: %+v", e.Days)
+	}
+	// 5 × 8 ч обычных в феврале закрыли норму недели: 1 марта — вся переработка
+	if d := e.Days[0]; d.RegularMinutes != 0 || d.OvertimeMinutes != 360 {
+		t.Errorf("2026-03-01: regular %d, overtime %d; want 0, 360", d.RegularMinutes, d.OvertimeMinutes)
+	}
+	if d := e.Days[1]; d.RegularMinutes != 360 || d.OvertimeMinutes != 0 {
+		t.Errorf("2026-03-02: regular %d, overtime %d; want 360, 0", d.RegularMinutes, d.OvertimeMinutes)
+	}
+
+	if e.Month.Shifts != 2 || e.Month.WorkedMinutes != 720 || e.Month.RegularMinutes != 360 || e.Month.OvertimeMinutes != 360 {
+		t.Errorf("month totals: %+v", e.Month)
+	}
+	if len(e.Weeks) != 2 || e.Weeks[0].Week != "2026-W09" || e.Weeks[0].From != "2026-03-01" || e.Weeks[1].Week != "2026-W10" {
+		t.Errorf("weeks: %+v", e.Weeks)
+	}
+}